@@ -0,0 +1,110 @@
+package achievements
+
+import (
+	"backlog-backend/config"
+	"backlog-backend/dto"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrNotConfigured is returned when no provider base URL has been set
+var ErrNotConfigured = errors.New("achievement provider not configured")
+
+// Provider fetches the achievement list and unlock state of a game from an external source
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context, externalGameID, account string) ([]dto.AchievementRequest, error)
+}
+
+// HTTPProvider talks to a JSON API:
+//
+//	GET {BaseURL}/games/{externalGameID}/achievements?account={account}
+//
+// answering with an array of achievements or {"achievements": [...]}.
+// Pointing BaseURL at a local stub is enough to develop without the real service.
+type HTTPProvider struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+func NewHTTPProvider(baseURL, apiKey string) *HTTPProvider {
+	return &HTTPProvider{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		APIKey:  apiKey,
+		Client:  &http.Client{Timeout: 15 * time.Second},
+	}
+}
+
+// FromConfig builds the configured provider. It returns nil when no URL is configured.
+func FromConfig(cfg config.Achievements) Provider {
+	if cfg.ProviderURL == "" {
+		return nil
+	}
+	return NewHTTPProvider(cfg.ProviderURL, cfg.ProviderKey)
+}
+
+func (p *HTTPProvider) Name() string {
+	return p.BaseURL
+}
+
+func (p *HTTPProvider) Fetch(ctx context.Context, externalGameID, account string) ([]dto.AchievementRequest, error) {
+	if p.BaseURL == "" {
+		return nil, ErrNotConfigured
+	}
+
+	endpoint := fmt.Sprintf("%s/games/%s/achievements", p.BaseURL, url.PathEscape(externalGameID))
+	if account != "" {
+		endpoint += "?account=" + url.QueryEscape(account)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if p.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+p.APIKey)
+	}
+
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("provider responded %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, err
+	}
+	return Decode(body)
+}
+
+// Decode parses either a bare JSON array of achievements or an object with an
+// "achievements" array, the two shapes accepted for imports and providers
+func Decode(data []byte) ([]dto.AchievementRequest, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) > 0 && data[0] == '[' {
+		var list []dto.AchievementRequest
+		if err := json.Unmarshal(data, &list); err != nil {
+			return nil, err
+		}
+		return list, nil
+	}
+	var wrapped dto.ImportAchievementsRequest
+	if err := json.Unmarshal(data, &wrapped); err != nil {
+		return nil, err
+	}
+	return wrapped.Achievements, nil
+}
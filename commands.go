@@ -0,0 +1,172 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"backlog-backend/config"
+	"backlog-backend/database"
+	"backlog-backend/handlers"
+	"backlog-backend/images"
+	"backlog-backend/storage"
+)
+
+// commandEnv is what the admin commands run with
+type commandEnv struct {
+	cfg   *config.Config
+	store storage.Storage
+	h     *handlers.Handler
+}
+
+// runCommand dispatches the admin commands available from the command line
+func runCommand(env commandEnv, name string, args []string) error {
+	switch name {
+	case "backfill-images":
+		return backfillImages(env, args)
+	case "gc-images":
+		return gcImages(env, args)
+	case "dedupe-images":
+		return dedupeImages(env, args)
+	case "analyze-images":
+		return analyzeImages(env, args)
+	}
+	return fmt.Errorf("unknown command %q (available: backfill-images, gc-images, dedupe-images, analyze-images)", name)
+}
+
+// analyzeImages computes the cover placeholders (BlurHash, colors, aspect
+// ratio) of images uploaded before they existed
+func analyzeImages(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("analyze-images", flag.ExitOnError)
+	force := fs.Bool("force", false, "analyse images that already have placeholder data")
+	fs.Parse(args)
+
+	database.Connect(env.cfg.Database)
+	if database.DB == nil {
+		return fmt.Errorf("analyze-images needs the database")
+	}
+	analyzed, failed, err := env.h.AnalyzeImages(context.Background(), *force)
+	if err != nil {
+		return err
+	}
+	log.Printf("analyze-images: %d analysed, %d failed", analyzed, failed)
+	return nil
+}
+
+// dedupeImages moves uploads from before content addressing to their
+// content-addressed names, collapsing identical files
+func dedupeImages(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("dedupe-images", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report the renames without changing anything")
+	fs.Parse(args)
+
+	database.Connect(env.cfg.Database)
+	if database.DB == nil {
+		return fmt.Errorf("dedupe-images needs the database")
+	}
+	report, err := env.h.DeduplicateImages(context.Background(), *dryRun)
+	if err != nil {
+		return err
+	}
+	for from, to := range report.Renamed {
+		log.Printf("%s -> %s", from, to)
+	}
+	log.Printf("dedupe-images: %d files renamed, %d duplicates (%d bytes)", len(report.Renamed), report.Duplicates, report.FreedBytes)
+	return nil
+}
+
+// gcImages runs the image garbage collector once and prints what it removed,
+// or with -dry-run what it would remove
+func gcImages(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("gc-images", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "report orphaned images without deleting them")
+	grace := fs.Duration("grace", env.h.ImageGCGrace(), "minimum age of an unreferenced image before it is removed")
+	fs.Parse(args)
+
+	database.Connect(env.cfg.Database)
+	if database.DB == nil {
+		return fmt.Errorf("gc-images needs the database")
+	}
+	report, err := env.h.CollectOrphanImages(context.Background(), *grace, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	verb := "removed"
+	if *dryRun {
+		verb = "would remove"
+	}
+	for _, o := range report.Orphans {
+		log.Printf("%s %s (%s, %d bytes, age %s)", verb, o.Name, o.Reason, o.Size, o.Age.Round(time.Minute))
+	}
+	log.Printf("gc-images: scanned %d files, %d referenced, %d in grace period, %s %d (%d bytes), %d image records without a file",
+		report.Scanned, report.Referenced, report.InGrace, verb, len(report.Orphans), report.FreedBytes, report.StaleImages)
+	return nil
+}
+
+// backfillImages generates the derived sizes for images uploaded before they
+// existed. Images that already have every size are skipped unless -force.
+func backfillImages(env commandEnv, args []string) error {
+	fs := flag.NewFlagSet("backfill-images", flag.ExitOnError)
+	force := fs.Bool("force", false, "regenerate sizes that already exist")
+	dryRun := fs.Bool("dry-run", false, "list the images that would be processed without writing anything")
+	fs.Parse(args)
+
+	ctx := context.Background()
+	store := env.store
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		return err
+	}
+	stored := make(map[string]bool, len(objects))
+	for _, o := range objects {
+		stored[o.Name] = true
+	}
+
+	var processed, skipped, failed int
+	for _, o := range objects {
+		name := o.Name
+		if strings.Contains(name, "/") || images.IsVariant(name) {
+			continue // Resizes and derived sizes
+		}
+		if !*force && hasAllSizes(stored, name) {
+			skipped++
+			continue
+		}
+		if *dryRun {
+			log.Printf("would process %s", name)
+			processed++
+			continue
+		}
+
+		img, err := images.Load(ctx, store, name)
+		if err == nil {
+			_, err = images.SaveVariants(ctx, store, name, img, env.cfg.Images.Precompress)
+		}
+		if err != nil {
+			log.Printf("%s: %v", name, err)
+			failed++
+			continue
+		}
+		processed++
+	}
+
+	log.Printf("backfill-images: %d processed, %d already complete, %d failed", processed, skipped, failed)
+	return nil
+}
+
+func hasAllSizes(stored map[string]bool, name string) bool {
+	for _, s := range images.Sizes {
+		if !stored[images.VariantName(name, s.Name, ".jpg")] && !stored[images.VariantName(name, s.Name, ".png")] {
+			return false
+		}
+		if images.HasWebPVariants(filepath.Ext(name)) && !stored[images.VariantName(name, s.Name, ".webp")] {
+			return false
+		}
+	}
+	return true
+}
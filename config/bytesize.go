@@ -0,0 +1,55 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ByteSize is a number of bytes written as "500", "512KB", "100MB" or "2GB"
+// (binary units), or "unlimited"
+type ByteSize int64
+
+// Unlimited as a ByteSize disables a limit
+const Unlimited ByteSize = -1
+
+// ParseByteSize parses a size such as "100MB", or "unlimited"/"-1"
+func ParseByteSize(s string) (ByteSize, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if s == "UNLIMITED" || s == "-1" {
+		return Unlimited, nil
+	}
+	units := []struct {
+		suffix string
+		size   int64
+	}{{"TB", 1 << 40}, {"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}}
+	unit := int64(1)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSpace(strings.TrimSuffix(s, u.suffix)), u.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q, expected a size like 100MB or unlimited", s)
+	}
+	return ByteSize(n * float64(unit)), nil
+}
+
+// UnmarshalText lets TOML files use sizes with units
+func (b *ByteSize) UnmarshalText(text []byte) error {
+	size, err := ParseByteSize(string(text))
+	if err != nil {
+		return err
+	}
+	*b = size
+	return nil
+}
+
+// UnmarshalYAML accepts both plain numbers and sizes with units
+func (b *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	return b.UnmarshalText([]byte(node.Value))
+}
@@ -0,0 +1,384 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Modes. Production refuses to start with missing or insecure secrets.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// Config is the typed configuration of the API. Load fills it from, in
+// increasing precedence: the defaults, an optional YAML or TOML file, .env
+// and the environment. Every setting has an environment variable, named in
+// the comment next to it. main loads it once, builds the storage from it and
+// passes both to handlers.New; nothing reads it from a package variable.
+type Config struct {
+	Env  string `yaml:"env" toml:"env"`   // APP_ENV: development (default) or production
+	Port string `yaml:"port" toml:"port"` // PORT
+	// Local cache of rendered tier lists and downloaded covers, CACHE_DIR,
+	// default ./cache. Each instance may use its own.
+	CacheDir string `yaml:"cache_dir" toml:"cache_dir"`
+
+	Database     Database     `yaml:"database" toml:"database"`
+	Auth         Auth         `yaml:"auth" toml:"auth"`
+	Storage      Storage      `yaml:"storage" toml:"storage"`
+	Images       Images       `yaml:"images" toml:"images"`
+	Achievements Achievements `yaml:"achievements" toml:"achievements"`
+}
+
+type Database struct {
+	Host     string `yaml:"host" toml:"host"`         // DB_HOST
+	Port     string `yaml:"port" toml:"port"`         // DB_PORT
+	User     string `yaml:"user" toml:"user"`         // DB_USER
+	Password string `yaml:"password" toml:"password"` // DB_PASSWORD
+	Name     string `yaml:"name" toml:"name"`         // DB_NAME
+	SSLMode  string `yaml:"sslmode" toml:"sslmode"`   // DB_SSLMODE
+	TimeZone string `yaml:"timezone" toml:"timezone"` // DB_TIMEZONE
+}
+
+// DSN is the Postgres connection string. Empty settings are left out so the
+// driver defaults apply.
+func (d Database) DSN() string {
+	var parts []string
+	for _, kv := range [][2]string{
+		{"host", d.Host}, {"user", d.User}, {"password", d.Password}, {"dbname", d.Name},
+		{"port", d.Port}, {"sslmode", d.SSLMode}, {"TimeZone", d.TimeZone},
+	} {
+		if kv[1] != "" {
+			value := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(kv[1])
+			parts = append(parts, fmt.Sprintf("%s='%s'", kv[0], value))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+type Auth struct {
+	JWTSecret string        `yaml:"jwt_secret" toml:"jwt_secret"` // JWT_SECRET, required in production
+	TokenTTL  time.Duration `yaml:"token_ttl" toml:"token_ttl"`   // JWT_TTL, default 72h
+}
+
+type Storage struct {
+	Driver   string `yaml:"driver" toml:"driver"`       // STORAGE_DRIVER: local (default) or s3
+	LocalDir string `yaml:"local_dir" toml:"local_dir"` // STORAGE_LOCAL_DIR, default ./images
+	S3       S3     `yaml:"s3" toml:"s3"`
+	// Upload quota per role, STORAGE_QUOTA_<ROLE>. Roles without one use the
+	// quota of "user".
+	Quotas map[string]ByteSize `yaml:"quotas" toml:"quotas"`
+}
+
+type S3 struct {
+	Endpoint        string `yaml:"endpoint" toml:"endpoint"`                   // S3_ENDPOINT
+	Bucket          string `yaml:"bucket" toml:"bucket"`                       // S3_BUCKET
+	AccessKeyID     string `yaml:"access_key_id" toml:"access_key_id"`         // S3_ACCESS_KEY_ID
+	SecretAccessKey string `yaml:"secret_access_key" toml:"secret_access_key"` // S3_SECRET_ACCESS_KEY
+	Region          string `yaml:"region" toml:"region"`                       // S3_REGION
+	UseSSL          bool   `yaml:"use_ssl" toml:"use_ssl"`                     // S3_USE_SSL, default true
+	Prefix          string `yaml:"prefix" toml:"prefix"`                       // S3_PREFIX
+}
+
+type Images struct {
+	FetchAllowedHosts    []string      `yaml:"fetch_allowed_hosts" toml:"fetch_allowed_hosts"`       // IMAGE_FETCH_ALLOWED_HOSTS, comma-separated
+	LocalizeRemoteCovers bool          `yaml:"localize_remote_covers" toml:"localize_remote_covers"` // LOCALIZE_REMOTE_COVERS
+	Precompress          bool          `yaml:"precompress" toml:"precompress"`                       // IMAGE_PRECOMPRESS
+	SignedURLs           bool          `yaml:"signed_urls" toml:"signed_urls"`                       // IMAGE_SIGNED_URLS, default true, required in production
+	URLSecret            string        `yaml:"url_secret" toml:"url_secret"`                         // IMAGE_URL_SECRET, defaults to the JWT secret
+	URLTTL               time.Duration `yaml:"url_ttl" toml:"url_ttl"`                               // IMAGE_URL_TTL, default 24h
+	GCInterval           time.Duration `yaml:"gc_interval" toml:"gc_interval"`                       // IMAGE_GC_INTERVAL, default 6h, 0 disables
+	GCGrace              time.Duration `yaml:"gc_grace" toml:"gc_grace"`                             // IMAGE_GC_GRACE, default 24h
+}
+
+type Achievements struct {
+	ProviderURL string `yaml:"provider_url" toml:"provider_url"` // ACHIEVEMENT_PROVIDER_URL, empty disables syncing
+	ProviderKey string `yaml:"provider_key" toml:"provider_key"` // ACHIEVEMENT_PROVIDER_KEY
+}
+
+// insecureSecret is the default JWT secret, only accepted in development
+const insecureSecret = "secret"
+
+// minSecretLength is the shortest secret accepted in production
+const minSecretLength = 32
+
+// Default is the configuration with nothing set
+func Default() *Config {
+	return &Config{
+		Env:      EnvDevelopment,
+		Port:     "3000",
+		CacheDir: "./cache",
+		Auth:     Auth{JWTSecret: insecureSecret, TokenTTL: 72 * time.Hour},
+		Storage: Storage{
+			Driver:   "local",
+			LocalDir: "./images",
+			S3:       S3{UseSSL: true},
+			Quotas:   map[string]ByteSize{"user": 100 << 20, "admin": Unlimited},
+		},
+		Images: Images{
+			SignedURLs: true,
+			URLTTL:     24 * time.Hour,
+			GCInterval: 6 * time.Hour,
+			GCGrace:    24 * time.Hour,
+		},
+	}
+}
+
+// Load reads the configuration. The file is CONFIG_FILE, or config.yaml,
+// config.yml or config.toml in the working directory when one exists; its
+// format follows the extension. Variables from .env do not override the
+// environment. Invalid values, and in production missing or insecure
+// secrets, fail the load.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using default/env vars")
+	}
+	cfg := Default()
+
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		for _, candidate := range []string{"config.yaml", "config.yml", "config.toml"} {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path != "" {
+		if err := loadFile(cfg, path); err != nil {
+			return nil, err
+		}
+		log.Printf("Loaded configuration from %s", path)
+	}
+
+	if err := applyEnv(cfg); err != nil {
+		return nil, err
+	}
+	hosts := cfg.Images.FetchAllowedHosts[:0]
+	for _, h := range cfg.Images.FetchAllowedHosts {
+		if h = strings.ToLower(strings.TrimSpace(h)); h != "" {
+			hosts = append(hosts, h)
+		}
+	}
+	cfg.Images.FetchAllowedHosts = hosts
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.Auth.JWTSecret == insecureSecret {
+		log.Printf("JWT_SECRET is not set, using an insecure default (refused when APP_ENV=%s)", EnvProduction)
+	}
+	return cfg, nil
+}
+
+func loadFile(cfg *Config, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading config file: %w", err)
+	}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, cfg)
+	case ".toml":
+		_, err = toml.Decode(string(data), cfg)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q (expected .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// applyEnv overrides the configuration with the environment variables that
+// are set, collecting every value that cannot be parsed
+func applyEnv(cfg *Config) error {
+	var errs []error
+	str := func(dst *string, key string) {
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			*dst = v
+		}
+	}
+	boolean := func(dst *bool, key string) {
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			b, err := strconv.ParseBool(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: expected true or false, got %q", key, v))
+				return
+			}
+			*dst = b
+		}
+	}
+	list := func(dst *[]string, key string) {
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			*dst = strings.Split(v, ",")
+		}
+	}
+	duration := func(dst *time.Duration, key string) {
+		if v, ok := os.LookupEnv(key); ok && v != "" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: expected a duration like 1h30m, got %q", key, v))
+				return
+			}
+			*dst = d
+		}
+	}
+
+	str(&cfg.Env, "APP_ENV")
+	str(&cfg.Port, "PORT")
+	str(&cfg.CacheDir, "CACHE_DIR")
+
+	str(&cfg.Database.Host, "DB_HOST")
+	str(&cfg.Database.Port, "DB_PORT")
+	str(&cfg.Database.User, "DB_USER")
+	str(&cfg.Database.Password, "DB_PASSWORD")
+	str(&cfg.Database.Name, "DB_NAME")
+	str(&cfg.Database.SSLMode, "DB_SSLMODE")
+	str(&cfg.Database.TimeZone, "DB_TIMEZONE")
+
+	str(&cfg.Auth.JWTSecret, "JWT_SECRET")
+	duration(&cfg.Auth.TokenTTL, "JWT_TTL")
+
+	str(&cfg.Storage.Driver, "STORAGE_DRIVER")
+	str(&cfg.Storage.LocalDir, "STORAGE_LOCAL_DIR")
+	str(&cfg.Storage.S3.Endpoint, "S3_ENDPOINT")
+	str(&cfg.Storage.S3.Bucket, "S3_BUCKET")
+	str(&cfg.Storage.S3.AccessKeyID, "S3_ACCESS_KEY_ID")
+	str(&cfg.Storage.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	str(&cfg.Storage.S3.Region, "S3_REGION")
+	boolean(&cfg.Storage.S3.UseSSL, "S3_USE_SSL")
+	str(&cfg.Storage.S3.Prefix, "S3_PREFIX")
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		role, ok := strings.CutPrefix(key, "STORAGE_QUOTA_")
+		if !ok || role == "" || value == "" {
+			continue
+		}
+		size, err := ParseByteSize(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
+		}
+		if cfg.Storage.Quotas == nil {
+			cfg.Storage.Quotas = map[string]ByteSize{}
+		}
+		cfg.Storage.Quotas[strings.ToLower(role)] = size
+	}
+
+	list(&cfg.Images.FetchAllowedHosts, "IMAGE_FETCH_ALLOWED_HOSTS")
+	boolean(&cfg.Images.LocalizeRemoteCovers, "LOCALIZE_REMOTE_COVERS")
+	boolean(&cfg.Images.Precompress, "IMAGE_PRECOMPRESS")
+	boolean(&cfg.Images.SignedURLs, "IMAGE_SIGNED_URLS")
+	str(&cfg.Images.URLSecret, "IMAGE_URL_SECRET")
+	duration(&cfg.Images.URLTTL, "IMAGE_URL_TTL")
+	// "0" disables the collector; ParseDuration accepts it without a unit
+	duration(&cfg.Images.GCInterval, "IMAGE_GC_INTERVAL")
+	duration(&cfg.Images.GCGrace, "IMAGE_GC_GRACE")
+
+	str(&cfg.Achievements.ProviderURL, "ACHIEVEMENT_PROVIDER_URL")
+	str(&cfg.Achievements.ProviderKey, "ACHIEVEMENT_PROVIDER_KEY")
+
+	return errors.Join(errs...)
+}
+
+// Validate checks the values and, in production, that the secrets are set
+// and strong enough. All problems are reported together.
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	if c.Env != EnvDevelopment && c.Env != EnvProduction {
+		fail("APP_ENV must be %s or %s, got %q", EnvDevelopment, EnvProduction, c.Env)
+	}
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		fail("PORT must be a port number, got %q", c.Port)
+	}
+	if c.CacheDir == "" {
+		fail("CACHE_DIR must not be empty")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		fail("JWT_TTL must be positive")
+	}
+
+	switch c.Storage.Driver {
+	case "local":
+		if c.Storage.LocalDir == "" {
+			fail("STORAGE_LOCAL_DIR is required for the local storage driver")
+		}
+	case "s3":
+		if c.Storage.S3.Endpoint == "" || c.Storage.S3.Bucket == "" {
+			fail("S3_ENDPOINT and S3_BUCKET are required for the s3 storage driver")
+		}
+	default:
+		fail("STORAGE_DRIVER must be local or s3, got %q", c.Storage.Driver)
+	}
+	for role, quota := range c.Storage.Quotas {
+		if quota < 0 && quota != Unlimited {
+			fail("storage quota of %s must not be negative", role)
+		}
+	}
+
+	if c.Images.SignedURLs && c.Images.URLTTL <= 0 {
+		fail("IMAGE_URL_TTL must be positive when IMAGE_SIGNED_URLS is enabled")
+	}
+	if c.Images.GCInterval < 0 || c.Images.GCGrace < 0 {
+		fail("IMAGE_GC_INTERVAL and IMAGE_GC_GRACE must not be negative")
+	}
+
+	if c.Env == EnvProduction {
+		if err := checkSecret("JWT_SECRET", c.Auth.JWTSecret); err != nil {
+			errs = append(errs, err)
+		}
+		if c.Images.URLSecret != "" {
+			if err := checkSecret("IMAGE_URL_SECRET", c.Images.URLSecret); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if c.Database.Password == "" {
+			fail("DB_PASSWORD is required in production")
+		}
+		if !c.Images.SignedURLs {
+			fail("IMAGE_SIGNED_URLS cannot be disabled in production, covers of private libraries would be public")
+		}
+		if c.Storage.Driver == "s3" && (c.Storage.S3.AccessKeyID == "" || c.Storage.S3.SecretAccessKey == "") {
+			fail("S3_ACCESS_KEY_ID and S3_SECRET_ACCESS_KEY are required in production")
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// checkSecret rejects missing, well-known and short secrets
+func checkSecret(key, secret string) error {
+	switch strings.ToLower(secret) {
+	case "":
+		return fmt.Errorf("%s is required in production", key)
+	case insecureSecret, "changeme", "change-me", "password":
+		return fmt.Errorf("%s uses an insecure default", key)
+	}
+	if len(secret) < minSecretLength {
+		return fmt.Errorf("%s must be at least %d characters in production", key, minSecretLength)
+	}
+	return nil
+}
+
+// ImageURLSecret is the key signing image URLs: IMAGE_URL_SECRET, or the JWT
+// secret when it is not set
+func (c *Config) ImageURLSecret() string {
+	if c.Images.URLSecret != "" {
+		return c.Images.URLSecret
+	}
+	return c.Auth.JWTSecret
+}
@@ -0,0 +1,160 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ByteSize
+	}{
+		{"500", 500},
+		{"0", 0},
+		{"500B", 500},
+		{"512KB", 512 << 10},
+		{"100MB", 100 << 20},
+		{"100 mb", 100 << 20},
+		{"1.5GB", 3 << 29},
+		{"2TB", 2 << 40},
+		{" 2gb ", 2 << 30},
+		{"unlimited", Unlimited},
+		{"Unlimited", Unlimited},
+		{"-1", Unlimited},
+	}
+	for _, tt := range tests {
+		got, err := ParseByteSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseByteSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "MB", "lots", "10XB", "-5MB", "-2", "1,5GB"} {
+		if got, err := ParseByteSize(in); err == nil {
+			t.Errorf("ParseByteSize(%q) = %d, want an error", in, got)
+		}
+	}
+}
+
+// production is a configuration that passes validation in production
+func production() *Config {
+	cfg := Default()
+	cfg.Env = EnvProduction
+	cfg.Auth.JWTSecret = strings.Repeat("j", minSecretLength)
+	cfg.Database.Password = "db"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		base    func() *Config
+		change  func(c *Config)
+		wantErr []string // Substrings of the error, none when valid
+	}{
+		{"defaults", Default, func(c *Config) {}, nil},
+		{"production", production, func(c *Config) {}, nil},
+		{"unknown env", Default, func(c *Config) { c.Env = "staging" }, []string{"APP_ENV"}},
+		{"port out of range", Default, func(c *Config) { c.Port = "70000" }, []string{"PORT"}},
+		{"port not a number", Default, func(c *Config) { c.Port = "http" }, []string{"PORT"}},
+		{"cache dir", Default, func(c *Config) { c.CacheDir = "" }, []string{"CACHE_DIR"}},
+		{"token ttl", Default, func(c *Config) { c.Auth.TokenTTL = 0 }, []string{"JWT_TTL"}},
+		{"local dir", Default, func(c *Config) { c.Storage.LocalDir = "" }, []string{"STORAGE_LOCAL_DIR"}},
+		{"s3 bucket", Default, func(c *Config) { c.Storage.Driver = "s3"; c.Storage.S3.Endpoint = "minio:9000" }, []string{"S3_BUCKET"}},
+		{"s3 in development", Default, func(c *Config) {
+			c.Storage.Driver, c.Storage.S3.Endpoint, c.Storage.S3.Bucket = "s3", "minio:9000", "covers"
+		}, nil},
+		{"unknown driver", Default, func(c *Config) { c.Storage.Driver = "ftp" }, []string{"STORAGE_DRIVER"}},
+		{"negative quota", Default, func(c *Config) { c.Storage.Quotas["user"] = -2 }, []string{"quota of user"}},
+		{"unlimited quota", Default, func(c *Config) { c.Storage.Quotas["user"] = Unlimited }, nil},
+		{"url ttl with signing", Default, func(c *Config) { c.Images.URLTTL = 0 }, []string{"IMAGE_URL_TTL"}},
+		{"url ttl without signing", Default, func(c *Config) { c.Images.URLTTL, c.Images.SignedURLs = 0, false }, nil},
+		{"negative gc interval", Default, func(c *Config) { c.Images.GCInterval = -time.Hour }, []string{"IMAGE_GC_INTERVAL"}},
+		{"gc disabled", Default, func(c *Config) { c.Images.GCInterval = 0 }, nil},
+		{"insecure secret in development", Default, func(c *Config) { c.Auth.JWTSecret = "secret" }, nil},
+		{"default secret in production", production, func(c *Config) { c.Auth.JWTSecret = "secret" }, []string{"JWT_SECRET uses an insecure default"}},
+		{"missing secret in production", production, func(c *Config) { c.Auth.JWTSecret = "" }, []string{"JWT_SECRET is required"}},
+		{"short secret in production", production, func(c *Config) { c.Auth.JWTSecret = "tooshort" }, []string{"JWT_SECRET must be at least"}},
+		{"short url secret in production", production, func(c *Config) { c.Images.URLSecret = "tooshort" }, []string{"IMAGE_URL_SECRET"}},
+		{"db password in production", production, func(c *Config) { c.Database.Password = "" }, []string{"DB_PASSWORD"}},
+		{"unsigned urls in production", production, func(c *Config) { c.Images.SignedURLs = false }, []string{"IMAGE_SIGNED_URLS"}},
+		{"s3 keys in production", production, func(c *Config) {
+			c.Storage.Driver, c.Storage.S3.Endpoint, c.Storage.S3.Bucket = "s3", "minio:9000", "covers"
+		}, []string{"S3_ACCESS_KEY_ID"}},
+		{"every problem reported", production, func(c *Config) {
+			c.Port, c.Auth.JWTSecret, c.Database.Password = "0", "", ""
+		}, []string{"PORT", "JWT_SECRET", "DB_PASSWORD"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.base()
+			tt.change(cfg)
+			err := cfg.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() = nil, want %v", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() = %v, want it to mention %s", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	err := os.WriteFile(file, []byte(`
+port: "4000"
+storage:
+  quotas:
+    user: 50MB
+    admin: unlimited
+images:
+  url_ttl: 2h
+  fetch_allowed_hosts: [" Images.IGDB.com ", ""]
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("PORT", "5000") // The environment wins over the file
+	t.Setenv("STORAGE_QUOTA_PREMIUM", "1GB")
+	t.Setenv("CACHE_DIR", "/var/cache/backlog")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Port != "5000" || cfg.Images.URLTTL != 2*time.Hour || cfg.Auth.TokenTTL != 72*time.Hour {
+		t.Errorf("port %s, url ttl %v, token ttl %v", cfg.Port, cfg.Images.URLTTL, cfg.Auth.TokenTTL)
+	}
+	if cfg.CacheDir != "/var/cache/backlog" {
+		t.Errorf("cache dir = %s", cfg.CacheDir)
+	}
+	if q := cfg.Storage.Quotas; q["user"] != 50<<20 || q["admin"] != Unlimited || q["premium"] != 1<<30 {
+		t.Errorf("quotas = %v", q)
+	}
+	if hosts := cfg.Images.FetchAllowedHosts; len(hosts) != 1 || hosts[0] != "images.igdb.com" {
+		t.Errorf("allowed hosts = %q", hosts)
+	}
+
+	t.Setenv("IMAGE_SIGNED_URLS", "sometimes")
+	t.Setenv("JWT_TTL", "3 days")
+	_, err = Load()
+	if err == nil || !strings.Contains(err.Error(), "IMAGE_SIGNED_URLS") || !strings.Contains(err.Error(), "JWT_TTL") {
+		t.Errorf("Load() = %v, want both invalid variables reported", err)
+	}
+}
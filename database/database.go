@@ -1,10 +1,9 @@
 package database
 
 import (
-	"fmt"
 	"log"
-	"os"
 
+	"backlog-backend/config"
 	"backlog-backend/models"
 
 	"gorm.io/driver/postgres"
@@ -13,20 +12,9 @@ import (
 
 var DB *gorm.DB
 
-func Connect() {
+func Connect(cfg config.Database) {
 	var err error
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=%s",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_SSLMODE"),
-		os.Getenv("DB_TIMEZONE"),
-	)
-
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	DB, err = gorm.Open(postgres.Open(cfg.DSN()), &gorm.Config{})
 	if err != nil {
 		log.Printf("Failed to connect to database: %v", err)
 		return
@@ -35,9 +23,16 @@ func Connect() {
 	log.Println("Database connected successfully")
 
 	log.Println("Running migrations...")
-	if err := DB.AutoMigrate(&models.User{}, &models.Game{}, &models.GameTag{}, &models.TierList{}, &models.TierRow{}, &models.TierItem{}); err != nil {
+	if err := DB.AutoMigrate(&models.User{}, &models.Game{}, &models.GameTag{}, &models.TierList{}, &models.TierRow{}, &models.TierItem{}, &models.Ownership{}, &models.Series{}, &models.GameRelation{}, &models.Achievement{}, &models.TierListTemplate{}, &models.TierTemplateRow{}, &models.TierListVersion{}, &models.TierListCollaborator{}, &models.Image{}); err != nil {
 		log.Printf("Failed to migrate database: %v", err)
 	} else {
+		// Image names were unique before identical uploads were deduplicated
+		if DB.Migrator().HasIndex(&models.Image{}, "idx_images_name") {
+			DB.Migrator().DropIndex(&models.Image{}, "idx_images_name")
+		}
+		if err := backfillOwnerships(DB); err != nil {
+			log.Printf("Failed to backfill ownerships: %v", err)
+		}
 		log.Println("Database migrated successfully")
 	}
 }
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"backlog-backend/models"
+	"log"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// backfillOwnerships gives every owned game from before ownerships existed
+// one Ownership built from its legacy platform and hours, so the game's hours
+// stay the sum of its copies. Games that already have an ownership, wishlist
+// games and games without a platform are left alone; running it again only
+// picks up what is still missing.
+func backfillOwnerships(db *gorm.DB) error {
+	var games []models.Game
+	created := 0
+	err := db.Where("status <> ? AND platform <> '' AND NOT EXISTS (SELECT 1 FROM ownerships WHERE ownerships.game_id = games.id)",
+		models.StatusWishlist).
+		FindInBatches(&games, 200, func(tx *gorm.DB, batch int) error {
+			ownerships := make([]models.Ownership, 0, len(games))
+			for _, g := range games {
+				if o, ok := legacyOwnership(g); ok {
+					ownerships = append(ownerships, o)
+				}
+			}
+			if len(ownerships) == 0 {
+				return nil
+			}
+			if err := tx.Create(&ownerships).Error; err != nil {
+				return err
+			}
+			created += len(ownerships)
+			return nil
+		}).Error
+	if err != nil {
+		return err
+	}
+	if created > 0 {
+		log.Printf("Created %d ownerships from legacy game platforms", created)
+	}
+	return nil
+}
+
+// legacyOwnership describes the copy a game's legacy fields stand for.
+// Free-text platforms outside the vocabulary become "other" and are kept as
+// the storefront.
+func legacyOwnership(g models.Game) (models.Ownership, bool) {
+	raw := strings.TrimSpace(g.Platform)
+	if raw == "" || g.Status == models.StatusWishlist {
+		return models.Ownership{}, false
+	}
+	platform, storefront, ok := models.ParsePlatform(raw)
+	if !ok {
+		platform, storefront = models.PlatformOther, raw
+		if r := []rune(storefront); len(r) > 50 { // The column's length
+			storefront = string(r[:50])
+		}
+	}
+	return models.Ownership{
+		GameID:      g.ID,
+		Platform:    platform,
+		Storefront:  storefront,
+		Format:      models.FormatDigital,
+		AcquiredAt:  g.AcquiredAt,
+		Price:       g.PricePaid,
+		HoursPlayed: g.HoursPlayed,
+		Completed:   g.Status == models.StatusCompleted,
+		CompletedAt: g.DateFinished,
+	}, true
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"backlog-backend/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestLegacyOwnership(t *testing.T) {
+	acquired := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	price := 19.99
+
+	tests := []struct {
+		name           string
+		platform       string
+		status         models.GameStatus
+		want           bool
+		wantPlatform   models.Platform
+		wantStorefront string
+	}{
+		{"slug", "switch", models.StatusPlaying, true, models.PlatformSwitch, ""},
+		{"storefront alias", "Steam", models.StatusBacklog, true, models.PlatformPC, "Steam"},
+		{"display name", "PlayStation", models.StatusCompleted, true, models.PlatformPlayStation, ""},
+		{"free text", "Sega Dreamcast", models.StatusBacklog, true, models.PlatformOther, "Sega Dreamcast"},
+		{"long free text", strings.Repeat("é", 60), models.StatusBacklog, true, models.PlatformOther, strings.Repeat("é", 50)},
+		{"no platform", "", models.StatusPlaying, false, "", ""},
+		{"blank platform", "  ", models.StatusPlaying, false, "", ""},
+		{"wishlist", "pc", models.StatusWishlist, false, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := models.Game{ID: uuid.New(), Platform: tt.platform, Status: tt.status, HoursPlayed: 42, AcquiredAt: &acquired, PricePaid: &price}
+			o, ok := legacyOwnership(g)
+			if ok != tt.want {
+				t.Fatalf("legacyOwnership(%q, %s) ok = %v, want %v", tt.platform, tt.status, ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if o.Platform != tt.wantPlatform || o.Storefront != tt.wantStorefront {
+				t.Errorf("platform %s, storefront %q, want %s, %q", o.Platform, o.Storefront, tt.wantPlatform, tt.wantStorefront)
+			}
+			if o.GameID != g.ID || o.HoursPlayed != 42 || o.AcquiredAt != &acquired || o.Price != &price {
+				t.Errorf("ownership = %+v, want the game's hours, acquisition and price", o)
+			}
+			if o.Completed != (tt.status == models.StatusCompleted) {
+				t.Errorf("completed = %v for status %s", o.Completed, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AchievementRequest is used for manual edits, JSON file imports and provider results
+type AchievementRequest struct {
+	ExternalID  string     `json:"external_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	IconURL     string     `json:"icon_url"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at"`
+	Rarity      *float64   `json:"rarity"`
+}
+
+// UpdateAchievementRequest only changes the fields that are sent
+type UpdateAchievementRequest struct {
+	ExternalID  *string    `json:"external_id"`
+	Name        *string    `json:"name"`
+	Description *string    `json:"description"`
+	IconURL     *string    `json:"icon_url"`
+	Unlocked    *bool      `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at"` // Defaults to now when unlocking
+	Rarity      *float64   `json:"rarity"`
+}
+
+// ImportAchievementsRequest accepts either this object or a bare array of achievements
+type ImportAchievementsRequest struct {
+	Achievements []AchievementRequest `json:"achievements"`
+}
+
+type SyncAchievementsRequest struct {
+	ExternalID string `json:"external_id"` // Defaults to the game's external_id
+	Account    string `json:"account"`     // The player's account on the provider
+}
+
+type AchievementResponse struct {
+	ID          uuid.UUID  `json:"id"`
+	GameID      uuid.UUID  `json:"game_id"`
+	ExternalID  string     `json:"external_id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	IconURL     string     `json:"icon_url"`
+	Unlocked    bool       `json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at"`
+	Rarity      *float64   `json:"rarity"`
+}
+
+type AchievementImportResponse struct {
+	Created      int                   `json:"created"`
+	Updated      int                   `json:"updated"`
+	Total        int                   `json:"total"`
+	Unlocked     int                   `json:"unlocked"`
+	Platinum     bool                  `json:"platinum"`
+	Achievements []AchievementResponse `json:"achievements"`
+}
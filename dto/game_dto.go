@@ -18,9 +18,20 @@ type CreateGameRequest struct {
 	HoursPlayed  int         `json:"hours_played"`
 	HLTBEstimate int         `json:"hltb_estimate"`
 	ReleaseYear  int         `json:"release_year"`
+	ReleaseDate  *time.Time  `json:"release_date"`
+	AcquiredAt   *time.Time  `json:"acquired_at"`
+	PricePaid    *float64    `json:"price_paid"`
 	DateFinished *time.Time  `json:"date_finished"`
 	ReviewText   string      `json:"review_text"`
 	TagIDs       []uuid.UUID `json:"tag_ids"`
+	SeriesID     *uuid.UUID  `json:"series_id"`
+	ExternalID   string      `json:"external_id"`
+	// Download a remote CoverURL into the image storage. Defaults to the
+	// LOCALIZE_REMOTE_COVERS setting.
+	LocalizeCover *bool `json:"localize_cover"`
+	// Copies owned. When empty, a single ownership is derived from Platform
+	// unless the game is on the wishlist.
+	Ownerships []OwnershipRequest `json:"ownerships"`
 }
 
 type UpdateGameRequest struct {
@@ -34,28 +45,118 @@ type UpdateGameRequest struct {
 	HoursPlayed  int         `json:"hours_played"`
 	HLTBEstimate int         `json:"hltb_estimate"`
 	ReleaseYear  int         `json:"release_year"`
+	ReleaseDate  *time.Time  `json:"release_date"` // Omit to keep the current date
+	AcquiredAt   *time.Time  `json:"acquired_at"`  // Defaults to now when leaving the wishlist
+	PricePaid    *float64    `json:"price_paid"`
 	DateFinished *time.Time  `json:"date_finished"`
 	ReviewText   string      `json:"review_text"`
-	TagIDs       []uuid.UUID `json:"tag_ids"` // Replace tags
+	TagIDs       []uuid.UUID `json:"tag_ids"`   // Replace tags
+	SeriesID     *uuid.UUID  `json:"series_id"` // Omit to keep, nil UUID to remove from the series
+	ExternalID   *string     `json:"external_id"`
+	// Download a new remote CoverURL into the image storage. Defaults to the
+	// LOCALIZE_REMOTE_COVERS setting.
+	LocalizeCover *bool `json:"localize_cover"`
 }
 
 type GameResponse struct {
-	ID           uuid.UUID     `json:"id"`
-	UserID       uuid.UUID     `json:"user_id"`
-	Title        string        `json:"title"`
-	CoverURL     string        `json:"cover_url"`
-	Genre        string        `json:"genre"`
-	Status       string        `json:"status"`
-	Platform     string        `json:"platform"`
-	Platinum     bool          `json:"platinum"`
-	Score        *float64      `json:"score"`
-	HoursPlayed  int           `json:"hours_played"`
-	HLTBEstimate int           `json:"hltb_estimate"`
-	ReleaseYear  int           `json:"release_year"`
-	DateFinished *time.Time    `json:"date_finished"`
-	LastPlayedAt *time.Time    `json:"last_played_at"`
-	ReviewText   string        `json:"review_text"`
-	CreatedAt    time.Time     `json:"created_at"`
-	UpdatedAt    time.Time     `json:"updated_at"`
-	Tags         []TagResponse `json:"tags"`
+	ID       uuid.UUID `json:"id"`
+	UserID   uuid.UUID `json:"user_id"`
+	Title    string    `json:"title"`
+	CoverURL string    `json:"cover_url"`
+	// Placeholder for a cover stored by the API, null for remote covers and
+	// covers not analysed yet
+	Cover    *CoverImage `json:"cover"`
+	Genre    string      `json:"genre"`
+	Status   string      `json:"status"`
+	Platform string      `json:"platform"`
+	Platinum bool        `json:"platinum"`
+	// Achievement progress. Percentage is null when the game has no achievements.
+	AchievementsTotal    int                 `json:"achievements_total"`
+	AchievementsUnlocked int                 `json:"achievements_unlocked"`
+	AchievementProgress  *float64            `json:"achievement_progress"`
+	Score                *float64            `json:"score"`
+	HoursPlayed          int                 `json:"hours_played"`
+	HLTBEstimate         int                 `json:"hltb_estimate"`
+	ReleaseYear          int                 `json:"release_year"`
+	ReleaseDate          *time.Time          `json:"release_date"`
+	AcquiredAt           *time.Time          `json:"acquired_at"`
+	PricePaid            *float64            `json:"price_paid"`
+	DateFinished         *time.Time          `json:"date_finished"`
+	LastPlayedAt         *time.Time          `json:"last_played_at"`
+	ReviewText           string              `json:"review_text"`
+	SeriesID             *uuid.UUID          `json:"series_id"`
+	ExternalID           string              `json:"external_id"`
+	QueuePosition        *int                `json:"queue_position"`
+	CreatedAt            time.Time           `json:"created_at"`
+	UpdatedAt            time.Time           `json:"updated_at"`
+	Tags                 []TagResponse       `json:"tags"`
+	Platforms            []string            `json:"platforms"`    // Every platform the game is owned on
+	CompletedOn          []string            `json:"completed_on"` // Platforms where it has been completed
+	Ownerships           []OwnershipResponse `json:"ownerships"`
+}
+
+// ReorderQueueRequest carries the full backlog order after a drag-and-drop.
+// Backlog games not listed are taken out of the queue.
+type ReorderQueueRequest struct {
+	GameIDs []uuid.UUID `json:"game_ids"`
+}
+
+type NextGameSuggestion struct {
+	Game      GameResponse       `json:"game"`
+	Score     float64            `json:"score"`
+	Breakdown map[string]float64 `json:"breakdown"`
+}
+
+type NextGameResponse struct {
+	Mode         string               `json:"mode"`
+	PlayingCount int                  `json:"playing_count"`
+	Weights      map[string]float64   `json:"weights"`
+	Suggestions  []NextGameSuggestion `json:"suggestions"`
+}
+
+type CalendarTokenResponse struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+type OwnershipRequest struct {
+	Platform    string     `json:"platform"` // Slug, display name or legacy value such as "Steam"
+	Storefront  string     `json:"storefront"`
+	Edition     string     `json:"edition"`
+	Format      string     `json:"format"` // "digital" (default) or "physical"
+	AcquiredAt  *time.Time `json:"acquired_at"`
+	Price       *float64   `json:"price"`
+	HoursPlayed int        `json:"hours_played"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+type OwnershipResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	GameID       uuid.UUID  `json:"game_id"`
+	Platform     string     `json:"platform"`
+	PlatformName string     `json:"platform_name"`
+	Storefront   string     `json:"storefront"`
+	Edition      string     `json:"edition"`
+	Format       string     `json:"format"`
+	AcquiredAt   *time.Time `json:"acquired_at"`
+	Price        *float64   `json:"price"`
+	HoursPlayed  int        `json:"hours_played"`
+	Completed    bool       `json:"completed"`
+	CompletedAt  *time.Time `json:"completed_at"`
+}
+
+type PlatformResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// CoverImage lets the client draw a placeholder while a cover loads
+type CoverImage struct {
+	Width         int      `json:"width"`
+	Height        int      `json:"height"`
+	AspectRatio   float64  `json:"aspect_ratio"`
+	BlurHash      string   `json:"blurhash"`
+	DominantColor string   `json:"dominant_color"`
+	Palette       []string `json:"palette"`
 }
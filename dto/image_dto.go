@@ -0,0 +1,5 @@
+package dto
+
+type FetchImageRequest struct {
+	URL string `json:"url"`
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SeriesRequest struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	GameIDs     []uuid.UUID `json:"game_ids"` // Optional on create, adds these games to the series
+}
+
+type SeriesGamesRequest struct {
+	GameIDs []uuid.UUID `json:"game_ids"`
+}
+
+type SeriesEntry struct {
+	GameID      uuid.UUID  `json:"game_id"`
+	Title       string     `json:"title"`
+	CoverURL    string     `json:"cover_url"`
+	Status      string     `json:"status"`
+	ReleaseYear int        `json:"release_year"`
+	ReleaseDate *time.Time `json:"release_date"`
+}
+
+type SeriesResponse struct {
+	ID                uuid.UUID     `json:"id"`
+	Name              string        `json:"name"`
+	Description       string        `json:"description"`
+	GameCount         int           `json:"game_count"`
+	CompletedCount    int           `json:"completed_count"`
+	CompletionPercent float64       `json:"completion_percent"`
+	Entries           []SeriesEntry `json:"entries,omitempty"`
+	CreatedAt         time.Time     `json:"created_at"`
+}
+
+type GameRelationRequest struct {
+	RelatedGameID uuid.UUID `json:"related_game_id"`
+	Type          string    `json:"type"` // dlc_of, expansion_of, sequel_of, remaster_of
+}
+
+type GameRelationResponse struct {
+	ID               uuid.UUID `json:"id"`
+	Type             string    `json:"type"`
+	GameID           uuid.UUID `json:"game_id"`
+	GameTitle        string    `json:"game_title"`
+	RelatedGameID    uuid.UUID `json:"related_game_id"`
+	RelatedGameTitle string    `json:"related_game_title"`
+}
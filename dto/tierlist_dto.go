@@ -0,0 +1,372 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type TierItemRequest struct {
+	ID     uuid.UUID `json:"id"` // Optional; an ID the list already has is kept on update
+	GameID uuid.UUID `json:"game_id"`
+}
+
+type TierRowRequest struct {
+	ID    uuid.UUID         `json:"id"` // Optional; an ID the list already has is kept on update
+	Label string            `json:"label"`
+	Color string            `json:"color"`
+	Items []TierItemRequest `json:"items"`
+}
+
+type CreateTierListRequest struct {
+	Name string           `json:"name"`
+	Rows []TierRowRequest `json:"rows"`
+}
+
+// UpdateTierListRequest replaces the whole list; rows and items are kept in the order sent
+type UpdateTierListRequest struct {
+	Name string           `json:"name"`
+	Rows []TierRowRequest `json:"rows"`
+}
+
+type TierGame struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	CoverURL string    `json:"cover_url"`
+	Status   string    `json:"status"`
+	Score    *float64  `json:"score"`
+}
+
+type TierItemResponse struct {
+	ID        uuid.UUID `json:"id"`
+	GameID    uuid.UUID `json:"game_id"`
+	SortOrder int       `json:"sort_order"`
+	Game      TierGame  `json:"game"`
+}
+
+type TierRowResponse struct {
+	ID        uuid.UUID          `json:"id"`
+	Label     string             `json:"label"`
+	Color     string             `json:"color"`
+	SortOrder int                `json:"sort_order"`
+	Items     []TierItemResponse `json:"items"`
+}
+
+type TierListResponse struct {
+	ID           uuid.UUID         `json:"id"`
+	Name         string            `json:"name"`
+	Visibility   string            `json:"visibility"`
+	ForkedFromID *uuid.UUID        `json:"forked_from_id"`
+	ForkedFrom   *ForkOrigin       `json:"forked_from,omitempty"` // Only when the origin is still readable
+	CreatedAt    time.Time         `json:"created_at"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+	Rows         []TierRowResponse `json:"rows"`
+}
+
+// TierListSummaryResponse is the lightweight form used when listing tier lists
+type TierListSummaryResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	Name         string     `json:"name"`
+	Visibility   string     `json:"visibility"`
+	ForkedFromID *uuid.UUID `json:"forked_from_id"`
+	RowCount     int        `json:"row_count"`
+	ItemCount    int        `json:"item_count"`
+	CoverPreview []string   `json:"cover_preview"` // Covers of the first few games, top row first
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// Tier list patch operations
+const (
+	OpRename      = "rename"       // name
+	OpAddRow      = "add_row"      // label, color, index, optional row_id
+	OpRemoveRow   = "remove_row"   // row_id
+	OpRenameRow   = "rename_row"   // row_id, label
+	OpRecolorRow  = "recolor_row"  // row_id, color
+	OpMoveRow     = "move_row"     // row_id, index
+	OpReorderRows = "reorder_rows" // row_ids
+	OpAddItem     = "add_item"     // row_id, game_id, index, optional item_id
+	OpRemoveItem  = "remove_item"  // item_id
+	OpMoveItem    = "move_item"    // item_id, row_id (target), index
+)
+
+// TierListOperation is one step of a PATCH. Index is the target position;
+// nil appends at the end.
+type TierListOperation struct {
+	Op     string      `json:"op"`
+	Name   string      `json:"name,omitempty"`
+	RowID  uuid.UUID   `json:"row_id,omitempty"`
+	ItemID uuid.UUID   `json:"item_id,omitempty"`
+	GameID uuid.UUID   `json:"game_id,omitempty"`
+	Label  string      `json:"label,omitempty"`
+	Color  string      `json:"color,omitempty"`
+	Index  *int        `json:"index,omitempty"`
+	RowIDs []uuid.UUID `json:"row_ids,omitempty"`
+}
+
+type PatchTierListRequest struct {
+	Operations []TierListOperation `json:"operations"`
+}
+
+type TemplateRowRequest struct {
+	Label    string   `json:"label"`
+	Color    string   `json:"color"`
+	MinScore *float64 `json:"min_score"`
+}
+
+type TemplateRequest struct {
+	Name        string               `json:"name"`
+	Description string               `json:"description"`
+	Rows        []TemplateRowRequest `json:"rows"`
+}
+
+type TemplateRowResponse struct {
+	ID        uuid.UUID `json:"id"`
+	Label     string    `json:"label"`
+	Color     string    `json:"color"`
+	SortOrder int       `json:"sort_order"`
+	MinScore  *float64  `json:"min_score"`
+}
+
+type TemplateResponse struct {
+	ID          uuid.UUID             `json:"id"`
+	Name        string                `json:"name"`
+	Description string                `json:"description"`
+	BuiltIn     bool                  `json:"built_in"`
+	Rows        []TemplateRowResponse `json:"rows"`
+	CreatedAt   *time.Time            `json:"created_at,omitempty"`
+}
+
+// GameFilter selects games from the caller's library. Empty fields do not filter.
+type GameFilter struct {
+	GameIDs         []uuid.UUID `json:"game_ids"`
+	Statuses        []string    `json:"statuses"`
+	TagIDs          []uuid.UUID `json:"tag_ids"` // Games having any of these tags
+	Platform        string      `json:"platform"`
+	Genre           string      `json:"genre"`
+	ReleaseYearFrom int         `json:"release_year_from"`
+	ReleaseYearTo   int         `json:"release_year_to"`
+}
+
+// GenerateTierListRequest builds a tier list from a template, placing the
+// filtered games automatically by score or hours played
+type GenerateTierListRequest struct {
+	Name         string     `json:"name"`
+	TemplateID   *uuid.UUID `json:"template_id"` // Defaults to the built-in classic template
+	By           string     `json:"by"`          // "score" (default), "hours" or "none"
+	Thresholds   []float64  `json:"thresholds"`  // Minimum value per row, top row first
+	Filter       GameFilter `json:"filter"`
+	HoldingLabel string     `json:"holding_label"` // Row for games that could not be placed
+}
+
+type ShareTierListRequest struct {
+	Visibility string `json:"visibility"` // private, unlisted or public
+}
+
+type ShareSettingsResponse struct {
+	Visibility  string     `json:"visibility"`
+	Slug        string     `json:"slug,omitempty"`
+	URL         string     `json:"url,omitempty"`
+	PublishedAt *time.Time `json:"published_at"`
+}
+
+// SharedGame is the only game data exposed on shared tier lists
+type SharedGame struct {
+	Title    string   `json:"title"`
+	CoverURL string   `json:"cover_url"`
+	Score    *float64 `json:"score"`
+}
+
+type SharedTierItem struct {
+	ID        uuid.UUID  `json:"id"`
+	SortOrder int        `json:"sort_order"`
+	Game      SharedGame `json:"game"`
+}
+
+type SharedTierRow struct {
+	ID        uuid.UUID        `json:"id"`
+	Label     string           `json:"label"`
+	Color     string           `json:"color"`
+	SortOrder int              `json:"sort_order"`
+	Items     []SharedTierItem `json:"items"`
+}
+
+type SharedTierListResponse struct {
+	Slug      string          `json:"slug"`
+	Name      string          `json:"name"`
+	Author    string          `json:"author"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Rows      []SharedTierRow `json:"rows,omitempty"`
+}
+
+type GameMove struct {
+	GameID uuid.UUID `json:"game_id"`
+	Title  string    `json:"title"`
+	From   string    `json:"from,omitempty"` // Row label; empty when the game was added
+	To     string    `json:"to,omitempty"`   // Row label; empty when the game was removed
+}
+
+type RowRename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// VersionDiff summarises what changed compared to the previous version
+type VersionDiff struct {
+	Renamed     *RowRename  `json:"renamed,omitempty"`
+	Moved       []GameMove  `json:"moved"`
+	Added       []GameMove  `json:"added"`
+	Removed     []GameMove  `json:"removed"`
+	RowsAdded   []string    `json:"rows_added"`
+	RowsRemoved []string    `json:"rows_removed"`
+	RowsRenamed []RowRename `json:"rows_renamed"`
+}
+
+type TierListVersionResponse struct {
+	Version   int          `json:"version"`
+	Source    string       `json:"source"`
+	Name      string       `json:"name"`
+	RowCount  int          `json:"row_count"`
+	ItemCount int          `json:"item_count"`
+	CreatedAt time.Time    `json:"created_at"`
+	Changes   VersionDiff  `json:"changes"`
+	Rows      []VersionRow `json:"rows,omitempty"` // Only when fetching a single version
+}
+
+type VersionRow struct {
+	Label string        `json:"label"`
+	Color string        `json:"color"`
+	Items []VersionItem `json:"items"`
+}
+
+type VersionItem struct {
+	GameID uuid.UUID `json:"game_id"`
+	Title  string    `json:"title"` // Empty if the game has since been deleted
+}
+
+type InviteCollaboratorRequest struct {
+	Username string `json:"username"`
+	Role     string `json:"role"` // viewer or editor
+}
+
+type UpdateCollaboratorRequest struct {
+	Role string `json:"role"`
+}
+
+type CollaboratorResponse struct {
+	UserID     uuid.UUID  `json:"user_id"`
+	Username   string     `json:"username"`
+	Role       string     `json:"role"`
+	Accepted   bool       `json:"accepted"`
+	AcceptedAt *time.Time `json:"accepted_at"`
+	InvitedAt  time.Time  `json:"invited_at"`
+}
+
+type TierListInviteResponse struct {
+	ID           uuid.UUID  `json:"id"`
+	TierListID   uuid.UUID  `json:"tier_list_id"`
+	TierListName string     `json:"tier_list_name"`
+	Owner        string     `json:"owner"`
+	Role         string     `json:"role"`
+	Accepted     bool       `json:"accepted"`
+	AcceptedAt   *time.Time `json:"accepted_at"`
+	InvitedAt    time.Time  `json:"invited_at"`
+}
+
+// Live editing message types
+const (
+	LiveOps      = "ops"      // client -> server: apply operations; server -> clients: applied operations
+	LiveState    = "state"    // server -> client: full tier list and revision
+	LiveReject   = "reject"   // server -> client: operations were not applied
+	LivePresence = "presence" // server -> clients: who is connected
+	LiveResync   = "resync"   // client -> server: ask for a fresh state
+	LiveClosed   = "closed"   // server -> clients: the list was deleted or access was revoked
+)
+
+// LiveRequest is a message sent by a client over the live editing socket
+type LiveRequest struct {
+	Type         string              `json:"type"`
+	ClientSeq    int                 `json:"client_seq"`
+	BaseRevision int                 `json:"base_revision"`
+	Operations   []TierListOperation `json:"operations"`
+}
+
+type LiveUser struct {
+	ConnectionID uuid.UUID `json:"connection_id"`
+	UserID       uuid.UUID `json:"user_id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role"`
+}
+
+type LiveGame struct {
+	ID       uuid.UUID `json:"id"`
+	Title    string    `json:"title"`
+	CoverURL string    `json:"cover_url"`
+}
+
+// LiveEvent is a message sent by the server; only the fields relevant to the type are set
+type LiveEvent struct {
+	Type         string              `json:"type"`
+	Revision     int                 `json:"revision"`
+	ConnectionID *uuid.UUID          `json:"connection_id,omitempty"` // Origin of ops, or the receiver's own ID on state
+	ClientSeq    int                 `json:"client_seq,omitempty"`
+	Operations   []TierListOperation `json:"operations,omitempty"`
+	Role         string              `json:"role,omitempty"`
+	TierList     *TierListResponse   `json:"tier_list,omitempty"`
+	Library      []LiveGame          `json:"library,omitempty"` // Owner games that can be added
+	Users        []LiveUser          `json:"users,omitempty"`
+	Error        string              `json:"error,omitempty"`
+}
+
+type ConsensusRequest struct {
+	TierListIDs   []uuid.UUID `json:"tier_list_ids"`  // Own lists, lists shared with you, or public lists
+	ShareSlugs    []string    `json:"share_slugs"`    // Unlisted or public lists by their share link
+	Method        string      `json:"method"`         // "mean" (default) or "median"
+	ExcludeLabels []string    `json:"exclude_labels"` // Rows left out of the ranking; defaults to the generator's holding row
+	MinVotes      int         `json:"min_votes"`      // Games ranked by fewer lists are left out
+}
+
+type ConsensusGame struct {
+	Title         string      `json:"title"`
+	CoverURL      string      `json:"cover_url"`
+	ExternalID    string      `json:"external_id,omitempty"`
+	Votes         int         `json:"votes"`
+	Mean          float64     `json:"mean"`   // Average row position, 0 is the top row
+	Median        float64     `json:"median"` // Median row position
+	Variance      float64     `json:"variance"`
+	StdDev        float64     `json:"std_dev"`
+	Controversial bool        `json:"controversial"`
+	Placements    []string    `json:"placements"` // Row label in each list that ranked the game
+	TierListIDs   []uuid.UUID `json:"tier_list_ids"`
+}
+
+type ConsensusRow struct {
+	Label string          `json:"label"`
+	Color string          `json:"color"`
+	Games []ConsensusGame `json:"games"`
+}
+
+type ConsensusResponse struct {
+	Method        string          `json:"method"`
+	ListCount     int             `json:"list_count"`
+	Rows          []ConsensusRow  `json:"rows"`
+	Controversial []ConsensusGame `json:"controversial"` // Highest variance first
+}
+
+type ForkTierListRequest struct {
+	Name          string `json:"name"`           // Defaults to the source name
+	CreateMissing bool   `json:"create_missing"` // Add games missing from your library to the backlog
+}
+
+type ForkOrigin struct {
+	ID     uuid.UUID `json:"id"`
+	Name   string    `json:"name"`
+	Author string    `json:"author"`
+}
+
+type ForkTierListResponse struct {
+	TierList     TierListResponse `json:"tier_list"`
+	ForkedFrom   ForkOrigin       `json:"forked_from"`
+	CreatedGames int              `json:"created_games"`
+	SkippedGames []string         `json:"skipped_games"` // Titles not in your library and not created
+}
@@ -32,3 +32,21 @@ type UserResponse struct {
 	Token     string    `json:"token,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 }
+
+// StorageUsageResponse is a user's uploaded bytes against their quota. The
+// quota and remaining bytes are null when the quota is unlimited.
+type StorageUsageResponse struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Role           string    `json:"role"`
+	UsedBytes      int64     `json:"used_bytes"`
+	QuotaBytes     *int64    `json:"quota_bytes"`
+	RemainingBytes *int64    `json:"remaining_bytes"`
+	ImageCount     int64     `json:"image_count"`
+	QuotaSource    string    `json:"quota_source"` // role or account
+}
+
+// UpdateStorageQuotaRequest sets an account's quota override: bytes, -1 for
+// unlimited, or null to use the role quota
+type UpdateStorageQuotaRequest struct {
+	QuotaBytes *int64 `json:"quota_bytes"`
+}
@@ -0,0 +1,378 @@
+package handlers
+
+import (
+	"backlog-backend/achievements"
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+const maxAchievementImportSize = 5 << 20
+
+func mapAchievementToResponse(a models.Achievement) dto.AchievementResponse {
+	return dto.AchievementResponse{
+		ID:          a.ID,
+		GameID:      a.GameID,
+		ExternalID:  a.ExternalID,
+		Name:        a.Name,
+		Description: a.Description,
+		IconURL:     a.IconURL,
+		Unlocked:    a.Unlocked,
+		UnlockedAt:  a.UnlockedAt,
+		Rarity:      a.Rarity,
+	}
+}
+
+// achievementProgress returns the unlocked percentage, or nil when there is nothing to unlock
+func achievementProgress(game models.Game) *float64 {
+	if game.AchievementsTotal == 0 {
+		return nil
+	}
+	p := float64(game.AchievementsUnlocked) / float64(game.AchievementsTotal) * 100
+	p = float64(int(p*10+0.5)) / 10
+	return &p
+}
+
+func applyAchievementRequest(req dto.AchievementRequest, a *models.Achievement) {
+	a.ExternalID = req.ExternalID
+	a.Name = req.Name
+	a.Description = req.Description
+	a.IconURL = req.IconURL
+	a.Unlocked = req.Unlocked
+	a.UnlockedAt = req.UnlockedAt
+	if a.Unlocked && a.UnlockedAt == nil {
+		now := time.Now()
+		a.UnlockedAt = &now
+	}
+	if !a.Unlocked {
+		a.UnlockedAt = nil
+	}
+	a.Rarity = req.Rarity
+}
+
+// syncAchievementProgress refreshes the game's achievement counters and awards
+// the platinum once every achievement is unlocked
+func syncAchievementProgress(tx *gorm.DB, game *models.Game) error {
+	var total, unlocked int64
+	if err := tx.Model(&models.Achievement{}).Where("game_id = ?", game.ID).Count(&total).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&models.Achievement{}).Where("game_id = ? AND unlocked = ?", game.ID, true).Count(&unlocked).Error; err != nil {
+		return err
+	}
+
+	game.AchievementsTotal = int(total)
+	game.AchievementsUnlocked = int(unlocked)
+	updates := map[string]interface{}{
+		"achievements_total":    total,
+		"achievements_unlocked": unlocked,
+	}
+	if total > 0 && unlocked == total {
+		game.Platinum = true
+		updates["platinum"] = true
+	}
+	return tx.Model(game).Updates(updates).Error
+}
+
+// achievementImportError reports an imported entry that cannot be saved, as
+// opposed to a database failure
+type achievementImportError struct {
+	Index int
+	Err   error
+}
+
+func (e *achievementImportError) Error() string {
+	return fmt.Sprintf("achievement %d: %v", e.Index, e.Err)
+}
+
+func (e *achievementImportError) Unwrap() error {
+	return e.Err
+}
+
+// upsertAchievements merges entries into the game's achievements, matching by
+// external ID first and then by case-insensitive name
+func upsertAchievements(tx *gorm.DB, game *models.Game, entries []dto.AchievementRequest) (created, updated int, err error) {
+	var existing []models.Achievement
+	if err := tx.Where("game_id = ?", game.ID).Find(&existing).Error; err != nil {
+		return 0, 0, err
+	}
+	byExternal := map[string]*models.Achievement{}
+	byName := map[string]*models.Achievement{}
+	for i := range existing {
+		if existing[i].ExternalID != "" {
+			byExternal[existing[i].ExternalID] = &existing[i]
+		}
+		byName[strings.ToLower(existing[i].Name)] = &existing[i]
+	}
+
+	for i, entry := range entries {
+		if strings.TrimSpace(entry.Name) == "" {
+			return 0, 0, &achievementImportError{Index: i, Err: errors.New("name is required")}
+		}
+		match := byExternal[entry.ExternalID]
+		if match == nil || entry.ExternalID == "" {
+			match = byName[strings.ToLower(entry.Name)]
+		}
+
+		if match != nil {
+			// An import never re-locks something already unlocked
+			wasUnlocked, unlockedAt := match.Unlocked, match.UnlockedAt
+			if entry.ExternalID == "" {
+				entry.ExternalID = match.ExternalID
+			}
+			applyAchievementRequest(entry, match)
+			if wasUnlocked && !match.Unlocked {
+				match.Unlocked, match.UnlockedAt = true, unlockedAt
+			}
+			if err := tx.Save(match).Error; err != nil {
+				return 0, 0, err
+			}
+			updated++
+			continue
+		}
+
+		achievement := models.Achievement{GameID: game.ID}
+		applyAchievementRequest(entry, &achievement)
+		if err := tx.Create(&achievement).Error; err != nil {
+			return 0, 0, err
+		}
+		byName[strings.ToLower(achievement.Name)] = &achievement
+		if achievement.ExternalID != "" {
+			byExternal[achievement.ExternalID] = &achievement
+		}
+		created++
+	}
+
+	return created, updated, syncAchievementProgress(tx, game)
+}
+
+func (h *Handler) GetAchievements(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var list []models.Achievement
+	database.DB.Where("game_id = ?", game.ID).Order("unlocked desc, unlocked_at asc, name asc").Find(&list)
+
+	res := make([]dto.AchievementResponse, 0, len(list))
+	for _, a := range list {
+		res = append(res, mapAchievementToResponse(a))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+func (h *Handler) CreateAchievement(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var req dto.AchievementRequest
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Name) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+
+	achievement := models.Achievement{GameID: game.ID}
+	applyAchievementRequest(req, &achievement)
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&achievement).Error; err != nil {
+			return err
+		}
+		return syncAchievementProgress(tx, &game)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create achievement", "error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": mapAchievementToResponse(achievement)})
+}
+
+func (h *Handler) UpdateAchievement(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var achievement models.Achievement
+	if err := database.DB.Where("game_id = ?", game.ID).First(&achievement, "id = ?", c.Params("achievementId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Achievement not found"})
+	}
+
+	var req dto.UpdateAchievementRequest
+	if err := c.BodyParser(&req); err != nil || (req.Name != nil && strings.TrimSpace(*req.Name) == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if req.ExternalID != nil {
+		achievement.ExternalID = *req.ExternalID
+	}
+	if req.Name != nil {
+		achievement.Name = *req.Name
+	}
+	if req.Description != nil {
+		achievement.Description = *req.Description
+	}
+	if req.IconURL != nil {
+		achievement.IconURL = *req.IconURL
+	}
+	if req.Rarity != nil {
+		achievement.Rarity = req.Rarity
+	}
+	if req.UnlockedAt != nil {
+		achievement.UnlockedAt = req.UnlockedAt
+	}
+	if req.Unlocked != nil {
+		achievement.Unlocked = *req.Unlocked
+	}
+	if achievement.Unlocked && achievement.UnlockedAt == nil {
+		now := time.Now()
+		achievement.UnlockedAt = &now
+	}
+	if !achievement.Unlocked {
+		achievement.UnlockedAt = nil
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&achievement).Error; err != nil {
+			return err
+		}
+		return syncAchievementProgress(tx, &game)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update achievement", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "data": mapAchievementToResponse(achievement)})
+}
+
+func (h *Handler) DeleteAchievement(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ? AND game_id = ?", c.Params("achievementId"), game.ID).Delete(&models.Achievement{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return syncAchievementProgress(tx, &game)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Achievement not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not delete achievement", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Achievement deleted"})
+}
+
+// ImportAchievements bulk imports achievements from an uploaded JSON file
+// (multipart field "file") or from the raw JSON request body
+func (h *Handler) ImportAchievements(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	data := c.Body()
+	if file, err := c.FormFile("file"); err == nil {
+		if file.Size > maxAchievementImportSize {
+			return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"status": "error", "message": "Import file too large"})
+		}
+		f, err := file.Open()
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Could not read import file"})
+		}
+		defer f.Close()
+		if data, err = io.ReadAll(f); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Could not read import file"})
+		}
+	}
+
+	entries, err := achievements.Decode(data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid achievements JSON", "error": err.Error()})
+	}
+	return saveAchievementImport(c, game, entries)
+}
+
+// SyncAchievements pulls achievements for the game from the configured provider
+func (h *Handler) SyncAchievements(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var req dto.SyncAchievementsRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+		}
+	}
+	externalID := req.ExternalID
+	if externalID == "" {
+		externalID = game.ExternalID
+	}
+	if externalID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Game has no external_id to sync with"})
+	}
+
+	provider := h.achievements
+	if provider == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "error", "message": "No achievement provider configured"})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	entries, err := provider.Fetch(ctx, externalID, req.Account)
+	if err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"status": "error", "message": "Achievement provider failed", "error": err.Error()})
+	}
+	return saveAchievementImport(c, game, entries)
+}
+
+func saveAchievementImport(c *fiber.Ctx, game models.Game, entries []dto.AchievementRequest) error {
+	var created, updated int
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		created, updated, err = upsertAchievements(tx, &game, entries)
+		return err
+	})
+	var importErr *achievementImportError
+	if errors.As(err, &importErr) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid achievements", "error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not import achievements", "error": err.Error()})
+	}
+
+	var list []models.Achievement
+	database.DB.Where("game_id = ?", game.ID).Order("name asc").Find(&list)
+	res := make([]dto.AchievementResponse, 0, len(list))
+	for _, a := range list {
+		res = append(res, mapAchievementToResponse(a))
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "data": dto.AchievementImportResponse{
+		Created:      created,
+		Updated:      updated,
+		Total:        game.AchievementsTotal,
+		Unlocked:     game.AchievementsUnlocked,
+		Platinum:     game.Platinum,
+		Achievements: res,
+	}})
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestUpsertAchievementsRejectsNamelessEntries(t *testing.T) {
+	db, statements := dryRunDB(t)
+	game := models.Game{ID: uuid.New()}
+
+	_, _, err := upsertAchievements(db, &game, []dto.AchievementRequest{{Name: "First Blood"}, {Name: "  "}})
+	var importErr *achievementImportError
+	if !errors.As(err, &importErr) || importErr.Index != 1 {
+		t.Fatalf("upsertAchievements() = %v, want an import error for entry 1", err)
+	}
+	if len(*statements) != 1 {
+		t.Errorf("statements = %v, want only the first entry inserted", *statements)
+	}
+
+	created, _, err := upsertAchievements(db, &game, []dto.AchievementRequest{{Name: "First Blood"}, {Name: "Completionist"}})
+	if errors.As(err, &importErr) {
+		t.Fatalf("upsertAchievements() = %v, want no import error", err)
+	}
+	if created != 2 {
+		t.Errorf("created = %d, want 2", created)
+	}
+}
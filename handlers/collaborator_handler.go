@@ -0,0 +1,224 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// roleOwner is the access level of the tier list's owner
+const roleOwner = "owner"
+
+// tierListAccess resolves the caller's access to a tier list: the owner, or
+// an accepted collaborator with their role
+func tierListAccess(db *gorm.DB, tierListID string, userID uuid.UUID) (models.TierList, string, error) {
+	var tierList models.TierList
+	if err := db.Where("id = ?", tierListID).First(&tierList).Error; err != nil {
+		return tierList, "", err
+	}
+	if tierList.UserID == userID {
+		return tierList, roleOwner, nil
+	}
+	var collaborator models.TierListCollaborator
+	if err := db.Where("tier_list_id = ? AND user_id = ? AND accepted_at IS NOT NULL", tierList.ID, userID).
+		First(&collaborator).Error; err != nil {
+		return models.TierList{}, "", err
+	}
+	return tierList, string(collaborator.Role), nil
+}
+
+func mapCollaboratorToResponse(c models.TierListCollaborator) dto.CollaboratorResponse {
+	return dto.CollaboratorResponse{
+		UserID:     c.UserID,
+		Username:   c.User.Username,
+		Role:       string(c.Role),
+		Accepted:   c.AcceptedAt != nil,
+		AcceptedAt: c.AcceptedAt,
+		InvitedAt:  c.CreatedAt,
+	}
+}
+
+func findOwnedTierList(c *fiber.Ctx) (models.TierList, error) {
+	var tierList models.TierList
+	err := database.DB.Where("id = ? AND user_id = ?", c.Params("id"), c.Locals("user_id")).First(&tierList).Error
+	return tierList, err
+}
+
+// GetCollaborators lists everyone invited to a tier list
+func (h *Handler) GetCollaborators(c *fiber.Ctx) error {
+	tierList, err := findOwnedTierList(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+
+	var collaborators []models.TierListCollaborator
+	database.DB.Preload("User").Where("tier_list_id = ?", tierList.ID).Order("created_at asc").Find(&collaborators)
+
+	res := make([]dto.CollaboratorResponse, 0, len(collaborators))
+	for _, collaborator := range collaborators {
+		res = append(res, mapCollaboratorToResponse(collaborator))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// InviteCollaborator invites a user by username as viewer or editor
+func (h *Handler) InviteCollaborator(c *fiber.Ctx) error {
+	tierList, err := findOwnedTierList(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+
+	var req dto.InviteCollaboratorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	role := models.CollaboratorRole(req.Role)
+	if req.Role == "" {
+		role = models.CollaboratorViewer
+	}
+	if !role.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid role. Allowed: viewer, editor"})
+	}
+
+	var invitee models.User
+	if err := database.DB.Where("username = ?", strings.TrimSpace(req.Username)).First(&invitee).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+	}
+	if invitee.ID == tierList.UserID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "You already own this tier list"})
+	}
+
+	var count int64
+	database.DB.Model(&models.TierListCollaborator{}).Where("tier_list_id = ? AND user_id = ?", tierList.ID, invitee.ID).Count(&count)
+	if count > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "User is already invited"})
+	}
+
+	collaborator := models.TierListCollaborator{TierListID: tierList.ID, UserID: invitee.ID, Role: role, InvitedBy: tierList.UserID}
+	if err := database.DB.Omit("TierList", "User").Create(&collaborator).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not invite collaborator"})
+	}
+	collaborator.User = invitee
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": mapCollaboratorToResponse(collaborator)})
+}
+
+// UpdateCollaborator changes a collaborator's role, also for open live sessions
+func (h *Handler) UpdateCollaborator(c *fiber.Ctx) error {
+	tierList, err := findOwnedTierList(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+
+	var req dto.UpdateCollaboratorRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	role := models.CollaboratorRole(req.Role)
+	if !role.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid role. Allowed: viewer, editor"})
+	}
+
+	var collaborator models.TierListCollaborator
+	if err := database.DB.Preload("User").Where("tier_list_id = ? AND user_id = ?", tierList.ID, c.Params("userId")).
+		First(&collaborator).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Collaborator not found"})
+	}
+	collaborator.Role = role
+	if err := database.DB.Model(&collaborator).Update("role", role).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update collaborator"})
+	}
+	h.live.setRole(tierList.ID, collaborator.UserID, string(role))
+
+	return c.JSON(fiber.Map{"status": "success", "data": mapCollaboratorToResponse(collaborator)})
+}
+
+// RemoveCollaborator revokes access and disconnects the user's live sessions
+func (h *Handler) RemoveCollaborator(c *fiber.Ctx) error {
+	tierList, err := findOwnedTierList(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+
+	result := database.DB.Where("tier_list_id = ? AND user_id = ?", tierList.ID, c.Params("userId")).Delete(&models.TierListCollaborator{})
+	if result.Error != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not remove collaborator"})
+	}
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Collaborator not found"})
+	}
+	if userID, err := uuid.Parse(c.Params("userId")); err == nil {
+		h.live.kick(tierList.ID, userID)
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Collaborator removed"})
+}
+
+// GetTierListInvites lists the caller's pending invites and the tier lists
+// they collaborate on
+func (h *Handler) GetTierListInvites(c *fiber.Ctx) error {
+	var invites []models.TierListCollaborator
+	if err := database.DB.Preload("TierList").
+		Where("user_id = ?", c.Locals("user_id")).
+		Order("created_at desc").Find(&invites).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch invites"})
+	}
+
+	owners := map[uuid.UUID]string{}
+	res := make([]dto.TierListInviteResponse, 0, len(invites))
+	for _, invite := range invites {
+		owner, ok := owners[invite.TierList.UserID]
+		if !ok {
+			var user models.User
+			database.DB.Select("username").First(&user, "id = ?", invite.TierList.UserID)
+			owner = user.Username
+			owners[invite.TierList.UserID] = owner
+		}
+		res = append(res, dto.TierListInviteResponse{
+			ID:           invite.ID,
+			TierListID:   invite.TierListID,
+			TierListName: invite.TierList.Name,
+			Owner:        owner,
+			Role:         string(invite.Role),
+			Accepted:     invite.AcceptedAt != nil,
+			AcceptedAt:   invite.AcceptedAt,
+			InvitedAt:    invite.CreatedAt,
+		})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// AcceptTierListInvite grants the caller the invited access
+func (h *Handler) AcceptTierListInvite(c *fiber.Ctx) error {
+	var invite models.TierListCollaborator
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Params("inviteId"), c.Locals("user_id")).First(&invite).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Invite not found"})
+	}
+	if invite.AcceptedAt == nil {
+		now := time.Now()
+		invite.AcceptedAt = &now
+		if err := database.DB.Model(&invite).Update("accepted_at", now).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not accept invite"})
+		}
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": fiber.Map{"tier_list_id": invite.TierListID, "role": invite.Role, "accepted_at": invite.AcceptedAt}})
+}
+
+// DeclineTierListInvite declines a pending invite or leaves a tier list
+func (h *Handler) DeclineTierListInvite(c *fiber.Ctx) error {
+	var invite models.TierListCollaborator
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Params("inviteId"), c.Locals("user_id")).First(&invite).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Invite not found"})
+	}
+	if err := database.DB.Delete(&invite).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not decline invite"})
+	}
+	h.live.kick(invite.TierListID, invite.UserID)
+	return c.JSON(fiber.Map{"status": "success", "message": "Invite declined"})
+}
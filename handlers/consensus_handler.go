@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	maxConsensusLists = 50
+
+	// A game whose placements spread at least one full row is controversial
+	controversialStdDev = 1.0
+)
+
+// normalizeTitle reduces a title to lowercase letters and digits so the same
+// game entered by different users ("The Witcher 3: Wild Hunt" / "the witcher 3 wild hunt") matches
+func normalizeTitle(title string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(title) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			b.WriteRune(r)
+			space = false
+		} else {
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// consensusEntry collects the placements of one game across lists
+type consensusEntry struct {
+	game       models.Game
+	positions  []float64
+	placements []string
+	lists      []uuid.UUID
+}
+
+// loadReadableTierLists loads the requested lists the caller may read: by ID
+// their own, ones they collaborate on and public ones, and by share slug
+// unlisted/public ones
+func loadReadableTierLists(userID uuid.UUID, req dto.ConsensusRequest) ([]models.TierList, error) {
+	var lists []models.TierList
+	seen := map[uuid.UUID]bool{}
+	add := func(t models.TierList) error {
+		if seen[t.ID] {
+			return nil
+		}
+		seen[t.ID] = true
+		full, err := loadTierList(database.DB, t.ID.String(), t.UserID)
+		if err != nil {
+			return err
+		}
+		lists = append(lists, full)
+		return nil
+	}
+
+	for _, id := range req.TierListIDs {
+		t, _, err := tierListAccess(database.DB, id.String(), userID)
+		if err != nil {
+			// Unlisted lists are only readable through share_slugs
+			if err := database.DB.Where("id = ? AND visibility = ?", id, models.VisibilityPublic).First(&t).Error; err != nil {
+				return nil, fmt.Errorf("tier list %s not found", id)
+			}
+		}
+		if err := add(t); err != nil {
+			return nil, err
+		}
+	}
+	for _, slug := range req.ShareSlugs {
+		var t models.TierList
+		if err := database.DB.Where("share_slug = ? AND visibility IN ?", slug,
+			[]models.TierListVisibility{models.VisibilityUnlisted, models.VisibilityPublic}).First(&t).Error; err != nil {
+			return nil, fmt.Errorf("shared tier list %q not found", slug)
+		}
+		if err := add(t); err != nil {
+			return nil, err
+		}
+	}
+	return lists, nil
+}
+
+// rankedRows drops excluded rows; the remaining rows define tier positions
+func rankedRows(t models.TierList, exclude map[string]bool) []models.TierRow {
+	rows := make([]models.TierRow, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		if !exclude[normalizeTitle(row.Label)] {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// GetConsensusTierList combines several users' rankings of the same rows into
+// one tier list. Each user has their own Game rows, so games are matched by
+// external ID and otherwise by normalised title.
+func (h *Handler) GetConsensusTierList(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	var req dto.ConsensusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if req.Method == "" {
+		req.Method = "mean"
+	}
+	if req.Method != "mean" && req.Method != "median" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid method. Allowed: mean, median"})
+	}
+	if n := len(req.TierListIDs) + len(req.ShareSlugs); n < 2 || n > maxConsensusLists {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("Between 2 and %d tier lists are required", maxConsensusLists)})
+	}
+	if req.ExcludeLabels == nil {
+		req.ExcludeLabels = []string{defaultHoldingLabel}
+	}
+	if req.MinVotes < 1 {
+		req.MinVotes = 1
+	}
+
+	lists, err := loadReadableTierLists(userID, req)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": err.Error()})
+	}
+	if len(lists) < 2 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "At least 2 different tier lists are required"})
+	}
+
+	exclude := map[string]bool{}
+	for _, label := range req.ExcludeLabels {
+		exclude[normalizeTitle(label)] = true
+	}
+
+	// Every list must use the same rows (e.g. the same template) for positions to be comparable
+	reference := rankedRows(lists[0], exclude)
+	if len(reference) == 0 {
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"status": "error", "message": "The tier lists have no ranked rows"})
+	}
+	for _, t := range lists[1:] {
+		rows := rankedRows(t, exclude)
+		match := len(rows) == len(reference)
+		for i := 0; match && i < len(rows); i++ {
+			match = normalizeTitle(rows[i].Label) == normalizeTitle(reference[i].Label)
+		}
+		if !match {
+			return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Tier lists must share the same rows",
+				"data":    fiber.Map{"tier_list_id": t.ID},
+			})
+		}
+	}
+
+	entries := map[string]*consensusEntry{}
+	var order []string
+	byTitle := map[string]string{}
+	place := func(key string, list models.TierList, pos int, label string, game models.Game) {
+		entry, ok := entries[key]
+		if !ok {
+			entry = &consensusEntry{game: game}
+			entries[key] = entry
+			order = append(order, key)
+		}
+		// A game listed twice in one list only counts once
+		for _, id := range entry.lists {
+			if id == list.ID {
+				return
+			}
+		}
+		entry.positions = append(entry.positions, float64(pos))
+		entry.placements = append(entry.placements, label)
+		entry.lists = append(entry.lists, list.ID)
+	}
+
+	// Games with an external ID are grouped first so games without one can
+	// join them by title
+	for pass := 0; pass < 2; pass++ {
+		for _, list := range lists {
+			for pos, row := range rankedRows(list, exclude) {
+				for _, item := range row.Items {
+					game := item.Game
+					if game.ID != item.GameID {
+						continue // The owner deleted the game
+					}
+					title := normalizeTitle(game.Title)
+					if pass == 0 && game.ExternalID != "" {
+						key := "ext:" + game.ExternalID
+						if _, ok := byTitle[title]; !ok && title != "" {
+							byTitle[title] = key
+						}
+						place(key, list, pos, reference[pos].Label, game)
+					}
+					if pass == 1 && game.ExternalID == "" && title != "" {
+						key, ok := byTitle[title]
+						if !ok {
+							key = "title:" + title
+							byTitle[title] = key
+						}
+						place(key, list, pos, reference[pos].Label, game)
+					}
+				}
+			}
+		}
+	}
+
+	res := dto.ConsensusResponse{Method: req.Method, ListCount: len(lists), Rows: make([]dto.ConsensusRow, len(reference))}
+	for i, row := range reference {
+		res.Rows[i] = dto.ConsensusRow{Label: row.Label, Color: row.Color, Games: []dto.ConsensusGame{}}
+	}
+	res.Controversial = []dto.ConsensusGame{}
+
+	for _, key := range order {
+		entry := entries[key]
+		if len(entry.positions) < req.MinVotes {
+			continue
+		}
+		game := h.consensusGame(entry)
+		value := game.Mean
+		if req.Method == "median" {
+			value = game.Median
+		}
+		row := int(math.Round(value))
+		if row >= len(res.Rows) {
+			row = len(res.Rows) - 1
+		}
+		res.Rows[row].Games = append(res.Rows[row].Games, game)
+		if game.Controversial {
+			res.Controversial = append(res.Controversial, game)
+		}
+	}
+
+	for i := range res.Rows {
+		games := res.Rows[i].Games
+		sort.SliceStable(games, func(a, b int) bool {
+			if games[a].Mean != games[b].Mean {
+				return games[a].Mean < games[b].Mean
+			}
+			return games[a].Votes > games[b].Votes
+		})
+	}
+	sort.SliceStable(res.Controversial, func(a, b int) bool {
+		return res.Controversial[a].Variance > res.Controversial[b].Variance
+	})
+
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+func (h *Handler) consensusGame(entry *consensusEntry) dto.ConsensusGame {
+	n := float64(len(entry.positions))
+	var sum float64
+	for _, p := range entry.positions {
+		sum += p
+	}
+	mean := sum / n
+	var variance float64
+	for _, p := range entry.positions {
+		variance += (p - mean) * (p - mean)
+	}
+	variance /= n
+
+	sorted := append([]float64(nil), entry.positions...)
+	sort.Float64s(sorted)
+	median := sorted[len(sorted)/2]
+	if len(sorted)%2 == 0 {
+		median = (sorted[len(sorted)/2-1] + sorted[len(sorted)/2]) / 2
+	}
+
+	stdDev := math.Sqrt(variance)
+	return dto.ConsensusGame{
+		Title:         entry.game.Title,
+		CoverURL:      h.signImageURL(entry.game.CoverURL),
+		ExternalID:    entry.game.ExternalID,
+		Votes:         len(entry.positions),
+		Mean:          round2(mean),
+		Median:        median,
+		Variance:      round2(variance),
+		StdDev:        round2(stdDev),
+		Controversial: len(entry.positions) > 1 && stdDev >= controversialStdDev,
+		Placements:    entry.placements,
+		TierListIDs:   entry.lists,
+	}
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"backlog-backend/config"
+	"backlog-backend/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeTitle(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"The Witcher 3: Wild Hunt", "the witcher 3 wild hunt"},
+		{"the witcher 3 wild hunt", "the witcher 3 wild hunt"},
+		{"  Hades  ", "hades"},
+		{"Half-Life 2", "half life 2"},
+		{"NieR:Automata", "nier automata"},
+		{"Pokémon Légendes", "pokémon légendes"},
+		{"???", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeTitle(tt.in); got != tt.want {
+			t.Errorf("normalizeTitle(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestConsensusGame(t *testing.T) {
+	h := &Handler{cfg: config.Default()}
+
+	tests := []struct {
+		name          string
+		positions     []float64
+		mean, median  float64
+		variance, std float64
+		controversial bool
+	}{
+		{"single vote", []float64{1}, 1, 1, 0, 0, false},
+		{"one row apart", []float64{0, 2}, 1, 1, 1, 1, true},
+		{"mostly agreed", []float64{0, 0, 1}, 0.33, 0, 0.22, 0.47, false},
+		{"even count takes the middle pair", []float64{0, 1, 2, 3}, 1.5, 1.5, 1.25, 1.12, true},
+		{"unsorted votes", []float64{3, 0, 4, 1, 2}, 2, 2, 2, 1.41, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lists := make([]uuid.UUID, len(tt.positions))
+			placements := make([]string, len(tt.positions))
+			for i := range lists {
+				lists[i] = uuid.New()
+				placements[i] = "S"
+			}
+			entry := &consensusEntry{
+				game:       models.Game{Title: "Hades", CoverURL: "https://example.com/hades.jpg", ExternalID: "igdb:113112"},
+				positions:  tt.positions,
+				placements: placements,
+				lists:      lists,
+			}
+
+			got := h.consensusGame(entry)
+			if got.Votes != len(tt.positions) || got.Mean != tt.mean || got.Median != tt.median ||
+				got.Variance != tt.variance || got.StdDev != tt.std || got.Controversial != tt.controversial {
+				t.Errorf("consensusGame() = votes %d, mean %v, median %v, variance %v, std dev %v, controversial %v; want %d, %v, %v, %v, %v, %v",
+					got.Votes, got.Mean, got.Median, got.Variance, got.StdDev, got.Controversial,
+					len(tt.positions), tt.mean, tt.median, tt.variance, tt.std, tt.controversial)
+			}
+			if got.Title != "Hades" || got.CoverURL != "https://example.com/hades.jpg" || got.ExternalID != "igdb:113112" {
+				t.Errorf("game fields = %q, %q, %q", got.Title, got.CoverURL, got.ExternalID)
+			}
+			if len(got.TierListIDs) != len(lists) || len(got.Placements) != len(placements) {
+				t.Errorf("got %d lists and %d placements, want %d", len(got.TierListIDs), len(got.Placements), len(lists))
+			}
+		})
+	}
+}
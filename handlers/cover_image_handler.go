@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/images"
+	"backlog-backend/models"
+	"context"
+	"log"
+
+	"github.com/google/uuid"
+)
+
+// loadCoverImages looks up the placeholder data of the stored covers among
+// coverURLs, keyed by URL as given, signed or not. Remote covers and images
+// not analysed yet are left out.
+func loadCoverImages(coverURLs []string) map[string]*dto.CoverImage {
+	covers := map[string]*dto.CoverImage{}
+	urlsByName := map[string][]string{}
+	var names []string
+	for _, u := range coverURLs {
+		if name, ok := coverImageName(unsignedImageURL(u)); ok {
+			if urlsByName[name] == nil {
+				names = append(names, name)
+			}
+			urlsByName[name] = append(urlsByName[name], u)
+		}
+	}
+	if len(names) == 0 {
+		return covers
+	}
+	var rows []models.Image
+	database.DB.Select("name", "width", "height", "aspect_ratio", "blur_hash", "dominant_color", "palette").
+		Where("name IN ? AND blur_hash <> ''", names).Find(&rows)
+	for _, r := range rows {
+		cover := &dto.CoverImage{
+			Width:         r.Width,
+			Height:        r.Height,
+			AspectRatio:   r.AspectRatio,
+			BlurHash:      r.BlurHash,
+			DominantColor: r.DominantColor,
+			Palette:       r.Palette,
+		}
+		for _, u := range urlsByName[r.Name] {
+			covers[u] = cover
+		}
+	}
+	return covers
+}
+
+// withCovers fills in the cover placeholders of game responses with a
+// single query
+func withCovers(res []dto.GameResponse) []dto.GameResponse {
+	urls := make([]string, len(res))
+	for i, g := range res {
+		urls[i] = g.CoverURL
+	}
+	covers := loadCoverImages(urls)
+	for i := range res {
+		res[i].Cover = covers[res[i].CoverURL]
+	}
+	return res
+}
+
+func withCover(res dto.GameResponse) dto.GameResponse {
+	return withCovers([]dto.GameResponse{res})[0]
+}
+
+// defaultRowColor is the color of a new tier row created without one: the
+// dominant color of its first game's stored cover, or white
+func defaultRowColor(userID uuid.UUID, items []dto.TierItemRequest) string {
+	if len(items) > 0 {
+		var coverURL string
+		database.DB.Model(&models.Game{}).Where("id = ? AND user_id = ?", items[0].GameID, userID).Pluck("cover_url", &coverURL)
+		if cover := loadCoverImages([]string{coverURL})[coverURL]; cover != nil && cover.DominantColor != "" {
+			return cover.DominantColor
+		}
+	}
+	return "#FFFFFF"
+}
+
+// AnalyzeImages computes the placeholder data of images uploaded before it
+// existed, or of every image with force. It returns how many files were
+// analysed and how many failed.
+func (h *Handler) AnalyzeImages(ctx context.Context, force bool) (analyzed, failed int, err error) {
+	query := database.DB.Model(&models.Image{}).Distinct()
+	if !force {
+		query = query.Where("blur_hash IS NULL OR blur_hash = ''")
+	}
+	var names []string
+	if err := query.Pluck("name", &names).Error; err != nil {
+		return 0, 0, err
+	}
+
+	for _, name := range names {
+		img, err := images.Load(ctx, h.store, name)
+		if err != nil {
+			log.Printf("analyze %s: %v", name, err)
+			failed++
+			continue
+		}
+		a := images.Analyze(img)
+		b := img.Bounds()
+		// Rows from before derived files were charged get their full size
+		size, _ := images.StoredSize(ctx, h.store, name)
+		// A struct rather than a map so the palette goes through its serializer
+		err = database.DB.Model(&models.Image{}).Where("name = ?", name).Updates(models.Image{
+			Size:          size,
+			BlurHash:      a.BlurHash,
+			DominantColor: a.DominantColor,
+			Palette:       a.Palette,
+			AspectRatio:   a.AspectRatio,
+			Width:         b.Dx(),
+			Height:        b.Dy(),
+		}).Error
+		if err != nil {
+			return analyzed, failed, err
+		}
+		analyzed++
+	}
+	return analyzed, failed, nil
+}
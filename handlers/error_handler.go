@@ -0,0 +1,20 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorHandler renders errors that reach Fiber (unknown routes, panics,
+// failed upgrades) in the same envelope the handlers use
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	code := fiber.StatusInternalServerError
+	message := "Internal server error"
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		code = fiberErr.Code
+		message = fiberErr.Message
+	}
+	return c.Status(code).JSON(fiber.Map{"status": "error", "message": message})
+}
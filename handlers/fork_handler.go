@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ForkTierList clones a tier list the caller can read by ID (their own, one
+// they collaborate on, or a public one) into a new private list. Unlisted
+// lists are only reachable through their share link, so rotating it also
+// stops clones by anyone who learned the ID.
+func (h *Handler) ForkTierList(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	source, _, err := tierListAccess(database.DB, c.Params("id"), userID)
+	if err != nil {
+		if err := database.DB.Where("id = ? AND visibility = ?", c.Params("id"), models.VisibilityPublic).First(&source).Error; err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+		}
+	}
+	return h.forkTierList(c, userID, source)
+}
+
+// ForkSharedTierList clones a tier list reached through its share link
+func (h *Handler) ForkSharedTierList(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	var source models.TierList
+	if err := database.DB.Where("share_slug = ? AND visibility IN ?", c.Params("slug"),
+		[]models.TierListVisibility{models.VisibilityUnlisted, models.VisibilityPublic}).First(&source).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+	return h.forkTierList(c, userID, source)
+}
+
+func (h *Handler) forkTierList(c *fiber.Ctx, userID uuid.UUID, source models.TierList) error {
+	var req dto.ForkTierListRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+		}
+	}
+
+	source, err := loadTierList(database.DB, source.ID.String(), source.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier list"})
+	}
+
+	var author models.User
+	database.DB.Select("username").First(&author, "id = ?", source.UserID)
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = source.Name
+	}
+	fork := models.TierList{UserID: userID, Name: name, Visibility: models.VisibilityPrivate, ForkedFromID: &source.ID}
+	res := dto.ForkTierListResponse{
+		ForkedFrom:   dto.ForkOrigin{ID: source.ID, Name: source.Name, Author: author.Username},
+		SkippedGames: []string{},
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		resolve := func(g models.Game) (uuid.UUID, bool, error) { return g.ID, true, nil }
+		if source.UserID != userID {
+			var mapper *gameMapper
+			if mapper, err = h.newGameMapper(tx, userID); err != nil {
+				return err
+			}
+			resolve = func(g models.Game) (uuid.UUID, bool, error) {
+				if id, ok := mapper.find(g); ok {
+					return id, true, nil
+				}
+				if !req.CreateMissing {
+					res.SkippedGames = append(res.SkippedGames, g.Title)
+					return uuid.Nil, false, nil
+				}
+				// A failed insert aborts the Postgres transaction, so it
+				// fails the whole fork rather than skipping the game
+				created, err := mapper.create(g)
+				if err != nil {
+					return uuid.Nil, false, err
+				}
+				res.CreatedGames++
+				return created, true, nil
+			}
+		}
+
+		used := map[uuid.UUID]bool{}
+		for i, row := range source.Rows {
+			newRow := models.TierRow{Label: row.Label, Color: row.Color, SortOrder: i}
+			for _, item := range row.Items {
+				if item.Game.ID != item.GameID {
+					continue // The owner deleted the game
+				}
+				gameID, ok, err := resolve(item.Game)
+				if err != nil {
+					return err
+				}
+				if !ok || used[gameID] {
+					continue
+				}
+				used[gameID] = true
+				newRow.Items = append(newRow.Items, models.TierItem{GameID: gameID, SortOrder: len(newRow.Items)})
+			}
+			fork.Rows = append(fork.Rows, newRow)
+		}
+
+		if err := tx.Omit("ForkedFrom").Create(&fork).Error; err != nil {
+			return err
+		}
+		return recordTierListVersion(tx, &fork, models.VersionSourceFork)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not clone tier list"})
+	}
+
+	created, err := loadTierList(database.DB, fork.ID.String(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier list"})
+	}
+	res.TierList = h.mapTierListToResponse(created)
+	res.TierList.ForkedFrom = &res.ForkedFrom
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// gameMapper finds the caller's copy of another user's game, by external ID
+// first and then by normalised title, and can add missing ones to the backlog
+type gameMapper struct {
+	h          *Handler
+	tx         *gorm.DB
+	userID     uuid.UUID
+	byExternal map[string]uuid.UUID
+	byTitle    map[string]uuid.UUID
+}
+
+func (h *Handler) newGameMapper(tx *gorm.DB, userID uuid.UUID) (*gameMapper, error) {
+	var games []models.Game
+	if err := tx.Select("id", "title", "external_id").Where("user_id = ?", userID).Order("created_at asc").Find(&games).Error; err != nil {
+		return nil, err
+	}
+	m := &gameMapper{h: h, tx: tx, userID: userID, byExternal: map[string]uuid.UUID{}, byTitle: map[string]uuid.UUID{}}
+	for _, g := range games {
+		m.remember(g)
+	}
+	return m, nil
+}
+
+func (m *gameMapper) remember(g models.Game) {
+	if g.ExternalID != "" {
+		if _, ok := m.byExternal[g.ExternalID]; !ok {
+			m.byExternal[g.ExternalID] = g.ID
+		}
+	}
+	if title := normalizeTitle(g.Title); title != "" {
+		if _, ok := m.byTitle[title]; !ok {
+			m.byTitle[title] = g.ID
+		}
+	}
+}
+
+func (m *gameMapper) find(g models.Game) (uuid.UUID, bool) {
+	if g.ExternalID != "" {
+		if id, ok := m.byExternal[g.ExternalID]; ok {
+			return id, true
+		}
+	}
+	id, ok := m.byTitle[normalizeTitle(g.Title)]
+	return id, ok
+}
+
+// create adds the game to the caller's backlog with its catalogue details;
+// personal data such as score, hours and review stay with the original
+func (m *gameMapper) create(g models.Game) (uuid.UUID, error) {
+	game := models.Game{
+		UserID:       m.userID,
+		Title:        g.Title,
+		CoverURL:     m.h.shareCoverImage(m.tx, g.CoverURL, m.userID),
+		Genre:        g.Genre,
+		Status:       models.StatusBacklog,
+		HLTBEstimate: g.HLTBEstimate,
+		ReleaseYear:  g.ReleaseYear,
+		ReleaseDate:  g.ReleaseDate,
+		ExternalID:   g.ExternalID,
+	}
+	game.QueuePosition = nextQueuePosition(m.tx, m.userID)
+	if err := m.tx.Omit("Tags", "Ownerships", "Achievements").Create(&game).Error; err != nil {
+		return uuid.Nil, err
+	}
+	retainCoverImage(m.tx, game.CoverURL)
+	m.remember(game)
+	return game.ID, nil
+}
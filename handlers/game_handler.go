@@ -5,14 +5,15 @@ import (
 	"backlog-backend/dto"
 	"backlog-backend/models"
 	"fmt"
-	"os"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 // Helper to map model to DTO
-func mapGameToResponse(game models.Game) dto.GameResponse {
+func (h *Handler) mapGameToResponse(game models.Game) dto.GameResponse {
 	var tagResponses []dto.TagResponse
 	for _, tag := range game.Tags {
 		tagResponses = append(tagResponses, dto.TagResponse{
@@ -20,55 +21,88 @@ func mapGameToResponse(game models.Game) dto.GameResponse {
 			Name: tag.Name,
 		})
 	}
+	ownerships := append([]models.Ownership(nil), game.Ownerships...)
+	sortOwnerships(ownerships)
+	ownershipResponses := make([]dto.OwnershipResponse, 0, len(ownerships))
+	platforms := make([]string, 0, len(ownerships))
+	completedOn := make([]string, 0)
+	for _, o := range ownerships {
+		ownershipResponses = append(ownershipResponses, mapOwnershipToResponse(o))
+		platforms = appendUnique(platforms, string(o.Platform))
+		if o.Completed {
+			completedOn = appendUnique(completedOn, string(o.Platform))
+		}
+	}
 	return dto.GameResponse{
-		ID:           game.ID,
-		UserID:       game.UserID,
-		Title:        game.Title,
-		CoverURL:     game.CoverURL,
-		Genre:        game.Genre,
-		Status:       string(game.Status),
-		Platform:     game.Platform,
-		Platinum:     game.Platinum,
-		Score:        game.Score,
-		HoursPlayed:  game.HoursPlayed,
-		HLTBEstimate: game.HLTBEstimate,
-		ReleaseYear:  game.ReleaseYear,
-		DateFinished: game.DateFinished,
-		LastPlayedAt: game.LastPlayedAt,
-		ReviewText:   game.ReviewText,
-		CreatedAt:    game.CreatedAt,
-		UpdatedAt:    game.UpdatedAt,
-		Tags:         tagResponses,
+		ID:                   game.ID,
+		UserID:               game.UserID,
+		Title:                game.Title,
+		CoverURL:             h.signImageURL(game.CoverURL),
+		Genre:                game.Genre,
+		Status:               string(game.Status),
+		Platform:             game.Platform,
+		Platinum:             game.Platinum,
+		AchievementsTotal:    game.AchievementsTotal,
+		AchievementsUnlocked: game.AchievementsUnlocked,
+		AchievementProgress:  achievementProgress(game),
+		Score:                game.Score,
+		HoursPlayed:          game.HoursPlayed,
+		HLTBEstimate:         game.HLTBEstimate,
+		ReleaseYear:          game.ReleaseYear,
+		ReleaseDate:          game.ReleaseDate,
+		AcquiredAt:           game.AcquiredAt,
+		PricePaid:            game.PricePaid,
+		DateFinished:         game.DateFinished,
+		LastPlayedAt:         game.LastPlayedAt,
+		ReviewText:           game.ReviewText,
+		SeriesID:             game.SeriesID,
+		ExternalID:           game.ExternalID,
+		QueuePosition:        game.QueuePosition,
+		CreatedAt:            game.CreatedAt,
+		UpdatedAt:            game.UpdatedAt,
+		Tags:                 tagResponses,
+		Platforms:            platforms,
+		CompletedOn:          completedOn,
+		Ownerships:           ownershipResponses,
 	}
 }
 
-func GetGames(c *fiber.Ctx) error {
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
+func (h *Handler) GetGames(c *fiber.Ctx) error {
 	userID := c.Locals("user_id")
 	fmt.Printf("DEBUG: GetGames - UserID: %v\n", userID)
 	var games []models.Game
-	result := database.DB.Preload("Tags").Where("user_id = ?", userID).Find(&games)
+	result := database.DB.Preload("Tags").Preload("Ownerships").Where("user_id = ?", userID).Find(&games)
 	if result.Error != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch games", "error": result.Error.Error()})
 	}
 
 	res := make([]dto.GameResponse, 0)
 	for _, game := range games {
-		res = append(res, mapGameToResponse(game))
+		res = append(res, h.mapGameToResponse(game))
 	}
-	return c.JSON(fiber.Map{"status": "success", "data": res})
+	return c.JSON(fiber.Map{"status": "success", "data": withCovers(res)})
 }
 
-func GetGame(c *fiber.Ctx) error {
+func (h *Handler) GetGame(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID := c.Locals("user_id")
 	var game models.Game
-	if err := database.DB.Preload("Tags").Where("user_id = ?", userID).First(&game, "id = ?", id).Error; err != nil {
+	if err := database.DB.Preload("Tags").Preload("Ownerships").Where("user_id = ?", userID).First(&game, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
 	}
-	return c.JSON(fiber.Map{"status": "success", "data": mapGameToResponse(game)})
+	return c.JSON(fiber.Map{"status": "success", "data": withCover(h.mapGameToResponse(game))})
 }
 
-func CreateGame(c *fiber.Ctx) error {
+func (h *Handler) CreateGame(c *fiber.Ctx) error {
 	var req dto.CreateGameRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
@@ -82,10 +116,21 @@ func CreateGame(c *fiber.Ctx) error {
 	// c.Locals("user_id") comes from claims["user_id"].
 	// We need to parse it to UUID.
 
+	if req.Platform != "" {
+		if _, _, ok := models.ParsePlatform(req.Platform); !ok {
+			return respondInvalidPlatform(c, req.Platform)
+		}
+	}
+
+	coverURL, err := h.localizeCoverURL(c.UserContext(), uuid.MustParse(userID.(string)), unsignedImageURL(req.CoverURL), req.LocalizeCover)
+	if err != nil {
+		return respondImageError(c, err)
+	}
+
 	game := models.Game{
 		UserID:       uuid.MustParse(userID.(string)), // Should handle error safely if possible, but middleware checks validity
 		Title:        req.Title,
-		CoverURL:     req.CoverURL,
+		CoverURL:     coverURL,
 		Genre:        req.Genre,
 		Status:       models.GameStatus(req.Status),
 		Platform:     req.Platform,
@@ -94,8 +139,48 @@ func CreateGame(c *fiber.Ctx) error {
 		HoursPlayed:  req.HoursPlayed,
 		HLTBEstimate: req.HLTBEstimate,
 		ReleaseYear:  req.ReleaseYear,
+		ReleaseDate:  req.ReleaseDate,
+		AcquiredAt:   req.AcquiredAt,
+		PricePaid:    req.PricePaid,
 		DateFinished: req.DateFinished,
 		ReviewText:   req.ReviewText,
+		ExternalID:   req.ExternalID,
+	}
+	if game.Status == "" {
+		game.Status = models.StatusBacklog
+	}
+	if game.ReleaseDate != nil {
+		game.ReleaseYear = game.ReleaseDate.Year()
+	}
+	if game.Status == models.StatusBacklog {
+		game.QueuePosition = nextQueuePosition(database.DB, game.UserID)
+	}
+
+	if req.SeriesID != nil && *req.SeriesID != uuid.Nil {
+		if !userOwnsSeries(game.UserID, *req.SeriesID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+		}
+		game.SeriesID = req.SeriesID
+	}
+
+	// Ownerships: explicit list, or one derived from the legacy platform field
+	ownershipReqs := req.Ownerships
+	if len(ownershipReqs) == 0 && req.Platform != "" && game.Status != models.StatusWishlist {
+		ownershipReqs = []dto.OwnershipRequest{{
+			Platform:    req.Platform,
+			AcquiredAt:  req.AcquiredAt,
+			Price:       req.PricePaid,
+			HoursPlayed: req.HoursPlayed,
+			Completed:   game.Status == models.StatusCompleted,
+			CompletedAt: req.DateFinished,
+		}}
+	}
+	for _, ownReq := range ownershipReqs {
+		var ownership models.Ownership
+		if err := buildOwnership(ownReq, &ownership); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ownership", "error": err.Error()})
+		}
+		game.Ownerships = append(game.Ownerships, ownership)
 	}
 
 	// Assign tags if provided
@@ -109,17 +194,24 @@ func CreateGame(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create game", "error": err.Error()})
 	}
 
+	if len(game.Ownerships) > 0 {
+		if err := syncGameOwnership(database.DB, &game); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update ownerships", "error": err.Error()})
+		}
+	}
+	retainCoverImage(database.DB, game.CoverURL)
+
 	// Reload to get associations
-	database.DB.Preload("Tags").First(&game, game.ID)
+	database.DB.Preload("Tags").Preload("Ownerships").First(&game, game.ID)
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": mapGameToResponse(game)})
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": withCover(h.mapGameToResponse(game))})
 }
 
-func UpdateGame(c *fiber.Ctx) error {
+func (h *Handler) UpdateGame(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID := c.Locals("user_id")
 	var game models.Game
-	if err := database.DB.Preload("Tags").Where("user_id = ?", userID).First(&game, "id = ?", id).Error; err != nil {
+	if err := database.DB.Preload("Tags").Preload("Ownerships").Where("user_id = ?", userID).First(&game, "id = ?", id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
 	}
 
@@ -127,24 +219,43 @@ func UpdateGame(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
 	}
+	if req.Platform != "" {
+		if _, _, ok := models.ParsePlatform(req.Platform); !ok {
+			return respondInvalidPlatform(c, req.Platform)
+		}
+	}
 
 	// Manual update mapping or helper
 	if req.Title != "" {
 		game.Title = req.Title
 	}
 	// ... map other fields ...
-	// Check for image update/cleanup
-	if req.CoverURL != game.CoverURL {
-		// If old cover was a local image, delete it
-		if game.CoverURL != "" && len(game.CoverURL) > 8 && game.CoverURL[:8] == "/images/" {
-			oldImagePath := "." + game.CoverURL
-			os.Remove(oldImagePath) // Ignore error
+	// The old cover is released once the game no longer points at it
+	oldCoverURL := game.CoverURL
+	game.CoverURL = unsignedImageURL(req.CoverURL)
+	if game.CoverURL != oldCoverURL {
+		localized, err := h.localizeCoverURL(c.UserContext(), game.UserID, game.CoverURL, req.LocalizeCover)
+		if err != nil {
+			return respondImageError(c, err)
 		}
+		game.CoverURL = localized
 	}
-	game.CoverURL = req.CoverURL
 	game.Genre = req.Genre
 	if req.Status != "" {
-		game.Status = models.GameStatus(req.Status)
+		newStatus := models.GameStatus(req.Status)
+		// Leaving the wishlist means the game was bought
+		if game.Status == models.StatusWishlist && newStatus != models.StatusWishlist && game.AcquiredAt == nil {
+			now := time.Now()
+			game.AcquiredAt = &now
+		}
+		if newStatus != game.Status {
+			if newStatus == models.StatusBacklog {
+				game.QueuePosition = nextQueuePosition(database.DB, game.UserID)
+			} else {
+				game.QueuePosition = nil
+			}
+		}
+		game.Status = newStatus
 	}
 	game.Platform = req.Platform
 	if req.Platinum != nil {
@@ -154,9 +265,50 @@ func UpdateGame(c *fiber.Ctx) error {
 	game.HoursPlayed = req.HoursPlayed
 	game.HLTBEstimate = req.HLTBEstimate
 	game.ReleaseYear = req.ReleaseYear
+	if req.ReleaseDate != nil {
+		game.ReleaseDate = req.ReleaseDate
+	}
+	if game.ReleaseDate != nil {
+		game.ReleaseYear = game.ReleaseDate.Year()
+	}
+	if req.AcquiredAt != nil {
+		game.AcquiredAt = req.AcquiredAt
+	}
+	if req.PricePaid != nil {
+		game.PricePaid = req.PricePaid
+	}
+	if req.ExternalID != nil {
+		game.ExternalID = *req.ExternalID
+	}
+	if req.SeriesID != nil {
+		if *req.SeriesID == uuid.Nil {
+			game.SeriesID = nil
+		} else if userOwnsSeries(game.UserID, *req.SeriesID) {
+			game.SeriesID = req.SeriesID
+		} else {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+		}
+	}
 	game.DateFinished = req.DateFinished
 	game.ReviewText = req.ReviewText
 
+	// An owned game given a platform but no copies gets one, as on create
+	var derived *models.Ownership
+	if len(game.Ownerships) == 0 && game.Platform != "" && game.Status != models.StatusWishlist {
+		derived = &models.Ownership{GameID: game.ID}
+		err := buildOwnership(dto.OwnershipRequest{
+			Platform:    game.Platform,
+			AcquiredAt:  game.AcquiredAt,
+			Price:       game.PricePaid,
+			HoursPlayed: game.HoursPlayed,
+			Completed:   game.Status == models.StatusCompleted,
+			CompletedAt: game.DateFinished,
+		}, derived)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ownership", "error": err.Error()})
+		}
+	}
+
 	// Update Tags association
 	if req.TagIDs != nil { // empty slice means clear tags, nil means don't update
 		var tags []*models.GameTag
@@ -165,14 +317,31 @@ func UpdateGame(c *fiber.Ctx) error {
 	}
 
 	database.DB.Save(&game)
+	if derived != nil {
+		if err := database.DB.Create(derived).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update ownerships", "error": err.Error()})
+		}
+		game.Ownerships = append(game.Ownerships, *derived)
+	}
+	// Per-platform hours win over the game-level value
+	if len(game.Ownerships) > 0 {
+		if err := syncGameOwnership(database.DB, &game); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update ownerships", "error": err.Error()})
+		}
+	}
+	if oldCoverURL != game.CoverURL {
+		// If old cover was a local image no other game uses, delete it
+		h.releaseCoverImage(database.DB, oldCoverURL)
+		retainCoverImage(database.DB, game.CoverURL)
+	}
 
 	// Reload for response
-	database.DB.Preload("Tags").First(&game, game.ID)
+	database.DB.Preload("Tags").Preload("Ownerships").First(&game, game.ID)
 
-	return c.JSON(fiber.Map{"status": "success", "data": mapGameToResponse(game)})
+	return c.JSON(fiber.Map{"status": "success", "data": withCover(h.mapGameToResponse(game))})
 }
 
-func DeleteGame(c *fiber.Ctx) error {
+func (h *Handler) DeleteGame(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID := c.Locals("user_id")
 	fmt.Printf("DEBUG: DeleteGame - GameID: %s, UserID: %v\n", id, userID)
@@ -181,17 +350,48 @@ func DeleteGame(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
 	}
 
-	// Delete image if exists
-	if game.CoverURL != "" && len(game.CoverURL) > 8 && game.CoverURL[:8] == "/images/" {
-		// Assuming CoverURL is saved as "/images/filename"
-		// Remove leading "/" to get relative path "./images/filename" (or just "images/filename")
-		imagePath := "." + game.CoverURL
-		if err := os.Remove(imagePath); err != nil {
-			// Log error but proceed with deleting game
-			// fmt.Printf("Failed to delete image %s: %v\n", imagePath, err)
+	// DLCs and expansions of this game: ask before taking them down with it
+	var addOns []models.Game
+	database.DB.
+		Joins("JOIN game_relations ON game_relations.game_id = games.id").
+		Where("game_relations.related_game_id = ? AND game_relations.type IN ?", game.ID, models.AddOnRelationTypes()).
+		Where("games.user_id = ?", userID).
+		Find(&addOns)
+
+	cascade := c.Query("cascade")
+	if len(addOns) > 0 && cascade != "true" && cascade != "false" {
+		res := make([]dto.SeriesEntry, 0, len(addOns))
+		for _, addOn := range addOns {
+			res = append(res, h.mapGameToSeriesEntry(addOn))
 		}
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Game has DLCs or expansions. Retry with ?cascade=true to delete them too, or ?cascade=false to keep them",
+			"data":    res,
+		})
 	}
 
-	database.DB.Delete(&game)
-	return c.JSON(fiber.Map{"status": "success", "message": "Game deleted"})
+	toDelete := []models.Game{game}
+	if cascade == "true" {
+		toDelete = append(toDelete, addOns...)
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, g := range toDelete {
+			// Remove the game from every tier list it was placed in
+			if err := tx.Where("game_id = ?", g.ID).Delete(&models.TierItem{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Delete(&g).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not delete game", "error": err.Error()})
+	}
+	for _, g := range toDelete {
+		h.releaseCoverImage(database.DB, g.CoverURL)
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "Game deleted", "data": fiber.Map{"deleted": len(toDelete)}})
 }
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"backlog-backend/achievements"
+	"backlog-backend/config"
+	"backlog-backend/images"
+	"backlog-backend/render"
+	"backlog-backend/storage"
+)
+
+// Handler serves the API with the loaded configuration and the services
+// built from it. routes.SetupRoutes registers its methods.
+type Handler struct {
+	cfg          *config.Config
+	store        storage.Storage
+	fetcher      *images.Fetcher
+	covers       render.Covers
+	renders      render.Cache
+	achievements achievements.Provider // nil when no provider is configured
+	live         *liveRooms
+}
+
+// New returns the handlers for a configuration, storing images in store
+func New(cfg *config.Config, store storage.Storage) *Handler {
+	fetcher := images.NewFetcher(cfg.Images.FetchAllowedHosts)
+	h := &Handler{
+		cfg:          cfg,
+		store:        store,
+		fetcher:      fetcher,
+		covers:       render.Covers{Store: store, Fetcher: fetcher, CacheDir: cfg.CacheDir},
+		renders:      render.Cache{Dir: cfg.CacheDir},
+		achievements: achievements.FromConfig(cfg.Achievements),
+	}
+	h.live = newLiveRooms(h)
+	return h
+}
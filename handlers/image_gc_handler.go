@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/images"
+	"backlog-backend/models"
+	"backlog-backend/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrImageGCRunning is returned by CollectOrphanImages while another
+// instance is collecting
+var ErrImageGCRunning = errors.New("image garbage collection already running")
+
+// imageGCLockKey is the Postgres advisory lock held during a collection, so
+// only one API instance collects at a time
+const imageGCLockKey = 0x696d6763 // "imgc"
+
+// OrphanImage is a stored file the garbage collector removes, or would
+// remove in a dry run
+type OrphanImage struct {
+	Name   string        `json:"name"`
+	Size   int64         `json:"size"`
+	Age    time.Duration `json:"age"`
+	Reason string        `json:"reason"` // unreferenced or derived (its original is gone)
+}
+
+type ImageGCReport struct {
+	DryRun      bool          `json:"dry_run"`
+	Scanned     int           `json:"scanned"`
+	Referenced  int           `json:"referenced"`
+	InGrace     int           `json:"in_grace"` // Unreferenced but younger than the grace period
+	Orphans     []OrphanImage `json:"orphans"`
+	FreedBytes  int64         `json:"freed_bytes"`
+	StaleImages int           `json:"stale_images"` // Image rows whose file no longer exists
+}
+
+// Resizes are stored as resized/<stem>_<w>x<h>_<fit>.<ext>
+var resizedNameRegex = regexp.MustCompile(`^resized/(.+)_\d+x\d+_[a-z]+\.[a-z]+$`)
+
+// originalStem returns the stem of the uploaded image a stored file derives
+// from, and whether the file is derived at all
+func originalStem(name string) (string, bool) {
+	if compressed, ok := images.PrecompressedOriginal(name); ok {
+		stem, _ := originalStem(compressed)
+		return stem, true
+	}
+	if m := resizedNameRegex.FindStringSubmatch(name); m != nil {
+		return m[1], true
+	}
+	stem := strings.TrimSuffix(name, imageExt(name))
+	if images.IsVariant(name) {
+		return stem[:strings.LastIndex(stem, ".")], true
+	}
+	return stem, false
+}
+
+func imageExt(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 && !strings.Contains(name[i:], "/") {
+		return name[i:]
+	}
+	return ""
+}
+
+// CollectOrphanImages removes uploads no game uses as its cover once they
+// are older than grace, together with their derived sizes, and derived
+// files left behind by a removed original. It also refreshes the reference
+// counts of the Image rows. With dryRun nothing is changed. A collection
+// that changes anything holds an advisory lock, and fails with
+// ErrImageGCRunning when another instance holds it.
+func (h *Handler) CollectOrphanImages(ctx context.Context, grace time.Duration, dryRun bool) (*ImageGCReport, error) {
+	store := h.store
+
+	if !dryRun {
+		// Session locks belong to a connection, so hold one for the run
+		sqlDB, err := database.DB.DB()
+		if err != nil {
+			return nil, err
+		}
+		conn, err := sqlDB.Conn(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer conn.Close()
+		var locked bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", imageGCLockKey).Scan(&locked); err != nil {
+			return nil, err
+		}
+		if !locked {
+			return nil, ErrImageGCRunning
+		}
+		defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", imageGCLockKey)
+	}
+
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var covers []imageCover
+	if err := database.DB.Model(&models.Game{}).Select("user_id", "cover_url").Where("cover_url LIKE ?", "/images/%").Distinct().Find(&covers).Error; err != nil {
+		return nil, err
+	}
+	var records []models.Image
+	if err := database.DB.Find(&records).Error; err != nil {
+		return nil, err
+	}
+
+	plan := planImageGC(objects, covers, records, time.Now(), grace, dryRun)
+	for _, name := range plan.derived {
+		store.Delete(ctx, name)
+	}
+	for _, name := range plan.orphans {
+		if err := images.Remove(ctx, store, name); err != nil {
+			log.Printf("image gc: removing %s: %v", name, err)
+		}
+	}
+	for i := range plan.staleRecords {
+		database.DB.Delete(&plan.staleRecords[i])
+	}
+	for _, name := range plan.sync {
+		syncImageRefs(database.DB, name)
+	}
+	return &plan.report, nil
+}
+
+// imageCover is a game's use of a stored image as its cover
+type imageCover struct {
+	UserID   uuid.UUID
+	CoverURL string
+}
+
+// imageGCPlan is what a collection reports and, unless it is a dry run,
+// changes
+type imageGCPlan struct {
+	report       ImageGCReport
+	derived      []string       // Derived files whose original is gone
+	orphans      []string       // Unreferenced uploads, removed with their derived files
+	staleRecords []models.Image // Image rows of missing or removed files
+	sync         []string       // Names whose reference counts are refreshed
+}
+
+// planImageGC decides what a collection does with the stored objects, given
+// the games' local covers and the Image rows. An upload is referenced by its
+// uploaders' games only, as in coverRefs, while files uploaded before images
+// were tracked count every game. Orphans are kept for the grace period,
+// counted from the latest upload of the file or, for untracked files, from
+// its modification time. A dry run plans no changes.
+func planImageGC(objects []storage.Object, covers []imageCover, records []models.Image, now time.Time, grace time.Duration, dryRun bool) imageGCPlan {
+	plan := imageGCPlan{report: ImageGCReport{DryRun: dryRun, Orphans: []OrphanImage{}}}
+	report := &plan.report
+
+	// Identical uploads share a file; its grace period runs from the latest
+	uploadedAt := make(map[string]time.Time, len(records))
+	uploaders := make(map[string]map[uuid.UUID]bool, len(records))
+	for _, r := range records {
+		if r.UpdatedAt.After(uploadedAt[r.Name]) {
+			uploadedAt[r.Name] = r.UpdatedAt
+		}
+		if uploaders[r.Name] == nil {
+			uploaders[r.Name] = map[uuid.UUID]bool{}
+		}
+		uploaders[r.Name][r.UserID] = true
+	}
+
+	referenced := make(map[string]bool, len(covers))
+	for _, c := range covers {
+		name, ok := strings.CutPrefix(c.CoverURL, "/images/")
+		if !ok {
+			continue
+		}
+		if users, tracked := uploaders[name]; !tracked || users[c.UserID] {
+			referenced[name] = true
+		}
+	}
+
+	originals := make(map[string]bool)
+	for _, o := range objects {
+		if stem, derived := originalStem(o.Name); !derived {
+			originals[stem] = true
+		}
+	}
+
+	removed := make(map[string]bool)
+	for _, o := range objects {
+		report.Scanned++
+		stem, derived := originalStem(o.Name)
+		if derived {
+			if !originals[stem] && now.Sub(o.ModTime) >= grace {
+				report.Orphans = append(report.Orphans, OrphanImage{Name: o.Name, Size: o.Size, Age: now.Sub(o.ModTime), Reason: "derived"})
+				report.FreedBytes += o.Size
+				plan.derived = append(plan.derived, o.Name)
+			}
+			continue
+		}
+		if referenced[o.Name] {
+			report.Referenced++
+			continue
+		}
+		created, ok := uploadedAt[o.Name]
+		if !ok {
+			created = o.ModTime // Uploaded before images were tracked
+		}
+		age := now.Sub(created)
+		if age < grace {
+			report.InGrace++
+			continue
+		}
+		report.Orphans = append(report.Orphans, OrphanImage{Name: o.Name, Size: o.Size, Age: age, Reason: "unreferenced"})
+		report.FreedBytes += o.Size
+		removed[o.Name] = true
+		plan.orphans = append(plan.orphans, o.Name)
+	}
+
+	stored := make(map[string]bool, len(objects))
+	for _, o := range objects {
+		stored[o.Name] = true
+	}
+	synced := make(map[string]bool)
+	for _, r := range records {
+		if !stored[r.Name] {
+			report.StaleImages++
+		}
+		if !stored[r.Name] || removed[r.Name] {
+			plan.staleRecords = append(plan.staleRecords, r)
+		} else if !synced[r.Name] {
+			synced[r.Name] = true
+			plan.sync = append(plan.sync, r.Name)
+		}
+	}
+
+	if dryRun {
+		plan.derived, plan.orphans, plan.staleRecords, plan.sync = nil, nil, nil, nil
+	}
+	return plan
+}
+
+// StartImageGC runs CollectOrphanImages in the background every configured
+// interval (0 disables it), removing orphans older than the grace period.
+// The grace period leaves time to save the game form an image was uploaded
+// from.
+func (h *Handler) StartImageGC() {
+	interval := h.cfg.Images.GCInterval
+	if interval <= 0 {
+		log.Println("Image garbage collection disabled")
+		return
+	}
+	grace := h.ImageGCGrace()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			ctx, cancel := context.WithTimeout(context.Background(), interval)
+			report, err := h.CollectOrphanImages(ctx, grace, false)
+			cancel()
+			if errors.Is(err, ErrImageGCRunning) {
+				continue // Another instance is collecting
+			}
+			if err != nil {
+				log.Printf("image gc: %v", err)
+				continue
+			}
+			log.Printf("image gc: scanned %d files, removed %d orphans (%d bytes), %d still in grace period", report.Scanned, len(report.Orphans), report.FreedBytes, report.InGrace)
+		}
+	}()
+}
+
+// ImageGCGrace is the configured grace period of the image garbage collector
+func (h *Handler) ImageGCGrace() time.Duration {
+	return h.cfg.Images.GCGrace
+}
+
+var contentNameRegex = regexp.MustCompile(`^[0-9a-f]{64}\.[a-z]+$`)
+
+// ImageDedupReport lists the uploads DeduplicateImages moved to their
+// content address
+type ImageDedupReport struct {
+	DryRun     bool              `json:"dry_run"`
+	Renamed    map[string]string `json:"renamed"` // Old name -> content-addressed name
+	Duplicates int               `json:"duplicates"`
+	FreedBytes int64             `json:"freed_bytes"`
+}
+
+// DeduplicateImages moves uploads stored before content addressing to
+// <sha256><ext>, pointing every game cover and Image row at the new name.
+// Identical files collapse into one. With dryRun nothing is changed.
+func (h *Handler) DeduplicateImages(ctx context.Context, dryRun bool) (*ImageDedupReport, error) {
+	store := h.store
+	report := &ImageDedupReport{DryRun: dryRun, Renamed: map[string]string{}}
+
+	objects, err := store.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(objects))
+	for _, o := range objects {
+		existing[o.Name] = true
+	}
+
+	for _, o := range objects {
+		if _, derived := originalStem(o.Name); derived || strings.Contains(o.Name, "/") || contentNameRegex.MatchString(o.Name) {
+			continue
+		}
+		r, _, err := store.Get(ctx, o.Name)
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(data)
+		hash := hex.EncodeToString(sum[:])
+		target := hash + strings.ToLower(imageExt(o.Name))
+		report.Renamed[o.Name] = target
+		if existing[target] {
+			report.Duplicates++
+			report.FreedBytes += o.Size
+		}
+		if dryRun {
+			continue
+		}
+
+		if !existing[target] {
+			if err := store.Put(ctx, target, bytes.NewReader(data), int64(len(data)), o.ContentType); err != nil {
+				return nil, err
+			}
+			existing[target] = true
+			if img, err := images.Decode(data, images.DefaultLimits); err == nil {
+				images.SaveVariants(ctx, store, target, img, h.cfg.Images.Precompress)
+			}
+		}
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Model(&models.Game{}).Where("cover_url = ?", "/images/"+o.Name).Update("cover_url", "/images/"+target).Error; err != nil {
+				return err
+			}
+			var rows []models.Image
+			tx.Where("name = ?", o.Name).Find(&rows)
+			for _, row := range rows {
+				// The uploader may already have a row for the target
+				var count int64
+				tx.Model(&models.Image{}).Where("user_id = ? AND name = ?", row.UserID, target).Count(&count)
+				if count > 0 {
+					if err := tx.Delete(&row).Error; err != nil {
+						return err
+					}
+					continue
+				}
+				if err := tx.Model(&row).Updates(map[string]any{"name": target, "hash": hash}).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		syncImageRefs(database.DB, target)
+		images.Remove(ctx, store, o.Name)
+	}
+	return report, nil
+}
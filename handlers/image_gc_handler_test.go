@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"backlog-backend/models"
+	"backlog-backend/storage"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestPlanImageGC(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	grace := 24 * time.Hour
+	old, recent := now.Add(-48*time.Hour), now.Add(-time.Hour)
+	alice, bob := uuid.New(), uuid.New()
+
+	object := func(name string, modTime time.Time) storage.Object {
+		return storage.Object{Name: name, Size: 100, ModTime: modTime}
+	}
+	cover := func(user uuid.UUID, name string) imageCover {
+		return imageCover{UserID: user, CoverURL: "/images/" + name}
+	}
+	record := func(user uuid.UUID, name string, uploaded time.Time) models.Image {
+		return models.Image{UserID: user, Name: name, UpdatedAt: uploaded}
+	}
+
+	type want struct {
+		referenced, inGrace, stale int
+		derived, orphans, sync     []string
+		staleRecords               []string
+	}
+	tests := []struct {
+		name    string
+		objects []storage.Object
+		covers  []imageCover
+		records []models.Image
+		dryRun  bool
+		want    want
+	}{
+		{
+			name:    "used by its uploader",
+			objects: []storage.Object{object("a.jpg", old)},
+			covers:  []imageCover{cover(alice, "a.jpg")},
+			records: []models.Image{record(alice, "a.jpg", old)},
+			want:    want{referenced: 1, sync: []string{"a.jpg"}},
+		},
+		{
+			name:    "used only by someone who did not upload it",
+			objects: []storage.Object{object("a.jpg", old)},
+			covers:  []imageCover{cover(bob, "a.jpg")},
+			records: []models.Image{record(alice, "a.jpg", old)},
+			want:    want{orphans: []string{"a.jpg"}, staleRecords: []string{"a.jpg"}},
+		},
+		{
+			name:    "shared upload kept by the second uploader",
+			objects: []storage.Object{object("a.jpg", old)},
+			covers:  []imageCover{cover(bob, "a.jpg")},
+			records: []models.Image{record(alice, "a.jpg", old), record(bob, "a.jpg", old)},
+			want:    want{referenced: 1, sync: []string{"a.jpg"}},
+		},
+		{
+			name:    "unused within the grace period",
+			objects: []storage.Object{object("a.jpg", old)},
+			records: []models.Image{record(alice, "a.jpg", recent)},
+			want:    want{inGrace: 1, sync: []string{"a.jpg"}},
+		},
+		{
+			name:    "grace runs from the latest identical upload",
+			objects: []storage.Object{object("a.jpg", old)},
+			records: []models.Image{record(alice, "a.jpg", old), record(bob, "a.jpg", recent)},
+			want:    want{inGrace: 1, sync: []string{"a.jpg"}},
+		},
+		{
+			name:    "unused after the grace period",
+			objects: []storage.Object{object("a.jpg", recent)},
+			records: []models.Image{record(alice, "a.jpg", old)},
+			want:    want{orphans: []string{"a.jpg"}, staleRecords: []string{"a.jpg"}},
+		},
+		{
+			name:    "untracked legacy file used by any game",
+			objects: []storage.Object{object("legacy.jpg", old)},
+			covers:  []imageCover{cover(bob, "legacy.jpg")},
+			want:    want{referenced: 1},
+		},
+		{
+			name:    "untracked legacy file ages from its modification time",
+			objects: []storage.Object{object("legacy.jpg", old), object("new.jpg", recent)},
+			want:    want{inGrace: 1, orphans: []string{"legacy.jpg"}},
+		},
+		{
+			name: "derived files of a kept original stay",
+			objects: []storage.Object{
+				object("a.jpg", old), object("a.thumb.webp", old), object("resized/a_128x0_inside.jpg", old),
+			},
+			covers:  []imageCover{cover(alice, "a.jpg")},
+			records: []models.Image{record(alice, "a.jpg", old)},
+			want:    want{referenced: 1, sync: []string{"a.jpg"}},
+		},
+		{
+			name: "derived files without an original go after the grace period",
+			objects: []storage.Object{
+				object("gone.thumb.webp", old), object("resized/gone_128x0_inside.jpg", old),
+				object("precompressed/gone.png.gz", old), object("fresh.thumb.webp", recent),
+			},
+			want: want{derived: []string{"gone.thumb.webp", "resized/gone_128x0_inside.jpg", "precompressed/gone.png.gz"}},
+		},
+		{
+			name:    "record of a missing file",
+			objects: []storage.Object{},
+			records: []models.Image{record(alice, "missing.jpg", old)},
+			want:    want{stale: 1, staleRecords: []string{"missing.jpg"}},
+		},
+		{
+			name:    "dry run only reports",
+			objects: []storage.Object{object("a.jpg", old), object("gone.thumb.webp", old)},
+			records: []models.Image{record(alice, "a.jpg", old), record(alice, "missing.jpg", old)},
+			dryRun:  true,
+			want:    want{stale: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := planImageGC(tt.objects, tt.covers, tt.records, now, grace, tt.dryRun)
+			report := plan.report
+
+			if report.DryRun != tt.dryRun || report.Scanned != len(tt.objects) {
+				t.Errorf("dry run %v, scanned %d", report.DryRun, report.Scanned)
+			}
+			if report.Referenced != tt.want.referenced || report.InGrace != tt.want.inGrace || report.StaleImages != tt.want.stale {
+				t.Errorf("referenced %d, in grace %d, stale %d, want %d, %d, %d",
+					report.Referenced, report.InGrace, report.StaleImages, tt.want.referenced, tt.want.inGrace, tt.want.stale)
+			}
+
+			// A dry run reports the same orphans without planning their removal
+			wantOrphans := append(append([]string(nil), tt.want.derived...), tt.want.orphans...)
+			if tt.dryRun {
+				wantOrphans = []string{"a.jpg", "gone.thumb.webp"}
+			}
+			var orphans []string
+			var freed int64
+			for _, o := range report.Orphans {
+				orphans = append(orphans, o.Name)
+				freed += o.Size
+			}
+			if !sameNames(orphans, wantOrphans) || report.FreedBytes != freed {
+				t.Errorf("orphans = %v freeing %d bytes, want %v", orphans, report.FreedBytes, wantOrphans)
+			}
+
+			var staleRecords []string
+			for _, r := range plan.staleRecords {
+				staleRecords = append(staleRecords, r.Name)
+			}
+			for _, check := range []struct {
+				what      string
+				got, want []string
+			}{
+				{"derived", plan.derived, tt.want.derived},
+				{"orphans", plan.orphans, tt.want.orphans},
+				{"stale records", staleRecords, tt.want.staleRecords},
+				{"sync", plan.sync, tt.want.sync},
+			} {
+				if !sameNames(check.got, check.want) {
+					t.Errorf("%s = %v, want %v", check.what, check.got, check.want)
+				}
+			}
+		})
+	}
+}
+
+// sameNames compares name lists in order, treating nil and empty alike
+func sameNames(got, want []string) bool {
+	if len(got) == 0 && len(want) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(got, want)
+}
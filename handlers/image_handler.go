@@ -1,114 +1,665 @@
 package handlers
 
 import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/images"
+	"backlog-backend/models"
+	"backlog-backend/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"os"
+	"io"
+	"log"
+	"mime"
+	"net/http"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UploadImage handles image uploads
-func UploadImage(c *fiber.Ctx) error {
+func (h *Handler) UploadImage(c *fiber.Ctx) error {
 	// Parse the form file
 	file, err := c.FormFile("image")
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Image upload failed",
+			"status":  "error",
+			"message": "Image upload failed",
 		})
 	}
 
-	// Check file type (basic extension check)
-	ext := strings.ToLower(filepath.Ext(file.Filename))
-	if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".gif" && ext != ".webp" {
+	if file.Size > images.MaxUploadBytes {
+		return respondImageError(c, images.ErrTooLarge)
+	}
+	src, err := file.Open()
+	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid image format. Allowed: jpg, jpeg, png, gif, webp",
+			"status":  "error",
+			"message": "Image upload failed",
+		})
+	}
+	defer src.Close()
+
+	// The content decides the type, not the file name: the upload is decoded
+	// and re-encoded, which also drops EXIF/GPS metadata
+	processed, err := images.Process(src, images.DefaultLimits)
+	if err != nil {
+		return respondImageError(c, err)
+	}
+
+	saved, err := h.saveImage(c.UserContext(), uuid.MustParse(c.Locals("user_id").(string)), processed)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Could not save image",
 		})
 	}
 
-	// Get game name for filename
-	gameName := c.FormValue("game_name")
-	if gameName == "" {
-		gameName = "unknown_game"
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"status": "success",
+		"data":   h.imageResponse(processed, saved),
+	})
+}
+
+// FetchImage downloads an image from a remote URL into the image storage,
+// validating it like an upload
+func (h *Handler) FetchImage(c *fiber.Ctx) error {
+	var req dto.FetchImageRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if req.URL == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "url is required"})
 	}
 
-	// Sanitize game name (remove non-alphanumeric characters, replace spaces with underscores)
-	reg, err := regexp.Compile("[^a-zA-Z0-9]+")
+	processed, err := h.fetcher.Fetch(c.UserContext(), req.URL, images.DefaultLimits)
+	if err != nil {
+		return respondImageError(c, err)
+	}
+	saved, err := h.saveImage(c.UserContext(), uuid.MustParse(c.Locals("user_id").(string)), processed)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Regex error",
+			"status":  "error",
+			"message": "Could not save image",
 		})
 	}
-	sanitizedGameName := reg.ReplaceAllString(gameName, "_")
-	// Trim underscores from start/end
-	sanitizedGameName = strings.Trim(sanitizedGameName, "_")
 
-	// Generate filename: [uuid]_[date]_[sanitized_game_name].ext
-	// Date format: YYYYMMDD
-	dateStr := time.Now().Format("20060102")
-	id := uuid.New().String()
+	res := h.imageResponse(processed, saved)
+	res["source_url"] = req.URL
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": res})
+}
+
+type savedImage struct {
+	Name         string
+	Hash         string
+	Deduplicated bool
+	Variants     []images.Variant
+	Analysis     images.Analysis
+}
+
+// saveImage stores a processed image and its derived sizes, and records the
+// user as one of its uploaders. Files are named by the SHA-256 of their
+// content, so the same box art uploaded for several games is stored once.
+func (h *Handler) saveImage(ctx context.Context, userID uuid.UUID, processed *images.Result) (*savedImage, error) {
+	sum := sha256.Sum256(processed.Data)
+	hash := hex.EncodeToString(sum[:])
+	name := hash + processed.Ext
 
-	newFilename := fmt.Sprintf("%s_%s_%s%s", id, dateStr, sanitizedGameName, ext)
+	// Checked once before anything is written, and again with the derived
+	// files once they are
+	if err := h.checkStorageQuota(database.DB, userID, name, int64(len(processed.Data))); err != nil {
+		return nil, err
+	}
 
-	// Create images directory if it doesn't exist (safety check, main.go should do this too)
-	if _, err := os.Stat("./images"); os.IsNotExist(err) {
-		os.Mkdir("./images", 0755)
+	store := h.store
+	_, err := store.Stat(ctx, name)
+	deduplicated := err == nil
+	if err != nil && !errors.Is(err, storage.ErrNotFound) {
+		return nil, err
 	}
 
 	// Save file
-	if err := c.SaveFile(file, fmt.Sprintf("./images/%s", newFilename)); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not save image",
+	if !deduplicated {
+		if err := store.Put(ctx, name, bytes.NewReader(processed.Data), int64(len(processed.Data)), processed.ContentType); err != nil {
+			return nil, err
+		}
+		if h.cfg.Images.Precompress {
+			if err := images.SavePrecompressed(ctx, store, name, processed.Data, processed.ContentType); err != nil {
+				images.Remove(ctx, store, name)
+				return nil, err
+			}
+		}
+	}
+	var variants []images.Variant
+	if related, _ := images.Related(ctx, store, name); deduplicated && len(related) == images.VariantCount(processed.Ext) {
+		variants, err = images.Variants(name, processed.Image)
+	} else {
+		variants, err = images.SaveVariants(ctx, store, name, processed.Image, h.cfg.Images.Precompress)
+	}
+	analysis := images.Analyze(processed.Image)
+	var size int64
+	if err == nil {
+		size, err = images.StoredSize(ctx, store, name)
+	}
+	if err == nil {
+		err = database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := h.checkStorageQuota(tx, userID, name, size); err != nil {
+				return err
+			}
+			// Unreferenced until a game uses it as its cover. Uploading the
+			// same file again restarts its garbage collection grace period
+			// and fills in the placeholder data of rows from before it was
+			// computed.
+			err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "user_id"}, {Name: "name"}},
+				DoUpdates: clause.AssignmentColumns([]string{"updated_at", "size", "blur_hash", "dominant_color", "palette", "aspect_ratio"}),
+			}).Create(&models.Image{
+				UserID:        userID,
+				Name:          name,
+				Size:          size,
+				Hash:          hash,
+				ContentType:   processed.ContentType,
+				Width:         processed.Width,
+				Height:        processed.Height,
+				BlurHash:      analysis.BlurHash,
+				DominantColor: analysis.DominantColor,
+				Palette:       analysis.Palette,
+				AspectRatio:   analysis.AspectRatio,
+			}).Error
+			if err != nil {
+				return err
+			}
+			// Every uploader of a file is charged the same size
+			return tx.Model(&models.Image{}).Where("name = ?", name).UpdateColumn("size", size).Error
 		})
 	}
+	if err != nil {
+		if !deduplicated {
+			images.Remove(ctx, store, name)
+		}
+		return nil, err
+	}
+	syncImageRefs(database.DB, name)
+	return &savedImage{Name: name, Hash: hash, Deduplicated: deduplicated, Variants: variants, Analysis: analysis}, nil
+}
+
+// imageResponse describes a saved image with a srcset-style map of its
+// sizes, and of their WebP copies for PNG images. The URLs are relative; the
+// client prepends the base URL.
+func (h *Handler) imageResponse(processed *images.Result, saved *savedImage) fiber.Map {
+	sizes := fiber.Map{}
+	var srcset, webpSrcset []string
+	for _, v := range saved.Variants {
+		url := h.signImageURL("/images/" + v.Name)
+		entry := fmt.Sprintf("%s %dw", url, v.Width)
+		if v.ContentType == "image/webp" {
+			if size, ok := sizes[v.Size].(fiber.Map); ok {
+				size["webp_url"] = url
+			}
+			webpSrcset = append(webpSrcset, entry)
+			continue
+		}
+		sizes[v.Size] = fiber.Map{"url": url, "width": v.Width, "height": v.Height, "content_type": v.ContentType}
+		srcset = append(srcset, entry)
+	}
+	return fiber.Map{
+		"url":          h.signImageURL("/images/" + saved.Name),
+		"content_type": processed.ContentType,
+		"size":         len(processed.Data),
+		"width":        processed.Width,
+		"height":       processed.Height,
+		"hash":         saved.Hash,
+		"deduplicated": saved.Deduplicated,
+		"sizes":        sizes,
+		"srcset":       strings.Join(srcset, ", "),
+		"srcset_webp":  strings.Join(webpSrcset, ", "), // Empty for JPEG images
+		// Placeholder data, also returned with games using the image as cover
+		"blurhash":       saved.Analysis.BlurHash,
+		"dominant_color": saved.Analysis.DominantColor,
+		"palette":        saved.Analysis.Palette,
+		"aspect_ratio":   saved.Analysis.AspectRatio,
+	}
+}
 
-	// Return URL
-	// Assuming server is running on same host/port, or via proxy.
-	// The client will prepend the base URL.
-	imageURL := fmt.Sprintf("/images/%s", newFilename)
+// localizeCoverURL downloads a remote cover into the image storage, when
+// shouldLocalizeCover says so, and returns its local URL. Local covers are
+// returned unchanged. A cover that cannot be fetched or saved is an error
+// only when the request asked for localize_cover; under the configured
+// default the remote URL is kept so saving the game never fails over it.
+func (h *Handler) localizeCoverURL(ctx context.Context, userID uuid.UUID, coverURL string, requested *bool) (string, error) {
+	if !h.shouldLocalizeCover(requested) || (!strings.HasPrefix(coverURL, "http://") && !strings.HasPrefix(coverURL, "https://")) {
+		return coverURL, nil
+	}
+	processed, err := h.fetcher.Fetch(ctx, coverURL, images.DefaultLimits)
+	if err == nil {
+		var saved *savedImage
+		if saved, err = h.saveImage(ctx, userID, processed); err == nil {
+			return "/images/" + saved.Name, nil
+		}
+	}
+	if requested != nil {
+		return "", err
+	}
+	log.Printf("Could not localize cover %s: %v", coverURL, err)
+	return coverURL, nil
+}
 
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"url": imageURL,
-	})
+// shouldLocalizeCover reports whether a remote cover set on a game is
+// downloaded: the request's localize_cover wins over the configured default
+func (h *Handler) shouldLocalizeCover(requested *bool) bool {
+	if requested != nil {
+		return *requested
+	}
+	return h.cfg.Images.LocalizeRemoteCovers
+}
+
+// ServeImage serves an uploaded image from storage. With w and/or h it
+// serves a resized copy instead, rendered on first request and stored. When
+// image URLs are signed, requests without a valid signature get 403.
+func (h *Handler) ServeImage(c *fiber.Ctx) error {
+	name := c.Params("name")
+	if !validImageName(name) {
+		return fiber.ErrNotFound
+	}
+	var expires time.Time
+	if h.imageURLSigning() {
+		var ok bool
+		if expires, ok = h.verifyImageURL(name, c.Query("expires"), c.Query("sig")); !ok {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Invalid or expired image URL"})
+		}
+	}
+	if c.Query("w") == "" && c.Query("h") == "" {
+		return h.sendStoredImage(c, name, expires)
+	}
+
+	width, errW := strconv.Atoi(c.Query("w", "0"))
+	height, errH := strconv.Atoi(c.Query("h", "0"))
+	if errW != nil || errH != nil || width < 0 || height < 0 || width > images.MaxResizeDimension || height > images.MaxResizeDimension || width+height == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": fmt.Sprintf("w and h must be between 0 and %d, and at least one must be set", images.MaxResizeDimension),
+		})
+	}
+	fit := c.Query("fit", images.FitInside)
+	if fit != images.FitInside && fit != images.FitCover {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"status":  "error",
+			"message": "fit must be inside or cover",
+		})
+	}
+	if fit == images.FitCover && (width == 0 || height == 0) {
+		fit = images.FitInside // Nothing to crop to without both dimensions
+	}
+
+	resized, added, err := images.Resized(c.UserContext(), h.store, name, width, height, fit)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return fiber.ErrNotFound
+		}
+		if errors.Is(err, images.ErrResizeBusy) {
+			c.Set(fiber.HeaderRetryAfter, "5")
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "error", "message": err.Error()})
+		}
+		return respondImageError(c, err)
+	}
+	if added > 0 {
+		// Charged to the uploaders without a quota check, resizes are
+		// bounded by the resize steps
+		database.DB.Model(&models.Image{}).Where("name = ?", name).UpdateColumn("size", gorm.Expr("size + ?", added))
+	}
+	return h.sendStoredImage(c, resized, expires)
+}
+
+// sendStoredImage streams a stored file with validators and caching
+// headers. It answers conditional requests with 304, serves a single byte
+// range, and sends the gzip copy of the file to clients that accept it.
+// expires is when the signed URL used expires, zero for unsigned URLs.
+func (h *Handler) sendStoredImage(c *fiber.Ctx, name string, expires time.Time) error {
+	ctx := c.UserContext()
+	store := h.store
+	obj, err := store.Stat(ctx, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidName) {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+	contentType := obj.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(name))
+	}
+
+	served := obj
+	if h.cfg.Images.Precompress {
+		c.Vary(fiber.HeaderAcceptEncoding)
+		// Ranges refer to the identity encoding, so they always get the original
+		if c.Get(fiber.HeaderRange) == "" && acceptsGzip(c.Get(fiber.HeaderAcceptEncoding)) {
+			if gz, err := store.Stat(ctx, images.PrecompressedName(name)); err == nil {
+				served = gz
+				c.Set(fiber.HeaderContentEncoding, "gzip")
+			}
+		}
+	}
+
+	etag := imageETag(served)
+	c.Set(fiber.HeaderContentType, contentType)
+	c.Set(fiber.HeaderLastModified, served.ModTime.UTC().Format(http.TimeFormat))
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, imageCacheControl(name, expires))
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	if notModified(c, etag, served.ModTime) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	start, length := int64(0), served.Size
+	if c.Get(fiber.HeaderRange) != "" && served == obj && ifRangeMatches(c.Get(fiber.HeaderIfRange), etag) {
+		ranges, err := c.Range(int(obj.Size))
+		switch {
+		case errors.Is(err, fiber.ErrRangeUnsatisfiable):
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", obj.Size))
+			return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		case err == nil && ranges.Type == "bytes" && len(ranges.Ranges) == 1:
+			r := ranges.Ranges[0]
+			start, length = int64(r.Start), int64(r.End-r.Start+1)
+			c.Status(fiber.StatusPartialContent)
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", r.Start, r.End, obj.Size))
+		}
+		// Malformed and multiple ranges get the whole file
+	}
+
+	r, _, err := store.Get(ctx, served.Name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+	if start > 0 {
+		if seeker, ok := r.(io.Seeker); ok {
+			_, err = seeker.Seek(start, io.SeekStart)
+		} else {
+			_, err = io.CopyN(io.Discard, r, start)
+		}
+		if err != nil {
+			r.Close()
+			return err
+		}
+	}
+	// The stream is closed by fasthttp once it has been sent
+	return c.SendStream(struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(r, length), r}, int(length))
+}
+
+// Content-addressed files are named by the SHA-256 of their bytes
+var contentHashRegex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// imageCacheControl lets browsers and proxies keep content-addressed files,
+// their derived sizes and resizes forever, as a changed image gets a new
+// name. Files from before content addressing can be renamed, so they are
+// kept for an hour. Signed URLs are only cached by the browser, and no longer
+// than they are valid.
+func imageCacheControl(name string, expires time.Time) string {
+	stem, _ := originalStem(name)
+	immutable := contentHashRegex.MatchString(stem)
+	if !expires.IsZero() {
+		maxAge := max(int(time.Until(expires)/time.Second), 0)
+		if immutable {
+			return fmt.Sprintf("private, max-age=%d, immutable", maxAge)
+		}
+		return fmt.Sprintf("private, max-age=%d", min(maxAge, 3600))
+	}
+	if immutable {
+		return "public, max-age=31536000, immutable"
+	}
+	return "public, max-age=3600"
+}
+
+// imageETag is a strong validator for a stored file: the content hash of
+// content-addressed originals, otherwise derived from the name, size and
+// modification time
+func imageETag(obj *storage.Object) string {
+	if contentNameRegex.MatchString(obj.Name) {
+		return `"` + strings.TrimSuffix(obj.Name, imageExt(obj.Name)) + `"`
+	}
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d|%d", obj.Name, obj.Size, obj.ModTime.UnixNano())))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified evaluates If-None-Match, or If-Modified-Since when there is
+// none, against the file about to be sent
+func notModified(c *fiber.Ctx, etag string, modTime time.Time) bool {
+	if noneMatch := c.Get(fiber.HeaderIfNoneMatch); noneMatch != "" {
+		for _, tag := range strings.Split(noneMatch, ",") {
+			// If-None-Match uses the weak comparison
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if since, err := http.ParseTime(c.Get(fiber.HeaderIfModifiedSince)); err == nil {
+		return !modTime.Truncate(time.Second).After(since)
+	}
+	return false
+}
+
+// ifRangeMatches reports whether a Range request applies: without If-Range,
+// or when If-Range names the current strong ETag
+func ifRangeMatches(ifRange, etag string) bool {
+	return ifRange == "" || ifRange == etag
+}
+
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.EqualFold(strings.TrimSpace(coding), "gzip") {
+			q := strings.ReplaceAll(params, " ", "")
+			return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+		}
+	}
+	return false
+}
+
+// validImageName rejects anything that could escape the images directory
+func validImageName(name string) bool {
+	return name != "" && !strings.Contains(name, "..") && !strings.Contains(name, "/") && !strings.Contains(name, "\\")
+}
+
+// respondImageError maps image validation errors to 413/415 and remote
+// fetch errors to 400/502
+func respondImageError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, images.ErrTooLarge), errors.Is(err, images.ErrTooManyPixels):
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{"status": "error", "message": "Image too large", "error": err.Error()})
+	case errors.Is(err, images.ErrUnsupportedType), errors.Is(err, images.ErrInvalidImage):
+		return c.Status(fiber.StatusUnsupportedMediaType).JSON(fiber.Map{"status": "error", "message": "Unsupported image", "error": err.Error()})
+	case errors.Is(err, images.ErrFetchBlocked):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "URL not allowed", "error": err.Error()})
+	case errors.Is(err, ErrQuotaExceeded):
+		return c.Status(fiber.StatusInsufficientStorage).JSON(fiber.Map{"status": "error", "message": "Storage quota exceeded", "error": err.Error()})
+	case errors.Is(err, images.ErrFetchFailed):
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"status": "error", "message": "Could not download image", "error": err.Error()})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not process image", "error": err.Error()})
+}
+
+// shareCoverImage lets another user's game use an uploaded cover, recording
+// the user as one of its uploaders so the shared file is reference counted
+// for them too. Remote URLs are returned unchanged; a stored image that no
+// longer exists yields an empty URL.
+func (h *Handler) shareCoverImage(db *gorm.DB, coverURL string, userID uuid.UUID) string {
+	name, ok := coverImageName(coverURL)
+	if !ok {
+		if strings.HasPrefix(coverURL, "/images/") {
+			return ""
+		}
+		return coverURL
+	}
+	ctx := context.Background()
+	obj, err := h.store.Stat(ctx, name)
+	if err != nil {
+		return ""
+	}
+	size, err := images.StoredSize(ctx, h.store, name)
+	if err != nil {
+		return ""
+	}
+	record := models.Image{UserID: userID, Name: name, Size: size, ContentType: obj.ContentType}
+	var original models.Image
+	if db.Where("name = ?", name).First(&original).Error == nil {
+		record.Hash, record.ContentType = original.Hash, original.ContentType
+		record.Width, record.Height = original.Width, original.Height
+		record.BlurHash, record.DominantColor = original.BlurHash, original.DominantColor
+		record.Palette, record.AspectRatio = original.Palette, original.AspectRatio
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&record).Error; err != nil {
+		return ""
+	}
+	return coverURL
 }
 
 // DeleteImage handles image deletion
-func DeleteImage(c *fiber.Ctx) error {
+func (h *Handler) DeleteImage(c *fiber.Ctx) error {
 	filename := c.Params("filename")
 	if filename == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Filename required",
+			"status":  "error",
+			"message": "Filename required",
 		})
 	}
 
 	// Security: Prevent directory traversal
-	if strings.Contains(filename, "..") || strings.Contains(filename, "/") || strings.Contains(filename, "\\") {
+	if !validImageName(filename) {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid filename",
+			"status":  "error",
+			"message": "Invalid filename",
 		})
 	}
 
-	filePath := fmt.Sprintf("./images/%s", filename)
-
 	// Check if file exists
-	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+	store := h.store
+	if _, err := store.Stat(c.UserContext(), filename); errors.Is(err, storage.ErrNotFound) {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-			"error": "Image not found",
+			"status":  "error",
+			"message": "Image not found",
 		})
 	}
 
-	// Delete file
-	if err := os.Remove(filePath); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Could not delete image",
+	// Only an uploader may delete an image, and only while no game uses it
+	var image models.Image
+	if err := database.DB.Where("name = ? AND user_id = ?", filename, c.Locals("user_id")).First(&image).Error; err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"status":  "error",
+			"message": "You can only delete images you uploaded",
+		})
+	}
+	syncImageRefs(database.DB, filename)
+	database.DB.First(&image, "id = ?", image.ID)
+	if image.RefCount > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Image is still used as a game cover",
+			"data":    fiber.Map{"ref_count": image.RefCount},
 		})
 	}
 
+	// The file is shared by everyone who uploaded the same content; it goes
+	// with its derived sizes once the last uploader lets go of it
+	database.DB.Delete(&image)
+	var uploaders int64
+	database.DB.Model(&models.Image{}).Where("name = ?", filename).Count(&uploaders)
+	if uploaders == 0 {
+		if err := images.Remove(c.UserContext(), store, filename); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"status":  "error",
+				"message": "Could not delete image",
+			})
+		}
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status":  "success",
 		"message": "Image deleted successfully",
 	})
 }
+
+// coverImageName returns the storage name of a cover uploaded to the API
+func coverImageName(coverURL string) (string, bool) {
+	if !strings.HasPrefix(coverURL, "/images/") {
+		return "", false
+	}
+	name := strings.TrimPrefix(coverURL, "/images/")
+	return name, validImageName(name)
+}
+
+// coverRefs counts the games that use an image as their cover. Only games of
+// users holding an Image row for it count, so pointing a game at someone
+// else's upload does not keep it alive. Files stored before uploads were
+// tracked have no rows; every game counts for them.
+func coverRefs(db *gorm.DB, name string) (int64, error) {
+	var tracked int64
+	if err := db.Model(&models.Image{}).Where("name = ?", name).Count(&tracked).Error; err != nil {
+		return 0, err
+	}
+	query := db.Model(&models.Game{}).Where("cover_url = ?", "/images/"+name)
+	if tracked > 0 {
+		query = query.Where("user_id IN (?)", db.Model(&models.Image{}).Select("user_id").Where("name = ?", name))
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count, err
+}
+
+// syncImageRefs recounts how many games use each image as their cover
+func syncImageRefs(db *gorm.DB, names ...string) {
+	for _, name := range names {
+		if count, err := coverRefs(db, name); err == nil {
+			db.Model(&models.Image{}).Where("name = ?", name).Update("ref_count", count)
+		}
+	}
+}
+
+// retainCoverImage records that a game now uses the cover
+func retainCoverImage(db *gorm.DB, coverURL string) {
+	if name, ok := coverImageName(coverURL); ok {
+		syncImageRefs(db, name)
+	}
+}
+
+// releaseCoverImage records that a game stopped using the cover and deletes
+// it, with its derived sizes, once no game references it. A file someone
+// uploaded within the garbage collection grace period is left to the
+// collector, since identical uploads share it and a game form may be about
+// to use it. Errors are ignored so a missing file never blocks deleting the
+// game.
+func (h *Handler) releaseCoverImage(db *gorm.DB, coverURL string) {
+	name, ok := coverImageName(coverURL)
+	if !ok {
+		return
+	}
+	syncImageRefs(db, name)
+	if count, err := coverRefs(db, name); err != nil || count > 0 {
+		return
+	}
+	var pending int64
+	db.Model(&models.Image{}).Where("name = ? AND updated_at > ?", name, time.Now().Add(-h.ImageGCGrace())).Count(&pending)
+	if pending > 0 {
+		return
+	}
+	images.Remove(context.Background(), h.store, name)
+	db.Where("name = ?", name).Delete(&models.Image{})
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"backlog-backend/config"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signedQuery splits a signed image URL into its name, expiry and signature
+func signedQuery(t *testing.T, u string) (name, expires, sig string) {
+	t.Helper()
+	parsed, err := url.Parse(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := parsed.Query()
+	return strings.TrimPrefix(parsed.Path, "/images/"), q.Get("expires"), q.Get("sig")
+}
+
+func TestSignImageURL(t *testing.T) {
+	h := &Handler{cfg: config.Default()}
+
+	signed := h.signImageURL("/images/cover.jpg")
+	name, expires, sig := signedQuery(t, signed)
+	if name != "cover.jpg" || expires == "" || sig == "" {
+		t.Fatalf("signImageURL() = %s", signed)
+	}
+	if again := h.signImageURL("/images/cover.jpg"); again != signed {
+		t.Errorf("signing twice gave %s and %s, want the same URL within a window", signed, again)
+	}
+	if resigned := h.signImageURL(signed); resigned != signed {
+		t.Errorf("re-signing a signed URL gave %s", resigned)
+	}
+	if unsignedImageURL(signed) != "/images/cover.jpg" {
+		t.Errorf("unsignedImageURL(%s) = %s", signed, unsignedImageURL(signed))
+	}
+
+	at, _ := strconv.ParseInt(expires, 10, 64)
+	ttl := h.cfg.Images.URLTTL
+	if valid := time.Until(time.Unix(at, 0)); valid < ttl || valid > 2*ttl {
+		t.Errorf("URL is valid for %v, want between %v and %v", valid, ttl, 2*ttl)
+	}
+
+	for _, u := range []string{"https://example.com/cover.jpg", "/images/../secret", ""} {
+		if got := h.signImageURL(u); got != u {
+			t.Errorf("signImageURL(%q) = %q, want it unchanged", u, got)
+		}
+	}
+	if got := unsignedImageURL("https://example.com/cover.jpg?size=large"); got != "https://example.com/cover.jpg?size=large" {
+		t.Errorf("unsignedImageURL() stripped the query of a remote URL: %s", got)
+	}
+
+	off := config.Default()
+	off.Images.SignedURLs = false
+	if got := (&Handler{cfg: off}).signImageURL("/images/cover.jpg"); got != "/images/cover.jpg" {
+		t.Errorf("signImageURL() with signing off = %s", got)
+	}
+}
+
+func TestVerifyImageURL(t *testing.T) {
+	h := &Handler{cfg: config.Default()}
+	_, expires, sig := signedQuery(t, h.signImageURL("/images/cover.jpg"))
+
+	past := time.Now().Add(-time.Minute).Unix()
+	expired := strconv.FormatInt(past, 10)
+	expiredSig := h.imageSignature("cover.jpg", past)
+
+	// Change the last character of the signature to a different one
+	tampered := sig[:len(sig)-1] + "A"
+	if tampered == sig {
+		tampered = sig[:len(sig)-1] + "B"
+	}
+
+	other := config.Default()
+	other.Images.URLSecret = strings.Repeat("x", 32)
+
+	tests := []struct {
+		name         string
+		h            *Handler
+		image        string
+		expires, sig string
+		ok           bool
+	}{
+		{"valid", h, "cover.jpg", expires, sig, true},
+		{"derived size", h, "cover.thumb.jpg", expires, sig, true},
+		{"derived webp", h, "cover.thumb.webp", expires, sig, true},
+		{"another image", h, "other.jpg", expires, sig, false},
+		{"expired", h, "cover.jpg", expired, expiredSig, false},
+		{"extended expiry", h, "cover.jpg", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10), expiredSig, false},
+		{"tampered signature", h, "cover.jpg", expires, tampered, false},
+		{"missing signature", h, "cover.jpg", expires, "", false},
+		{"non-numeric expiry", h, "cover.jpg", "tomorrow", sig, false},
+		{"missing expiry", h, "cover.jpg", "", sig, false},
+		{"other secret", &Handler{cfg: other}, "cover.jpg", expires, sig, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			until, ok := tt.h.verifyImageURL(tt.image, tt.expires, tt.sig)
+			if ok != tt.ok {
+				t.Fatalf("verifyImageURL(%s, %s, %s) = %v, want %v", tt.image, tt.expires, tt.sig, ok, tt.ok)
+			}
+			if ok && strconv.FormatInt(until.Unix(), 10) != tt.expires {
+				t.Errorf("expires at %d, want %s", until.Unix(), tt.expires)
+			}
+		})
+	}
+}
@@ -0,0 +1,641 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	livePingInterval = 30 * time.Second
+	liveReadTimeout  = 70 * time.Second
+	liveWriteTimeout = 10 * time.Second
+	liveSendBuffer   = 64
+	liveMaxMessage   = 64 << 10
+
+	// Consecutive live edits within this window update the same version
+	// instead of filling the history with one entry per drag
+	liveVersionWindow = 5 * time.Minute
+)
+
+// liveConn is one open socket. Writes go through send so a slow client
+// never blocks the room; if its buffer fills up it is disconnected.
+type liveConn struct {
+	id       uuid.UUID
+	userID   uuid.UUID
+	username string
+	role     string
+	revision int // Last revision the client was sent, guarded by the room's mu
+	send     chan dto.LiveEvent
+	closed   chan struct{}
+	once     sync.Once
+}
+
+func (lc *liveConn) close() {
+	lc.once.Do(func() { close(lc.closed) })
+}
+
+func (lc *liveConn) push(event dto.LiveEvent) {
+	select {
+	case lc.send <- event:
+	case <-lc.closed:
+	default:
+		lc.close()
+	}
+}
+
+// liveRoom holds this instance's connections to one tier list. Edits are
+// applied under the tier list's row lock and every save bumps its live
+// revision, so clients on every instance see the same order of changes.
+type liveRoom struct {
+	hub        *liveRooms
+	tierListID uuid.UUID
+	ownerID    uuid.UUID
+
+	mu     sync.Mutex
+	conns  map[*liveConn]bool
+	remote map[uuid.UUID]liveRemote // Users connected through other instances, by instance
+}
+
+// liveRemote is the last presence another instance announced for a room
+type liveRemote struct {
+	users []dto.LiveUser
+	seen  time.Time
+}
+
+// liveRooms are the rooms with connections to this instance. Changes reach
+// the rooms of every instance through Postgres notifications, see
+// live_sync.go.
+type liveRooms struct {
+	h         *Handler
+	instance  uuid.UUID   // Origin of the messages this instance publishes
+	listening atomic.Bool // Whether published messages come back through the listener
+
+	mu    sync.Mutex
+	rooms map[uuid.UUID]*liveRoom
+
+	partial map[uuid.UUID]*livePartial // Split notifications, only used by the listener
+}
+
+func newLiveRooms(h *Handler) *liveRooms {
+	return &liveRooms{
+		h:        h,
+		instance: uuid.New(),
+		rooms:    map[uuid.UUID]*liveRoom{},
+		partial:  map[uuid.UUID]*livePartial{},
+	}
+}
+
+// join adds a connection, reporting whether the room is new on this instance
+func (hub *liveRooms) join(tierList models.TierList, lc *liveConn) (*liveRoom, bool) {
+	hub.mu.Lock()
+	room, ok := hub.rooms[tierList.ID]
+	if !ok {
+		room = &liveRoom{
+			hub:        hub,
+			tierListID: tierList.ID,
+			ownerID:    tierList.UserID,
+			conns:      map[*liveConn]bool{},
+			remote:     map[uuid.UUID]liveRemote{},
+		}
+		hub.rooms[tierList.ID] = room
+	}
+	room.mu.Lock()
+	room.conns[lc] = true
+	room.mu.Unlock()
+	hub.mu.Unlock()
+	return room, !ok
+}
+
+func (hub *liveRooms) leave(room *liveRoom, lc *liveConn) {
+	hub.mu.Lock()
+	room.mu.Lock()
+	delete(room.conns, lc)
+	empty := len(room.conns) == 0
+	room.mu.Unlock()
+	if empty && hub.rooms[room.tierListID] == room {
+		delete(hub.rooms, room.tierListID)
+	}
+	hub.mu.Unlock()
+	if !empty {
+		room.broadcastPresence()
+	}
+	hub.announce(room, false)
+}
+
+func (hub *liveRooms) room(tierListID uuid.UUID) *liveRoom {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	return hub.rooms[tierListID]
+}
+
+func (hub *liveRooms) all() []*liveRoom {
+	hub.mu.Lock()
+	defer hub.mu.Unlock()
+	rooms := make([]*liveRoom, 0, len(hub.rooms))
+	for _, room := range hub.rooms {
+		rooms = append(rooms, room)
+	}
+	return rooms
+}
+
+// setRole applies a role change to the user's open sessions
+func (hub *liveRooms) setRole(tierListID, userID uuid.UUID, role string) {
+	hub.send(liveMessage{TierListID: tierListID, Kind: liveMsgRole, UserID: userID, Role: role})
+}
+
+// kick disconnects the user's open sessions after their access was revoked
+func (hub *liveRooms) kick(tierListID, userID uuid.UUID) {
+	hub.send(liveMessage{TierListID: tierListID, Kind: liveMsgKick, UserID: userID})
+}
+
+// closeRoom disconnects everyone, e.g. when the tier list is deleted
+func (hub *liveRooms) closeRoom(tierListID uuid.UUID) {
+	hub.send(liveMessage{TierListID: tierListID, Kind: liveMsgClose})
+}
+
+// notifyLiveChange pushes a fresh state to live sessions of the tier list
+func (h *Handler) notifyLiveChange(tierListID string) {
+	if id, err := uuid.Parse(tierListID); err == nil {
+		h.live.send(liveMessage{TierListID: id, Kind: liveMsgState})
+	}
+}
+
+// handle applies a message from any instance to the local room
+func (hub *liveRooms) handle(msg liveMessage) {
+	room := hub.room(msg.TierListID)
+	if room == nil {
+		return
+	}
+	switch msg.Kind {
+	case liveMsgOps:
+		if msg.Event != nil {
+			room.deliver(*msg.Event)
+		}
+	case liveMsgState:
+		room.refresh()
+	case liveMsgPresence:
+		if msg.Origin == hub.instance {
+			return
+		}
+		room.mergePresence(msg.Origin, msg.Users, time.Now())
+		if msg.Sync {
+			hub.announce(room, false)
+		}
+	case liveMsgRole:
+		if room.setRole(msg.UserID, msg.Role) {
+			room.broadcastPresence()
+			hub.announce(room, false)
+		}
+	case liveMsgKick:
+		room.disconnect(func(lc *liveConn) bool { return lc.userID == msg.UserID }, "Access revoked")
+	case liveMsgClose:
+		room.disconnect(func(*liveConn) bool { return true }, "Tier list deleted")
+	}
+}
+
+// roleOf reads a connection's role, which the owner can change while it is open
+func (r *liveRoom) roleOf(lc *liveConn) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return lc.role
+}
+
+// setRole changes the role of the user's connections, reporting whether any changed
+func (r *liveRoom) setRole(userID uuid.UUID, role string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	for lc := range r.conns {
+		if lc.userID == userID && lc.role != role {
+			lc.role = role
+			changed = true
+		}
+	}
+	return changed
+}
+
+// disconnect closes the matching connections, telling them why
+func (r *liveRoom) disconnect(match func(*liveConn) bool, reason string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for lc := range r.conns {
+		if match(lc) {
+			lc.push(dto.LiveEvent{Type: dto.LiveClosed, Error: reason})
+			lc.close()
+		}
+	}
+}
+
+func (r *liveRoom) broadcast(event dto.LiveEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for lc := range r.conns {
+		lc.push(event)
+	}
+}
+
+// deliver sends applied operations to the connections that have not seen
+// them yet; a state sent in the meantime may already include them
+func (r *liveRoom) deliver(event dto.LiveEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for lc := range r.conns {
+		if event.Revision > lc.revision {
+			lc.revision = event.Revision
+			lc.push(event)
+		}
+	}
+}
+
+func (r *liveRoom) localUsers() []dto.LiveUser {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := make([]dto.LiveUser, 0, len(r.conns))
+	for lc := range r.conns {
+		users = append(users, dto.LiveUser{ConnectionID: lc.id, UserID: lc.userID, Username: lc.username, Role: lc.role})
+	}
+	return users
+}
+
+// broadcastPresence lists the users of every instance to local connections
+func (r *liveRoom) broadcastPresence() {
+	users := r.localUsers()
+	r.mu.Lock()
+	for _, remote := range r.remote {
+		users = append(users, remote.users...)
+	}
+	r.mu.Unlock()
+	r.broadcast(dto.LiveEvent{Type: dto.LivePresence, Users: users})
+}
+
+// mergePresence records the users another instance has in the room
+func (r *liveRoom) mergePresence(instance uuid.UUID, users []dto.LiveUser, now time.Time) {
+	r.mu.Lock()
+	if len(users) == 0 {
+		delete(r.remote, instance)
+	} else {
+		r.remote[instance] = liveRemote{users: users, seen: now}
+	}
+	r.mu.Unlock()
+	r.broadcastPresence()
+}
+
+// expirePresence forgets instances that stopped announcing their users,
+// e.g. because they shut down; it reports whether any were forgotten
+func (r *liveRoom) expirePresence(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	expired := false
+	for instance, remote := range r.remote {
+		if now.Sub(remote.seen) > livePresenceTimeout {
+			delete(r.remote, instance)
+			expired = true
+		}
+	}
+	return expired
+}
+
+// state builds a full snapshot for (re)synchronising clients. The list and
+// the library are read from one snapshot, so the revision matches both.
+func (r *liveRoom) state() (dto.LiveEvent, error) {
+	var tierList models.TierList
+	var games []models.Game
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if tierList, err = loadTierList(tx, r.tierListID.String(), r.ownerID); err != nil {
+			return err
+		}
+		return tx.Select("id", "title", "cover_url").Where("user_id = ?", r.ownerID).Order("title asc").Find(&games).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return dto.LiveEvent{}, err
+	}
+	h := r.hub.h
+	library := make([]dto.LiveGame, 0, len(games))
+	for _, g := range games {
+		library = append(library, dto.LiveGame{ID: g.ID, Title: g.Title, CoverURL: h.signImageURL(g.CoverURL)})
+	}
+	res := h.mapTierListToResponse(tierList)
+	return dto.LiveEvent{Type: dto.LiveState, Revision: tierList.LiveRevision, TierList: &res, Library: library}, nil
+}
+
+// pushState sends a state to one connection, which is now at its revision
+func (r *liveRoom) pushState(lc *liveConn, state dto.LiveEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.ConnectionID = &lc.id
+	state.Role = lc.role
+	lc.revision = state.Revision
+	lc.push(state)
+}
+
+func (r *liveRoom) sendState(lc *liveConn) {
+	state, err := r.state()
+	if err != nil {
+		lc.push(dto.LiveEvent{Type: dto.LiveClosed, Error: "Tier list not found"})
+		lc.close()
+		return
+	}
+	r.pushState(lc, state)
+}
+
+// refresh sends the latest state to connections that are behind it, after
+// a save outside the live session or notifications that may have been missed
+func (r *liveRoom) refresh() {
+	state, err := r.state()
+	if err != nil {
+		log.Printf("live: could not load tier list %s: %v", r.tierListID, err)
+		return
+	}
+	r.mu.Lock()
+	var behind []*liveConn
+	for lc := range r.conns {
+		if lc.revision < state.Revision {
+			behind = append(behind, lc)
+		}
+	}
+	r.mu.Unlock()
+	for _, lc := range behind {
+		r.pushState(lc, state)
+	}
+}
+
+// apply runs a batch of operations against the latest stored state. Batches
+// are serialised by the tier list's row lock, so a batch based on an older
+// revision is applied on top of the changes it has not seen: operations
+// address rows and items by ID, and positions that no longer exist are
+// clamped. A batch that references something removed in the meantime is
+// rejected as a whole and the sender gets a fresh state to rebase on.
+func (r *liveRoom) apply(lc *liveConn, req dto.LiveRequest) {
+	if r.roleOf(lc) == string(models.CollaboratorViewer) {
+		lc.push(dto.LiveEvent{Type: dto.LiveReject, ClientSeq: req.ClientSeq, Error: "Viewers cannot edit"})
+		return
+	}
+	if len(req.Operations) == 0 {
+		return
+	}
+
+	ops := append([]dto.TierListOperation(nil), req.Operations...)
+	current := 0
+	msg := liveMessage{TierListID: r.tierListID, Kind: liveMsgOps}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockTierList(tx, r.tierListID.String(), r.ownerID)
+		if err != nil {
+			return err
+		}
+		current = existing.LiveRevision
+		stale := req.BaseRevision < existing.LiveRevision
+		edited := cloneTierList(existing)
+		for i := range ops {
+			// Server-assigned IDs are sent back so every client uses the same ones
+			switch ops[i].Op {
+			case dto.OpAddRow:
+				if ops[i].RowID == uuid.Nil {
+					ops[i].RowID = uuid.New()
+				}
+			case dto.OpAddItem:
+				if ops[i].ItemID == uuid.Nil {
+					ops[i].ItemID = uuid.New()
+				}
+			}
+			if stale {
+				clampOperationIndex(&edited, &ops[i])
+			}
+			if err := applyTierListOperation(&edited, ops[i]); err != nil {
+				return &tierListOpError{Index: i, Op: ops[i].Op, Err: err}
+			}
+		}
+		if err := checkTierListGames(tx, r.ownerID, &edited); err != nil {
+			return err
+		}
+		if err := saveTierListState(tx, &existing, &edited); err != nil {
+			return err
+		}
+		if err := recordLiveVersion(tx, &edited); err != nil {
+			return err
+		}
+		msg.Event = &dto.LiveEvent{
+			Type:         dto.LiveOps,
+			Revision:     edited.LiveRevision,
+			ConnectionID: &lc.id,
+			ClientSeq:    req.ClientSeq,
+			Operations:   ops,
+		}
+		// Published with the commit, so instances get batches in revision order
+		return r.hub.publish(tx, msg)
+	})
+	if err != nil {
+		lc.push(dto.LiveEvent{Type: dto.LiveReject, ClientSeq: req.ClientSeq, Revision: current, Error: liveErrorMessage(err)})
+		if state, stateErr := r.state(); stateErr == nil {
+			r.pushState(lc, state)
+		}
+		return
+	}
+	if !r.hub.listening.Load() {
+		r.hub.handle(msg)
+	}
+}
+
+func liveErrorMessage(err error) string {
+	var opErr *tierListOpError
+	var itemErr *tierItemValidationError
+	switch {
+	case errors.As(err, &opErr):
+		return opErr.Error()
+	case errors.As(err, &itemErr):
+		return itemErr.Error()
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return "Tier list not found"
+	}
+	return "Could not apply operations"
+}
+
+// clampOperationIndex keeps a target position from a stale client in range
+func clampOperationIndex(t *models.TierList, op *dto.TierListOperation) {
+	if op.Index == nil {
+		return
+	}
+	max := -1
+	switch op.Op {
+	case dto.OpAddRow:
+		max = len(t.Rows)
+	case dto.OpMoveRow:
+		max = len(t.Rows) - 1
+	case dto.OpAddItem:
+		if idx := findRowIndex(t, op.RowID); idx >= 0 {
+			max = len(t.Rows[idx].Items)
+		}
+	case dto.OpMoveItem:
+		rowIdx, _ := findItemIndex(t, op.ItemID)
+		target := rowIdx
+		if op.RowID != uuid.Nil {
+			target = findRowIndex(t, op.RowID)
+		}
+		if rowIdx >= 0 && target >= 0 {
+			max = len(t.Rows[target].Items)
+			if target == rowIdx {
+				max--
+			}
+		}
+	}
+	if max < 0 {
+		return
+	}
+	index := *op.Index
+	if index > max {
+		index = max
+	}
+	if index < 0 {
+		index = 0
+	}
+	op.Index = &index
+}
+
+// recordLiveVersion folds a burst of live edits into a single version
+func recordLiveVersion(tx *gorm.DB, t *models.TierList) error {
+	var last models.TierListVersion
+	err := tx.Where("tier_list_id = ?", t.ID).Order("version desc").First(&last).Error
+	if err == nil && last.Source == models.VersionSourceLive && time.Since(last.CreatedAt) < liveVersionWindow {
+		data, err := json.Marshal(snapshotTierList(t))
+		if err != nil {
+			return err
+		}
+		return tx.Model(&last).Update("snapshot", data).Error
+	}
+	return recordTierListVersion(tx, t, models.VersionSourceLive)
+}
+
+// LiveTierListUpgrade authorises a live editing connection before the
+// WebSocket handshake
+func (h *Handler) LiveTierListUpgrade(c *fiber.Ctx) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return c.Status(fiber.StatusUpgradeRequired).JSON(fiber.Map{"status": "error", "message": "WebSocket upgrade required"})
+	}
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+	tierList, role, err := tierListAccess(database.DB, c.Params("id"), userID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+	var user models.User
+	database.DB.Select("username").First(&user, "id = ?", userID)
+
+	c.Locals("live_tier_list", tierList)
+	c.Locals("live_role", role)
+	c.Locals("live_username", user.Username)
+	return c.Next()
+}
+
+// LiveTierList serves the live editing socket of a tier list
+func (h *Handler) LiveTierList() fiber.Handler {
+	return websocket.New(func(ws *websocket.Conn) {
+		tierList := ws.Locals("live_tier_list").(models.TierList)
+		userID, _ := uuid.Parse(ws.Locals("user_id").(string))
+		lc := &liveConn{
+			id:       uuid.New(),
+			userID:   userID,
+			username: ws.Locals("live_username").(string),
+			role:     ws.Locals("live_role").(string),
+			send:     make(chan dto.LiveEvent, liveSendBuffer),
+			closed:   make(chan struct{}),
+		}
+
+		room, created := h.live.join(tierList, lc)
+		defer h.live.leave(room, lc)
+
+		done := make(chan struct{})
+		go writeLiveEvents(ws, lc, done)
+		defer func() { <-done }()
+		defer lc.close()
+
+		room.sendState(lc)
+		room.broadcastPresence()
+		// A new room asks the other instances who is connected there
+		h.live.announce(room, created)
+
+		ws.SetReadLimit(liveMaxMessage)
+		ws.SetReadDeadline(time.Now().Add(liveReadTimeout))
+		ws.SetPongHandler(func(string) error {
+			return ws.SetReadDeadline(time.Now().Add(liveReadTimeout))
+		})
+
+		for {
+			var req dto.LiveRequest
+			if err := ws.ReadJSON(&req); err != nil {
+				return
+			}
+			select {
+			case <-lc.closed:
+				return
+			default:
+			}
+			ws.SetReadDeadline(time.Now().Add(liveReadTimeout))
+
+			switch req.Type {
+			case dto.LiveOps:
+				room.apply(lc, req)
+			case dto.LiveResync:
+				room.sendState(lc)
+			default:
+				lc.push(dto.LiveEvent{Type: dto.LiveReject, ClientSeq: req.ClientSeq, Error: "Unknown message type"})
+			}
+		}
+	})
+}
+
+// writeLiveEvents is the only writer of the socket; it also sends pings and
+// closes the socket once the connection is closed
+func writeLiveEvents(ws *websocket.Conn, lc *liveConn, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(livePingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-lc.send:
+			ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+			if err := ws.WriteJSON(event); err != nil {
+				lc.close()
+				ws.Close()
+				return
+			}
+		case <-ticker.C:
+			if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteTimeout)); err != nil {
+				lc.close()
+				ws.Close()
+				return
+			}
+		case <-lc.closed:
+			// Flush what is queued (e.g. a "closed" event) before hanging up
+			for {
+				select {
+				case event := <-lc.send:
+					ws.SetWriteDeadline(time.Now().Add(liveWriteTimeout))
+					if ws.WriteJSON(event) != nil {
+						ws.Close()
+						return
+					}
+				default:
+					ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(liveWriteTimeout))
+					ws.Close()
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestClampOperationIndex(t *testing.T) {
+	at := func(i int) *int { return &i }
+
+	tests := []struct {
+		name string
+		op   func(f *tierListFixture) dto.TierListOperation
+		want *int // nil when the index is left alone
+	}{
+		{
+			name: "no index",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C"}
+			},
+		},
+		{
+			name: "add row past the end",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C", Index: at(9)}
+			},
+			want: at(2),
+		},
+		{
+			name: "negative index",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C", Index: at(-3)}
+			},
+			want: at(0),
+		},
+		{
+			name: "move row past the end",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveRow, RowID: f.rowA, Index: at(5)}
+			},
+			want: at(1),
+		},
+		{
+			name: "add item past the end",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddItem, RowID: f.rowA, GameID: f.game3, Index: at(10)}
+			},
+			want: at(2),
+		},
+		{
+			name: "in range",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddItem, RowID: f.rowA, GameID: f.game3, Index: at(1)}
+			},
+			want: at(1),
+		},
+		{
+			name: "add item to an unknown row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddItem, RowID: uuid.New(), GameID: f.game3, Index: at(10)}
+			},
+			want: at(10),
+		},
+		{
+			name: "move item within its row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: f.item1, Index: at(10)}
+			},
+			want: at(1),
+		},
+		{
+			name: "move item to its own row by id",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: f.item1, RowID: f.rowA, Index: at(10)}
+			},
+			want: at(1),
+		},
+		{
+			name: "move item to an empty row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: f.item1, RowID: f.rowB, Index: at(10)}
+			},
+			want: at(0),
+		},
+		{
+			name: "move an unknown item",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: uuid.New(), RowID: f.rowB, Index: at(10)}
+			},
+			want: at(10),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTierListFixture()
+			op := tt.op(f)
+			clampOperationIndex(&f.list, &op)
+			switch {
+			case tt.want == nil && op.Index != nil:
+				t.Fatalf("index = %d, want none", *op.Index)
+			case tt.want != nil && op.Index == nil:
+				t.Fatalf("index = none, want %d", *tt.want)
+			case tt.want != nil && *op.Index != *tt.want:
+				t.Fatalf("index = %d, want %d", *op.Index, *tt.want)
+			}
+		})
+	}
+}
+
+func newLiveTestConn(userID uuid.UUID, role string, revision int) *liveConn {
+	return &liveConn{
+		id:       uuid.New(),
+		userID:   userID,
+		username: "user",
+		role:     role,
+		revision: revision,
+		send:     make(chan dto.LiveEvent, liveSendBuffer),
+		closed:   make(chan struct{}),
+	}
+}
+
+// received drains the events pushed to a connection
+func received(lc *liveConn) []dto.LiveEvent {
+	var events []dto.LiveEvent
+	for {
+		select {
+		case event := <-lc.send:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestLiveRoomDeliver(t *testing.T) {
+	hub := newLiveRooms(nil)
+	behind := newLiveTestConn(uuid.New(), "editor", 3)
+	ahead := newLiveTestConn(uuid.New(), "editor", 5) // Got a state at revision 5
+	room, _ := hub.join(models.TierList{ID: uuid.New()}, behind)
+	hub.join(models.TierList{ID: room.tierListID}, ahead)
+
+	for _, revision := range []int{4, 5, 6, 6, 2} {
+		room.deliver(dto.LiveEvent{Type: dto.LiveOps, Revision: revision})
+	}
+
+	revisions := func(lc *liveConn) []int {
+		var got []int
+		for _, event := range received(lc) {
+			got = append(got, event.Revision)
+		}
+		return got
+	}
+	if got := revisions(behind); !slices.Equal(got, []int{4, 5, 6}) {
+		t.Errorf("connection at 3 got revisions %v, want [4 5 6]", got)
+	}
+	if got := revisions(ahead); !slices.Equal(got, []int{6}) {
+		t.Errorf("connection at 5 got revisions %v, want [6]", got)
+	}
+	if behind.revision != 6 || ahead.revision != 6 {
+		t.Errorf("revisions = %d, %d, want 6", behind.revision, ahead.revision)
+	}
+}
+
+func TestLiveRoomPresence(t *testing.T) {
+	hub := newLiveRooms(nil)
+	local := newLiveTestConn(uuid.New(), "owner", 0)
+	room, created := hub.join(models.TierList{ID: uuid.New()}, local)
+	if !created {
+		t.Fatal("join() of a new room reported an existing one")
+	}
+
+	instanceA, instanceB := uuid.New(), uuid.New()
+	userA := dto.LiveUser{ConnectionID: uuid.New(), UserID: uuid.New(), Username: "a", Role: "editor"}
+	userB := dto.LiveUser{ConnectionID: uuid.New(), UserID: uuid.New(), Username: "b", Role: "viewer"}
+	start := time.Now()
+
+	users := func() []string {
+		t.Helper()
+		events := received(local)
+		if len(events) == 0 || events[len(events)-1].Type != dto.LivePresence {
+			t.Fatalf("events = %+v, want a presence last", events)
+		}
+		var names []string
+		for _, u := range events[len(events)-1].Users {
+			names = append(names, u.Username)
+		}
+		slices.Sort(names)
+		return names
+	}
+
+	room.mergePresence(instanceA, []dto.LiveUser{userA}, start)
+	room.mergePresence(instanceB, []dto.LiveUser{userB}, start.Add(livePresenceInterval))
+	if got := users(); !slices.Equal(got, []string{"a", "b", "user"}) {
+		t.Errorf("users = %v, want a, b and the local user", got)
+	}
+
+	// Instance A went quiet, B announced recently
+	if !room.expirePresence(start.Add(livePresenceTimeout + time.Second)) {
+		t.Fatal("expirePresence() dropped nobody")
+	}
+	room.broadcastPresence()
+	if got := users(); !slices.Equal(got, []string{"b", "user"}) {
+		t.Errorf("users after expiry = %v, want b and the local user", got)
+	}
+	if room.expirePresence(start.Add(livePresenceTimeout + time.Second)) {
+		t.Error("expirePresence() dropped an instance twice")
+	}
+
+	// An empty announcement means the instance's users left
+	room.mergePresence(instanceB, nil, start)
+	if got := users(); !slices.Equal(got, []string{"user"}) {
+		t.Errorf("users after leaving = %v, want only the local user", got)
+	}
+}
+
+func TestLiveRoomsHandle(t *testing.T) {
+	editor, viewer := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		msg        func(hub *liveRooms, id uuid.UUID) liveMessage
+		wantEvents []string // Event types the editor's connection gets
+		wantRole   string
+		wantClosed bool
+	}{
+		{
+			name: "ops",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: id, Kind: liveMsgOps, Event: &dto.LiveEvent{Type: dto.LiveOps, Revision: 1}}
+			},
+			wantEvents: []string{dto.LiveOps},
+			wantRole:   "editor",
+		},
+		{
+			name: "ops for another tier list",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: uuid.New(), Kind: liveMsgOps, Event: &dto.LiveEvent{Type: dto.LiveOps, Revision: 1}}
+			},
+			wantRole: "editor",
+		},
+		{
+			name: "presence of another instance",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{Origin: uuid.New(), TierListID: id, Kind: liveMsgPresence, Users: []dto.LiveUser{{Username: "remote"}}}
+			},
+			wantEvents: []string{dto.LivePresence},
+			wantRole:   "editor",
+		},
+		{
+			name: "own presence",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{Origin: hub.instance, TierListID: id, Kind: liveMsgPresence, Users: []dto.LiveUser{{Username: "user"}}}
+			},
+			wantRole: "editor",
+		},
+		{
+			name: "role change",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: id, Kind: liveMsgRole, UserID: editor, Role: "viewer"}
+			},
+			wantEvents: []string{dto.LivePresence},
+			wantRole:   "viewer",
+		},
+		{
+			name: "unchanged role",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: id, Kind: liveMsgRole, UserID: editor, Role: "editor"}
+			},
+			wantRole: "editor",
+		},
+		{
+			name: "kick of another user",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: id, Kind: liveMsgKick, UserID: viewer}
+			},
+			wantRole: "editor",
+		},
+		{
+			name: "kick",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: id, Kind: liveMsgKick, UserID: editor}
+			},
+			wantEvents: []string{dto.LiveClosed},
+			wantRole:   "editor",
+			wantClosed: true,
+		},
+		{
+			name: "close",
+			msg: func(hub *liveRooms, id uuid.UUID) liveMessage {
+				return liveMessage{TierListID: id, Kind: liveMsgClose}
+			},
+			wantEvents: []string{dto.LiveClosed},
+			wantRole:   "editor",
+			wantClosed: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newLiveRooms(nil)
+			lc := newLiveTestConn(editor, "editor", 0)
+			room, _ := hub.join(models.TierList{ID: uuid.New()}, lc)
+
+			hub.handle(tt.msg(hub, room.tierListID))
+
+			var types []string
+			for _, event := range received(lc) {
+				types = append(types, event.Type)
+			}
+			if !slices.Equal(types, tt.wantEvents) {
+				t.Errorf("events = %v, want %v", types, tt.wantEvents)
+			}
+			if role := room.roleOf(lc); role != tt.wantRole {
+				t.Errorf("role = %s, want %s", role, tt.wantRole)
+			}
+			select {
+			case <-lc.closed:
+				if !tt.wantClosed {
+					t.Error("connection closed")
+				}
+			default:
+				if tt.wantClosed {
+					t.Error("connection still open")
+				}
+			}
+		})
+	}
+}
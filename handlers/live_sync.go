@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/gorm"
+)
+
+// Live rooms of every instance are kept in sync through Postgres
+// LISTEN/NOTIFY: whatever changes a room is published on liveChannel and
+// every instance, the publishing one included, applies it to its own
+// connections. Applied batches are published inside their transaction, so
+// they arrive in commit order, which is revision order.
+const (
+	liveChannel = "tier_list_live"
+
+	// Postgres rejects payloads of 8000 bytes or more; larger messages are
+	// split into parts of this size, base64 encoded
+	liveNoticePart     = 4000
+	livePartialTimeout = time.Minute
+
+	liveListenRetry = 5 * time.Second
+
+	// Instances announce their users this often; users of an instance that
+	// stopped announcing are dropped from presence after the timeout
+	livePresenceInterval = 30 * time.Second
+	livePresenceTimeout  = 75 * time.Second
+)
+
+// Kinds of messages between instances
+const (
+	liveMsgOps      = "ops"      // Applied batch, with its revision
+	liveMsgState    = "state"    // Saved outside the live session; clients reload
+	liveMsgPresence = "presence" // Users connected through the sending instance
+	liveMsgRole     = "role"     // A collaborator's role changed
+	liveMsgKick     = "kick"     // A collaborator lost access
+	liveMsgClose    = "close"    // The tier list was deleted
+)
+
+// liveMessage is published to every instance; only the fields relevant to
+// the kind are set
+type liveMessage struct {
+	Origin     uuid.UUID      `json:"origin"`
+	TierListID uuid.UUID      `json:"tier_list_id"`
+	Kind       string         `json:"kind"`
+	Event      *dto.LiveEvent `json:"event,omitempty"`
+	UserID     uuid.UUID      `json:"user_id"`
+	Role       string         `json:"role,omitempty"`
+	Users      []dto.LiveUser `json:"users,omitempty"`
+	Sync       bool           `json:"sync,omitempty"` // Asks the other instances to announce their users
+}
+
+// liveNotice is one notification payload, a message or a part of it
+type liveNotice struct {
+	ID    uuid.UUID `json:"id"`
+	Part  int       `json:"part"`
+	Parts int       `json:"parts"`
+	Data  []byte    `json:"data"`
+}
+
+// livePartial collects the parts of a split message
+type livePartial struct {
+	parts   [][]byte
+	got     int
+	started time.Time
+}
+
+// splitLiveMessage cuts an encoded message into notifications that fit a
+// Postgres payload
+func splitLiveMessage(data []byte) []liveNotice {
+	id := uuid.New()
+	parts := (len(data) + liveNoticePart - 1) / liveNoticePart
+	if parts == 0 {
+		parts = 1
+	}
+	notices := make([]liveNotice, 0, parts)
+	for i := 0; i < parts; i++ {
+		end := min((i+1)*liveNoticePart, len(data))
+		notices = append(notices, liveNotice{ID: id, Part: i, Parts: parts, Data: data[i*liveNoticePart : end]})
+	}
+	return notices
+}
+
+// assemble collects a notification; it returns the whole message once every
+// part arrived. Parts of abandoned messages are dropped after a while.
+func (hub *liveRooms) assemble(n liveNotice, now time.Time) ([]byte, bool) {
+	if n.Parts <= 1 {
+		return n.Data, true
+	}
+	if n.Part < 0 || n.Part >= n.Parts {
+		return nil, false
+	}
+	p, ok := hub.partial[n.ID]
+	if !ok {
+		for id, old := range hub.partial {
+			if now.Sub(old.started) > livePartialTimeout {
+				delete(hub.partial, id)
+			}
+		}
+		p = &livePartial{parts: make([][]byte, n.Parts), started: now}
+		hub.partial[n.ID] = p
+	}
+	if len(p.parts) != n.Parts || p.parts[n.Part] != nil {
+		return nil, false
+	}
+	p.parts[n.Part] = n.Data
+	p.got++
+	if p.got < n.Parts {
+		return nil, false
+	}
+	delete(hub.partial, n.ID)
+	var data []byte
+	for _, part := range p.parts {
+		data = append(data, part...)
+	}
+	return data, true
+}
+
+// publish notifies every instance of msg when db commits. Without a
+// database nothing is published.
+func (hub *liveRooms) publish(db *gorm.DB, msg liveMessage) error {
+	if db == nil {
+		return nil
+	}
+	msg.Origin = hub.instance
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	for _, n := range splitLiveMessage(data) {
+		payload, err := json.Marshal(n)
+		if err != nil {
+			return err
+		}
+		if err := db.Exec("SELECT pg_notify(?, ?)", liveChannel, string(payload)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// send publishes a message outside of a transaction. While the listener is
+// down, this instance's rooms get it directly.
+func (hub *liveRooms) send(msg liveMessage) {
+	msg.Origin = hub.instance
+	err := hub.publish(database.DB, msg)
+	if err != nil {
+		log.Printf("live: could not publish %s for tier list %s: %v", msg.Kind, msg.TierListID, err)
+	}
+	if err != nil || !hub.listening.Load() {
+		hub.handle(msg)
+	}
+}
+
+// announce tells the other instances who is connected to the room here;
+// an empty list removes this instance's users
+func (hub *liveRooms) announce(room *liveRoom, sync bool) {
+	if !hub.listening.Load() {
+		return // No instance hears it, and presence is resent once listening
+	}
+	msg := liveMessage{TierListID: room.tierListID, Kind: liveMsgPresence, Users: room.localUsers(), Sync: sync}
+	if err := hub.publish(database.DB, msg); err != nil {
+		log.Printf("live: could not announce presence for tier list %s: %v", room.tierListID, err)
+	}
+}
+
+// receive handles a notification payload
+func (hub *liveRooms) receive(payload string, now time.Time) {
+	var n liveNotice
+	if err := json.Unmarshal([]byte(payload), &n); err != nil {
+		log.Printf("live: invalid notification: %v", err)
+		return
+	}
+	data, ok := hub.assemble(n, now)
+	if !ok {
+		return
+	}
+	var msg liveMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		log.Printf("live: invalid message: %v", err)
+		return
+	}
+	hub.handle(msg)
+}
+
+// StartLiveSync listens for live editing changes published by any instance,
+// so a tier list's collaborators can be connected to different instances.
+// The listener holds one database connection and reconnects when it drops;
+// until then each instance only reaches its own connections.
+func (h *Handler) StartLiveSync() {
+	if database.DB == nil {
+		return
+	}
+	hub := h.live
+	go func() {
+		for {
+			err := hub.listen(context.Background())
+			hub.listening.Store(false)
+			log.Printf("live: listener stopped, retrying in %v: %v", liveListenRetry, err)
+			time.Sleep(liveListenRetry)
+		}
+	}()
+	go func() {
+		ticker := time.NewTicker(livePresenceInterval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			for _, room := range hub.all() {
+				if room.expirePresence(now) {
+					room.broadcastPresence()
+				}
+				hub.announce(room, false)
+			}
+		}
+	}()
+}
+
+// listen receives notifications until the connection fails
+func (hub *liveRooms) listen(ctx context.Context) error {
+	sqlDB, err := database.DB.DB()
+	if err != nil {
+		return err
+	}
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		pc, ok := driverConn.(*stdlib.Conn)
+		if !ok {
+			return fmt.Errorf("unsupported database driver %T", driverConn)
+		}
+		// Closed rather than returned to the pool, which would keep listening
+		defer pc.Conn().Close(context.Background())
+
+		if _, err := pc.Conn().Exec(ctx, "LISTEN "+liveChannel); err != nil {
+			return err
+		}
+		hub.listening.Store(true)
+		// Catch up on what was missed while not listening
+		for _, room := range hub.all() {
+			room.refresh()
+			hub.announce(room, true)
+		}
+		for {
+			n, err := pc.Conn().WaitForNotification(ctx)
+			if err != nil {
+				return err
+			}
+			hub.receive(n.Payload, time.Now())
+		}
+	})
+}
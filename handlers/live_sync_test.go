@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSplitLiveMessage(t *testing.T) {
+	for _, size := range []int{0, 10, liveNoticePart, liveNoticePart + 1, 3*liveNoticePart + 7} {
+		data := bytes.Repeat([]byte("x"), size)
+		notices := splitLiveMessage(data)
+		var joined []byte
+		for i, n := range notices {
+			if n.ID != notices[0].ID || n.Part != i || n.Parts != len(notices) {
+				t.Errorf("size %d: notice %d = id %s, part %d of %d", size, i, n.ID, n.Part, n.Parts)
+			}
+			payload, _ := json.Marshal(n)
+			if len(payload) >= 8000 {
+				t.Errorf("size %d: payload of %d bytes, over the Postgres limit", size, len(payload))
+			}
+			joined = append(joined, n.Data...)
+		}
+		if !bytes.Equal(joined, data) {
+			t.Errorf("size %d: parts join to %d bytes", size, len(joined))
+		}
+	}
+}
+
+func TestAssembleLiveMessage(t *testing.T) {
+	data := bytes.Repeat([]byte("0123456789"), liveNoticePart/4)
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		notice func(parts []liveNotice) []liveNotice // Notifications as they arrive
+		want   []byte                                // nil when the message is incomplete
+	}{
+		{"in order", func(p []liveNotice) []liveNotice { return p }, data},
+		{"out of order", func(p []liveNotice) []liveNotice { return []liveNotice{p[2], p[0], p[1]} }, data},
+		{"duplicate part", func(p []liveNotice) []liveNotice { return []liveNotice{p[0], p[0], p[1]} }, nil},
+		{"missing part", func(p []liveNotice) []liveNotice { return []liveNotice{p[0], p[2]} }, nil},
+		{"part out of range", func(p []liveNotice) []liveNotice {
+			bad := p[1]
+			bad.Part = 5
+			return []liveNotice{p[0], bad, p[2]}
+		}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hub := newLiveRooms(nil)
+			parts := splitLiveMessage(data)
+			if len(parts) != 3 {
+				t.Fatalf("split into %d parts, want 3", len(parts))
+			}
+			var got []byte
+			for _, n := range tt.notice(parts) {
+				if whole, ok := hub.assemble(n, now); ok {
+					got = whole
+				}
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("assembled %d bytes, want %d", len(got), len(tt.want))
+			}
+		})
+	}
+
+	// Parts of a message that never completes are dropped eventually
+	hub := newLiveRooms(nil)
+	hub.assemble(splitLiveMessage(data)[0], now)
+	hub.assemble(splitLiveMessage(data)[0], now.Add(livePartialTimeout+time.Second))
+	if len(hub.partial) != 1 {
+		t.Errorf("%d partial messages kept, want only the recent one", len(hub.partial))
+	}
+}
+
+func TestReceiveLiveMessage(t *testing.T) {
+	hub := newLiveRooms(nil)
+	lc := newLiveTestConn(uuid.New(), "editor", 0)
+	room, _ := hub.join(models.TierList{ID: uuid.New()}, lc)
+
+	// Large enough to be split
+	ops := make([]dto.TierListOperation, 200)
+	for i := range ops {
+		ops[i] = dto.TierListOperation{Op: dto.OpAddRow, RowID: uuid.New(), Label: "A row with a reasonably long label"}
+	}
+	data, err := json.Marshal(liveMessage{Origin: uuid.New(), TierListID: room.tierListID, Kind: liveMsgOps,
+		Event: &dto.LiveEvent{Type: dto.LiveOps, Revision: 1, Operations: ops}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	notices := splitLiveMessage(data)
+	if len(notices) < 2 {
+		t.Fatalf("message of %d bytes was not split", len(data))
+	}
+	hub.receive("not json", time.Now())
+	for _, n := range notices {
+		payload, _ := json.Marshal(n)
+		hub.receive(string(payload), time.Now())
+	}
+
+	events := received(lc)
+	if len(events) != 1 || events[0].Revision != 1 || len(events[0].Operations) != len(ops) {
+		t.Fatalf("events = %d, want the ops at revision 1", len(events))
+	}
+}
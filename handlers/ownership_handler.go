@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+func mapOwnershipToResponse(o models.Ownership) dto.OwnershipResponse {
+	return dto.OwnershipResponse{
+		ID:           o.ID,
+		GameID:       o.GameID,
+		Platform:     string(o.Platform),
+		PlatformName: o.Platform.DisplayName(),
+		Storefront:   o.Storefront,
+		Edition:      o.Edition,
+		Format:       string(o.Format),
+		AcquiredAt:   o.AcquiredAt,
+		Price:        o.Price,
+		HoursPlayed:  o.HoursPlayed,
+		Completed:    o.Completed,
+		CompletedAt:  o.CompletedAt,
+	}
+}
+
+// platformIDs lists the platform vocabulary, returned with invalid platforms
+func platformIDs() []string {
+	ids := make([]string, len(models.Platforms))
+	for i, p := range models.Platforms {
+		ids[i] = string(p)
+	}
+	return ids
+}
+
+func unknownPlatformError(raw string) error {
+	return fmt.Errorf("unknown platform %q, allowed: %s, or a storefront such as steam, gog or psn", raw, strings.Join(platformIDs(), ", "))
+}
+
+// respondInvalidPlatform answers 400 with the accepted platforms
+func respondInvalidPlatform(c *fiber.Ctx, raw string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"status":  "error",
+		"message": "Invalid platform",
+		"error":   unknownPlatformError(raw).Error(),
+		"data":    platformIDs(),
+	})
+}
+
+// buildOwnership validates a request against the platform vocabulary and copies it onto o
+func buildOwnership(req dto.OwnershipRequest, o *models.Ownership) error {
+	platform, storefront, ok := models.ParsePlatform(req.Platform)
+	if !ok {
+		return unknownPlatformError(req.Platform)
+	}
+	format := models.OwnershipFormat(req.Format)
+	if format == "" {
+		format = models.FormatDigital
+	}
+	if format != models.FormatDigital && format != models.FormatPhysical {
+		return fmt.Errorf("invalid format %q, allowed: digital, physical", req.Format)
+	}
+	if req.HoursPlayed < 0 {
+		return fmt.Errorf("hours_played must not be negative")
+	}
+
+	o.Platform = platform
+	o.Storefront = req.Storefront
+	if o.Storefront == "" {
+		o.Storefront = storefront
+	}
+	o.Edition = req.Edition
+	o.Format = format
+	o.AcquiredAt = req.AcquiredAt
+	o.Price = req.Price
+	o.HoursPlayed = req.HoursPlayed
+	o.Completed = req.Completed
+	o.CompletedAt = req.CompletedAt
+	return nil
+}
+
+// syncGameOwnership rolls the per-platform data up to the game: total hours,
+// earliest acquisition and the primary (first acquired) platform. The legacy
+// platform field keeps a value that already names the primary platform,
+// such as "Steam" for a PC copy, so the storefront is not lost. Without
+// ownerships left the game has no hours and no platform.
+func syncGameOwnership(tx *gorm.DB, game *models.Game) error {
+	var ownerships []models.Ownership
+	if err := tx.Where("game_id = ?", game.ID).Find(&ownerships).Error; err != nil {
+		return err
+	}
+	if len(ownerships) == 0 {
+		return tx.Model(game).Updates(map[string]interface{}{"hours_played": 0, "platform": ""}).Error
+	}
+	sortOwnerships(ownerships)
+
+	hours := 0
+	for _, o := range ownerships {
+		hours += o.HoursPlayed
+	}
+	primary := ownerships[0]
+	platform := primary.Platform.DisplayName()
+	if p, _, ok := models.ParsePlatform(game.Platform); ok && p == primary.Platform {
+		platform = game.Platform
+	} else if p, _, ok := models.ParsePlatform(primary.Storefront); ok && p == primary.Platform {
+		platform = primary.Storefront
+	}
+	updates := map[string]interface{}{
+		"hours_played": hours,
+		"platform":     platform,
+	}
+	if primary.AcquiredAt != nil && (game.AcquiredAt == nil || primary.AcquiredAt.Before(*game.AcquiredAt)) {
+		updates["acquired_at"] = primary.AcquiredAt
+	}
+	return tx.Model(game).Updates(updates).Error
+}
+
+// sortOwnerships orders by acquisition date, undated copies last
+func sortOwnerships(ownerships []models.Ownership) {
+	sort.SliceStable(ownerships, func(i, j int) bool {
+		a, b := ownerships[i].AcquiredAt, ownerships[j].AcquiredAt
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+}
+
+// GetPlatforms lists the platform vocabulary
+func (h *Handler) GetPlatforms(c *fiber.Ctx) error {
+	res := make([]dto.PlatformResponse, 0, len(models.Platforms))
+	for _, p := range models.Platforms {
+		res = append(res, dto.PlatformResponse{ID: string(p), Name: p.DisplayName()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+func (h *Handler) GetOwnerships(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var ownerships []models.Ownership
+	database.DB.Where("game_id = ?", game.ID).Find(&ownerships)
+	sortOwnerships(ownerships)
+
+	res := make([]dto.OwnershipResponse, 0, len(ownerships))
+	for _, o := range ownerships {
+		res = append(res, mapOwnershipToResponse(o))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+func (h *Handler) CreateOwnership(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var req dto.OwnershipRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	ownership := models.Ownership{GameID: game.ID}
+	if err := buildOwnership(req, &ownership); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ownership", "error": err.Error()})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&ownership).Error; err != nil {
+			return err
+		}
+		return syncGameOwnership(tx, &game)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create ownership", "error": err.Error()})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": mapOwnershipToResponse(ownership)})
+}
+
+func (h *Handler) UpdateOwnership(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var ownership models.Ownership
+	if err := database.DB.Where("game_id = ?", game.ID).First(&ownership, "id = ?", c.Params("ownershipId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Ownership not found"})
+	}
+
+	var req dto.OwnershipRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if err := buildOwnership(req, &ownership); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ownership", "error": err.Error()})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&ownership).Error; err != nil {
+			return err
+		}
+		return syncGameOwnership(tx, &game)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update ownership", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "data": mapOwnershipToResponse(ownership)})
+}
+
+func (h *Handler) DeleteOwnership(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var ownership models.Ownership
+	if err := database.DB.Where("game_id = ?", game.ID).First(&ownership, "id = ?", c.Params("ownershipId")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Ownership not found"})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&ownership).Error; err != nil {
+			return err
+		}
+		return syncGameOwnership(tx, &game)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not delete ownership", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "message": "Ownership deleted"})
+}
+
+// findUserGame loads the game in the :id route param if it belongs to the caller
+func findUserGame(c *fiber.Ctx) (models.Game, error) {
+	var game models.Game
+	err := database.DB.Where("user_id = ?", c.Locals("user_id")).First(&game, "id = ?", c.Params("id")).Error
+	return game, err
+}
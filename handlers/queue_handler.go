@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errNotQueueable fails a reorder listing a game that is not one of the
+// user's backlog games
+var errNotQueueable = errors.New("not a backlog game of the user")
+
+// Default weights for the "what should I play next" picker. Every factor is
+// normalised to 0..1 before weighting, so these are relative importances.
+var defaultPickerWeights = map[string]float64{
+	"queue":    3,
+	"score":    1,
+	"hltb":     1,
+	"tags":     1,
+	"platform": 1,
+	"age":      0.5,
+	"load":     1,
+}
+
+const maxSuggestions = 10
+
+// nextQueuePosition returns the position at the end of the user's backlog queue
+func nextQueuePosition(db *gorm.DB, userID uuid.UUID) *int {
+	var maxPos int
+	db.Model(&models.Game{}).
+		Where("user_id = ? AND status = ?", userID, models.StatusBacklog).
+		Select("COALESCE(MAX(queue_position), 0)").
+		Scan(&maxPos)
+	pos := maxPos + 1
+	return &pos
+}
+
+// GetQueue returns the user's backlog ordered by queue position, unqueued games last
+func (h *Handler) GetQueue(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	var games []models.Game
+	if err := database.DB.Preload("Tags").Preload("Ownerships").
+		Where("user_id = ? AND status = ?", userID, models.StatusBacklog).
+		Order("queue_position ASC NULLS LAST, created_at ASC").
+		Find(&games).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch queue", "error": err.Error()})
+	}
+
+	res := make([]dto.GameResponse, 0, len(games))
+	for _, game := range games {
+		res = append(res, h.mapGameToResponse(game))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": withCovers(res)})
+}
+
+// ReorderQueue rewrites the backlog queue to match the order sent by the client
+func (h *Handler) ReorderQueue(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	var req dto.ReorderQueueRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+
+	seen := make(map[uuid.UUID]bool, len(req.GameIDs))
+	for _, id := range req.GameIDs {
+		if seen[id] {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Duplicate game in queue", "error": id.String()})
+		}
+		seen[id] = true
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Checked on locked rows so a game cannot leave the backlog meanwhile
+		if len(req.GameIDs) > 0 {
+			var ids []uuid.UUID
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.Game{}).
+				Where("user_id = ? AND status = ? AND id IN ?", userID, models.StatusBacklog, req.GameIDs).
+				Pluck("id", &ids).Error; err != nil {
+				return err
+			}
+			if len(ids) != len(req.GameIDs) {
+				return errNotQueueable
+			}
+		}
+		if err := tx.Model(&models.Game{}).
+			Where("user_id = ? AND status = ?", userID, models.StatusBacklog).
+			Update("queue_position", nil).Error; err != nil {
+			return err
+		}
+		for i, id := range req.GameIDs {
+			if err := tx.Model(&models.Game{}).
+				Where("id = ? AND user_id = ?", id, userID).
+				Update("queue_position", i+1).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if errors.Is(err, errNotQueueable) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Queue may only contain your own backlog games"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not reorder queue", "error": err.Error()})
+	}
+
+	return h.GetQueue(c)
+}
+
+// GetNextGame suggests backlog games to play next.
+//
+// mode=best (default) ranks the backlog by weighted score; mode=random rolls
+// the dice, picking games with probability proportional to their score.
+// Weights are overridden with weight_<factor> query params, and games can be
+// excluded by id, tag, platform or HLTB estimate.
+func (h *Handler) GetNextGame(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+
+	mode := c.Query("mode", "best")
+	if mode != "best" && mode != "random" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid mode. Allowed: best, random"})
+	}
+
+	count := c.QueryInt("count", 1)
+	if count < 1 {
+		count = 1
+	}
+	if count > maxSuggestions {
+		count = maxSuggestions
+	}
+
+	weights := make(map[string]float64, len(defaultPickerWeights))
+	for name, def := range defaultPickerWeights {
+		w := c.QueryFloat("weight_"+name, def)
+		if w < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Weights must not be negative", "error": "weight_" + name})
+		}
+		weights[name] = w
+	}
+
+	preferredTags := parseUUIDList(c.Query("tags"))
+	preferredPlatform := strings.TrimSpace(c.Query("platform"))
+	excludeIDs := parseUUIDList(c.Query("exclude"))
+	excludeTags := parseUUIDList(c.Query("exclude_tags"))
+	excludePlatforms := parseStringList(c.Query("exclude_platforms"))
+	maxHLTB := c.QueryInt("max_hltb", 0)
+
+	var games []models.Game
+	if err := database.DB.Preload("Tags").Preload("Ownerships").
+		Where("user_id = ? AND status = ?", userID, models.StatusBacklog).
+		Find(&games).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch games", "error": err.Error()})
+	}
+
+	var playingCount int64
+	database.DB.Model(&models.Game{}).Where("user_id = ? AND status = ?", userID, models.StatusPlaying).Count(&playingCount)
+
+	var queueLen int
+	for _, g := range games {
+		if g.QueuePosition != nil && *g.QueuePosition > queueLen {
+			queueLen = *g.QueuePosition
+		}
+	}
+
+	now := time.Now()
+	candidates := make([]dto.NextGameSuggestion, 0, len(games))
+	for _, game := range games {
+		if excludeIDs[game.ID] || onAnyPlatform(game, excludePlatforms) {
+			continue
+		}
+		if maxHLTB > 0 && game.HLTBEstimate > maxHLTB {
+			continue
+		}
+		if hasAnyTag(game, excludeTags) {
+			continue
+		}
+
+		breakdown := scoreGame(game, weights, preferredTags, preferredPlatform, queueLen, int(playingCount), now)
+		total := 0.0
+		for _, v := range breakdown {
+			total += v
+		}
+		candidates = append(candidates, dto.NextGameSuggestion{
+			Game:      h.mapGameToResponse(game),
+			Score:     math.Round(total*1000) / 1000,
+			Breakdown: breakdown,
+		})
+	}
+
+	var picks []dto.NextGameSuggestion
+	if mode == "random" {
+		picks = rollWeighted(candidates, count)
+	} else {
+		sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Score > candidates[j].Score })
+		if len(candidates) > count {
+			candidates = candidates[:count]
+		}
+		picks = candidates
+	}
+	urls := make([]string, len(picks))
+	for i, p := range picks {
+		urls[i] = p.Game.CoverURL
+	}
+	covers := loadCoverImages(urls)
+	for i := range picks {
+		picks[i].Game.Cover = covers[picks[i].Game.CoverURL]
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "data": dto.NextGameResponse{
+		Mode:         mode,
+		PlayingCount: int(playingCount),
+		Weights:      weights,
+		Suggestions:  picks,
+	}})
+}
+
+// scoreGame returns the weighted contribution of each factor for a single game
+func scoreGame(game models.Game, weights map[string]float64, preferredTags map[uuid.UUID]bool, preferredPlatform string, queueLen, playingCount int, now time.Time) map[string]float64 {
+	factors := map[string]float64{}
+
+	// Queued games score by position, the head of the queue scoring 1
+	if game.QueuePosition != nil && queueLen > 0 {
+		factors["queue"] = float64(queueLen-*game.QueuePosition+1) / float64(queueLen)
+	}
+
+	// Expected score on a 0-10 scale, unknown treated as average
+	factors["score"] = 0.5
+	if game.Score != nil {
+		factors["score"] = clamp01(*game.Score / 10)
+	}
+
+	// Shorter games are favoured; unknown length is neutral
+	longness := 0.5
+	if game.HLTBEstimate > 0 {
+		longness = float64(game.HLTBEstimate) / float64(game.HLTBEstimate+20)
+	}
+	factors["hltb"] = 1 - longness
+
+	if len(preferredTags) > 0 && len(game.Tags) > 0 {
+		matches := 0
+		for _, tag := range game.Tags {
+			if preferredTags[tag.ID] {
+				matches++
+			}
+		}
+		factors["tags"] = float64(matches) / float64(len(game.Tags))
+	}
+
+	if preferredPlatform != "" && onPlatform(game, preferredPlatform) {
+		factors["platform"] = 1
+	}
+
+	// Games that have waited longer rise slowly, reaching 0.5 after 6 months
+	days := now.Sub(game.CreatedAt).Hours() / 24
+	if days > 0 {
+		factors["age"] = days / (days + 180)
+	}
+
+	// The more games already in progress, the more long games are penalised
+	load := math.Min(float64(playingCount)/5, 1)
+	factors["load"] = 1 - load*longness
+
+	breakdown := make(map[string]float64, len(factors))
+	for name, v := range factors {
+		breakdown[name] = math.Round(v*weights[name]*1000) / 1000
+	}
+	return breakdown
+}
+
+// rollWeighted draws up to count suggestions without replacement, each with
+// probability proportional to its score
+func rollWeighted(candidates []dto.NextGameSuggestion, count int) []dto.NextGameSuggestion {
+	pool := append([]dto.NextGameSuggestion(nil), candidates...)
+	picks := make([]dto.NextGameSuggestion, 0, count)
+	for len(picks) < count && len(pool) > 0 {
+		total := 0.0
+		for _, s := range pool {
+			total += s.Score + 0.01 // keep zero-scored games rollable
+		}
+		r := rand.Float64() * total
+		idx := len(pool) - 1
+		for i, s := range pool {
+			r -= s.Score + 0.01
+			if r <= 0 {
+				idx = i
+				break
+			}
+		}
+		picks = append(picks, pool[idx])
+		pool = append(pool[:idx], pool[idx+1:]...)
+	}
+	return picks
+}
+
+func hasAnyTag(game models.Game, tags map[uuid.UUID]bool) bool {
+	for _, tag := range game.Tags {
+		if tags[tag.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
+
+// parseUUIDList parses a comma-separated list of UUIDs, skipping invalid entries
+func parseUUIDList(raw string) map[uuid.UUID]bool {
+	set := map[uuid.UUID]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		if id, err := uuid.Parse(strings.TrimSpace(part)); err == nil {
+			set[id] = true
+		}
+	}
+	return set
+}
+
+// parseStringList parses a comma-separated list into a lower-cased set
+// onPlatform reports whether a game is owned on the platform or storefront
+// named by raw, such as "pc", "PlayStation" or "steam". Games without
+// ownerships are matched by their legacy platform field.
+func onPlatform(game models.Game, raw string) bool {
+	platform, storefront, ok := models.ParsePlatform(raw)
+	if !ok {
+		return strings.EqualFold(strings.TrimSpace(game.Platform), strings.TrimSpace(raw))
+	}
+	matches := func(p models.Platform, s string) bool {
+		return p == platform && (storefront == "" || strings.EqualFold(s, storefront))
+	}
+	for _, o := range game.Ownerships {
+		if matches(o.Platform, o.Storefront) {
+			return true
+		}
+	}
+	if len(game.Ownerships) == 0 {
+		p, s, ok := models.ParsePlatform(game.Platform)
+		return ok && matches(p, s)
+	}
+	return false
+}
+
+func onAnyPlatform(game models.Game, platforms map[string]bool) bool {
+	for raw := range platforms {
+		if onPlatform(game, raw) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseStringList(raw string) map[string]bool {
+	set := map[string]bool{}
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			set[part] = true
+		}
+	}
+	return set
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"backlog-backend/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestScoreGame(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	ones := map[string]float64{"queue": 1, "score": 1, "hltb": 1, "tags": 1, "platform": 1, "age": 1, "load": 1}
+	ptr := func(v float64) *float64 { return &v }
+	pos := func(v int) *int { return &v }
+	action, rpg := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		game      models.Game
+		weights   map[string]float64
+		tags      map[uuid.UUID]bool
+		platform  string
+		queueLen  int
+		playing   int
+		breakdown map[string]float64
+	}{
+		{
+			name:      "unknown everything is neutral",
+			game:      models.Game{CreatedAt: now},
+			breakdown: map[string]float64{"score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "head of the queue",
+			game:      models.Game{CreatedAt: now, QueuePosition: pos(1)},
+			queueLen:  4,
+			breakdown: map[string]float64{"queue": 1, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "end of the queue",
+			game:      models.Game{CreatedAt: now, QueuePosition: pos(4)},
+			queueLen:  4,
+			breakdown: map[string]float64{"queue": 0.25, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "score is clamped to 10",
+			game:      models.Game{CreatedAt: now, Score: ptr(15)},
+			breakdown: map[string]float64{"score": 1, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "long game with a full plate",
+			game:      models.Game{CreatedAt: now, Score: ptr(8), HLTBEstimate: 60},
+			playing:   5,
+			breakdown: map[string]float64{"score": 0.8, "hltb": 0.25, "load": 0.25},
+		},
+		{
+			name:      "long game with nothing in progress",
+			game:      models.Game{CreatedAt: now, HLTBEstimate: 60},
+			breakdown: map[string]float64{"score": 0.5, "hltb": 0.25, "load": 1},
+		},
+		{
+			name:      "half the tags preferred",
+			game:      models.Game{CreatedAt: now, Tags: []*models.GameTag{{ID: action}, {ID: rpg}}},
+			tags:      map[uuid.UUID]bool{action: true},
+			breakdown: map[string]float64{"tags": 0.5, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "preferred storefront through an ownership",
+			game:      models.Game{CreatedAt: now, Ownerships: []models.Ownership{{Platform: models.PlatformPC, Storefront: "Steam"}}},
+			platform:  "steam",
+			breakdown: map[string]float64{"platform": 1, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "other storefront of the same platform",
+			game:      models.Game{CreatedAt: now, Ownerships: []models.Ownership{{Platform: models.PlatformPC, Storefront: "Epic"}}},
+			platform:  "steam",
+			breakdown: map[string]float64{"score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "legacy platform without ownerships",
+			game:      models.Game{CreatedAt: now, Platform: "Steam"},
+			platform:  "pc",
+			breakdown: map[string]float64{"platform": 1, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "waited six months",
+			game:      models.Game{CreatedAt: now.AddDate(0, 0, -180)},
+			breakdown: map[string]float64{"age": 0.5, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+		{
+			name:      "weights scale the factors",
+			game:      models.Game{CreatedAt: now, QueuePosition: pos(1)},
+			weights:   defaultPickerWeights,
+			queueLen:  1,
+			breakdown: map[string]float64{"queue": 3, "score": 0.5, "hltb": 0.5, "load": 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			weights := tt.weights
+			if weights == nil {
+				weights = ones
+			}
+			got := scoreGame(tt.game, weights, tt.tags, tt.platform, tt.queueLen, tt.playing, now)
+			if !reflect.DeepEqual(got, tt.breakdown) {
+				t.Errorf("scoreGame() = %v, want %v", got, tt.breakdown)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetUpcomingReleases returns the user's games releasing from today onwards, soonest first.
+// ?status=wishlist narrows the list to a single status.
+func (h *Handler) GetUpcomingReleases(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	query := database.DB.Preload("Tags").Preload("Ownerships").
+		Where("user_id = ? AND release_date >= ?", userID, today).
+		Order("release_date ASC")
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var games []models.Game
+	if err := query.Find(&games).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch releases", "error": err.Error()})
+	}
+
+	res := make([]dto.GameResponse, 0, len(games))
+	for _, game := range games {
+		res = append(res, h.mapGameToResponse(game))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": withCovers(res)})
+}
+
+// GetReleaseCalendar returns the wishlist release dates of the logged in user as iCalendar
+func (h *Handler) GetReleaseCalendar(c *fiber.Ctx) error {
+	return sendReleaseCalendar(c, c.Locals("user_id"))
+}
+
+// GetPublicReleaseCalendar serves the same feed to calendar apps, which cannot
+// send an Authorization header, using the user's calendar token instead
+func (h *Handler) GetPublicReleaseCalendar(c *fiber.Ctx) error {
+	token := strings.TrimSuffix(c.Params("token"), ".ics")
+	var user models.User
+	if token == "" || database.DB.Where("calendar_token = ?", token).First(&user).Error != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Calendar not found"})
+	}
+	return sendReleaseCalendar(c, user.ID)
+}
+
+// RotateCalendarToken issues a new calendar token, invalidating the previous feed URL
+func (h *Handler) RotateCalendarToken(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", userID).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not generate token"})
+	}
+	token := hex.EncodeToString(buf)
+	if err := database.DB.Model(&user).Update("calendar_token", token).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not save token", "error": err.Error()})
+	}
+
+	return c.JSON(fiber.Map{"status": "success", "data": dto.CalendarTokenResponse{
+		Token: token,
+		URL:   fmt.Sprintf("/api/calendar/%s.ics", token),
+	}})
+}
+
+func sendReleaseCalendar(c *fiber.Ctx, userID interface{}) error {
+	var games []models.Game
+	if err := database.DB.
+		Where("user_id = ? AND status = ? AND release_date IS NOT NULL", userID, models.StatusWishlist).
+		Order("release_date ASC").
+		Find(&games).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch releases", "error": err.Error()})
+	}
+
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `inline; filename="releases.ics"`)
+	return c.SendString(buildReleaseCalendar(games, time.Now().UTC()))
+}
+
+// buildReleaseCalendar renders one all-day event per game (RFC 5545)
+func buildReleaseCalendar(games []models.Game, now time.Time) string {
+	var b strings.Builder
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Gaming Backlog//Release Calendar//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "X-WR-CALNAME:Wishlist releases")
+	for _, game := range games {
+		day := game.ReleaseDate.UTC()
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+game.ID.String()+"@gaming-backlog")
+		writeICSLine(&b, "DTSTAMP:"+now.Format("20060102T150405Z"))
+		writeICSLine(&b, "DTSTART;VALUE=DATE:"+day.Format("20060102"))
+		writeICSLine(&b, "DTEND;VALUE=DATE:"+day.AddDate(0, 0, 1).Format("20060102"))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(game.Title+" releases"))
+		if game.Platform != "" {
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText("Platform: "+game.Platform))
+		}
+		writeICSLine(&b, "TRANSP:TRANSPARENT")
+		writeICSLine(&b, "END:VEVENT")
+	}
+	writeICSLine(&b, "END:VCALENDAR")
+	return b.String()
+}
+
+// writeICSLine writes a CRLF terminated content line, folded at 75 octets
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		// Do not split a multi-byte UTF-8 sequence
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // the leading space counts towards the next line
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+func escapeICSText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWriteICSLine(t *testing.T) {
+	a := func(n int) string { return strings.Repeat("a", n) }
+
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"short", "BEGIN:VEVENT", "BEGIN:VEVENT\r\n"},
+		{"exactly 75 octets", a(75), a(75) + "\r\n"},
+		{"one octet over", a(76), a(75) + "\r\n " + "a\r\n"},
+		{"continuation lines hold 74 octets", a(75 + 74 + 1), a(75) + "\r\n " + a(74) + "\r\n " + "a\r\n"},
+		{"multi-byte rune at the fold", a(74) + "é", a(74) + "\r\n " + "é\r\n"},
+		{"multi-byte rune before the fold", a(73) + "é" + "b", a(73) + "é" + "\r\n " + "b\r\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var b strings.Builder
+			writeICSLine(&b, tt.line)
+			if got := b.String(); got != tt.want {
+				t.Errorf("writeICSLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteICSLineUnfolds(t *testing.T) {
+	line := "SUMMARY:" + strings.Repeat("Pokémon Légendes Z-A ", 20)
+	var b strings.Builder
+	writeICSLine(&b, line)
+
+	out := strings.TrimSuffix(b.String(), "\r\n")
+	for _, physical := range strings.Split(out, "\r\n") {
+		if len(physical) > 75 {
+			t.Errorf("line of %d octets: %q", len(physical), physical)
+		}
+		if !utf8.ValidString(physical) {
+			t.Errorf("line splits a rune: %q", physical)
+		}
+	}
+	if unfolded := strings.ReplaceAll(out, "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolded = %q, want %q", unfolded, line)
+	}
+}
+
+func TestEscapeICSText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"Hades", "Hades"},
+		{"Zelda; Tears, of the Kingdom", `Zelda\; Tears\, of the Kingdom`},
+		{`C:\Games`, `C:\\Games`},
+		{"two\r\nlines\nthree", `two\nlines\nthree`},
+	}
+	for _, tt := range tests {
+		if got := escapeICSText(tt.in); got != tt.want {
+			t.Errorf("escapeICSText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
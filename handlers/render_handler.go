@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/models"
+	"backlog-backend/render"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// RenderTierList draws the tier list as a PNG or SVG image, e.g. for sharing
+// on sites that only accept images. Results are cached until the list changes.
+func (h *Handler) RenderTierList(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userIdStr := c.Locals("user_id").(string)
+
+	format := c.Query("format", "png")
+	if format != "png" && format != "svg" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid format. Allowed: png, svg"})
+	}
+
+	tierList, err := loadTierList(database.DB, id, userIdStr)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier list"})
+	}
+
+	opts := render.Options{
+		Width:    c.QueryInt("width", render.DefaultWidth),
+		ItemSize: c.QueryInt("item_size", render.DefaultItemSize),
+		Title:    tierList.Name,
+	}
+	if c.Query("title") == "false" {
+		opts.Title = ""
+	} else if title := c.Query("title"); title != "" && title != "true" {
+		opts.Title = title
+	}
+	opts = opts.Normalize()
+
+	contentType := "image/png"
+	if format == "svg" {
+		contentType = "image/svg+xml"
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+
+	key := renderKey(tierList, opts)
+	if data, ok := h.renders.CachedRender(id, key, format); ok {
+		return c.Send(data)
+	}
+
+	var buf bytes.Buffer
+	list := h.buildRenderTierList(tierList)
+	if format == "svg" {
+		err = render.SVG(&buf, list, opts)
+	} else {
+		err = render.PNG(&buf, list, opts)
+	}
+	if err != nil {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not render tier list"})
+	}
+
+	h.renders.StoreRender(id, key, format, buf.Bytes())
+	return c.Send(buf.Bytes())
+}
+
+// buildRenderTierList loads item covers and skips items whose game is gone
+func (h *Handler) buildRenderTierList(t models.TierList) render.TierList {
+	covers := map[string]image.Image{}
+	out := render.TierList{Rows: make([]render.Row, 0, len(t.Rows))}
+	for _, row := range t.Rows {
+		r := render.Row{Label: row.Label, Color: row.Color}
+		for _, item := range row.Items {
+			if item.Game.ID != item.GameID {
+				continue
+			}
+			cover, ok := covers[item.Game.CoverURL]
+			if !ok && item.Game.CoverURL != "" {
+				cover = h.covers.Load(item.Game.CoverURL)
+				covers[item.Game.CoverURL] = cover
+			}
+			r.Items = append(r.Items, render.Item{Title: item.Game.Title, Cover: cover})
+		}
+		out.Rows = append(out.Rows, r)
+	}
+	return out
+}
+
+// renderKey hashes everything that shows up in the image
+func renderKey(t models.TierList, opts render.Options) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d|%d|%q\n", opts.Width, opts.ItemSize, opts.Title)
+	for _, row := range t.Rows {
+		fmt.Fprintf(hash, "row|%q|%s\n", row.Label, row.Color)
+		for _, item := range row.Items {
+			if item.Game.ID != item.GameID {
+				continue
+			}
+			fmt.Fprintf(hash, "item|%q|%s\n", item.Game.Title, item.Game.CoverURL)
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil))[:16]
+}
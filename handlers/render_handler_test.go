@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"backlog-backend/models"
+	"backlog-backend/render"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestRenderKey(t *testing.T) {
+	item := func(title string) models.TierItem {
+		id := uuid.New()
+		return models.TierItem{ID: uuid.New(), GameID: id, Game: models.Game{ID: id, Title: title, CoverURL: "/images/" + title + ".jpg"}}
+	}
+	list := models.TierList{ID: uuid.New(), Name: "Favourites", Rows: []models.TierRow{
+		{Label: "S", Color: "#FF7F7F", Items: []models.TierItem{item("Hades"), item("Celeste")}},
+		{Label: "A", Color: "#FFBF7F", Items: []models.TierItem{item("Braid")}},
+	}}
+	opts := render.Options{Width: 1200, ItemSize: 96}
+	key := renderKey(list, opts)
+
+	tests := []struct {
+		name    string
+		change  func(t *models.TierList, o *render.Options)
+		changed bool
+	}{
+		{"nothing", func(t *models.TierList, o *render.Options) {}, false},
+		{"list name", func(t *models.TierList, o *render.Options) { t.Name = "Renamed" }, false}, // Only the title option is drawn
+		{"row label", func(t *models.TierList, o *render.Options) { t.Rows[0].Label = "S+" }, true},
+		{"row color", func(t *models.TierList, o *render.Options) { t.Rows[1].Color = "#000000" }, true},
+		{"row order", func(t *models.TierList, o *render.Options) { t.Rows[0], t.Rows[1] = t.Rows[1], t.Rows[0] }, true},
+		{"removed row", func(t *models.TierList, o *render.Options) { t.Rows = t.Rows[:1] }, true},
+		{"added row", func(t *models.TierList, o *render.Options) {
+			t.Rows = append(t.Rows, models.TierRow{Label: "B", Color: "#FFFF7F"})
+		}, true},
+		{"item order", func(t *models.TierList, o *render.Options) {
+			items := t.Rows[0].Items
+			items[0], items[1] = items[1], items[0]
+		}, true},
+		{"item moved to another row", func(t *models.TierList, o *render.Options) {
+			t.Rows[1].Items = append(t.Rows[1].Items, t.Rows[0].Items[1])
+			t.Rows[0].Items = t.Rows[0].Items[:1]
+		}, true},
+		{"game title", func(t *models.TierList, o *render.Options) { t.Rows[0].Items[0].Game.Title = "Hades II" }, true},
+		{"game cover", func(t *models.TierList, o *render.Options) { t.Rows[0].Items[0].Game.CoverURL = "/images/new.jpg" }, true},
+		{"item whose game is gone", func(t *models.TierList, o *render.Options) {
+			t.Rows[1].Items = append(t.Rows[1].Items, models.TierItem{ID: uuid.New(), GameID: uuid.New()})
+		}, false},
+		{"width", func(t *models.TierList, o *render.Options) { o.Width = 800 }, true},
+		{"item size", func(t *models.TierList, o *render.Options) { o.ItemSize = 64 }, true},
+		{"title", func(t *models.TierList, o *render.Options) { o.Title = "Favourites" }, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed, o := cloneTierList(list), opts
+			tt.change(&changed, &o)
+			got := renderKey(changed, o)
+			if (got != key) != tt.changed {
+				t.Errorf("key %s, base key %s, want changed = %v", got, key, tt.changed)
+			}
+		})
+	}
+}
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"math"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func (h *Handler) mapGameToSeriesEntry(game models.Game) dto.SeriesEntry {
+	return dto.SeriesEntry{
+		GameID:      game.ID,
+		Title:       game.Title,
+		CoverURL:    h.signImageURL(game.CoverURL),
+		Status:      string(game.Status),
+		ReleaseYear: game.ReleaseYear,
+		ReleaseDate: game.ReleaseDate,
+	}
+}
+
+// mapSeriesToResponse builds the response with entries in release order.
+// Undated entries go last, keeping their insertion order.
+func (h *Handler) mapSeriesToResponse(series models.Series, withEntries bool) dto.SeriesResponse {
+	games := append([]models.Game(nil), series.Games...)
+	sort.SliceStable(games, func(i, j int) bool {
+		return releaseSortKey(games[i]) < releaseSortKey(games[j])
+	})
+
+	completed := 0
+	entries := make([]dto.SeriesEntry, 0, len(games))
+	for _, game := range games {
+		if game.Status == models.StatusCompleted {
+			completed++
+		}
+		entries = append(entries, h.mapGameToSeriesEntry(game))
+	}
+
+	res := dto.SeriesResponse{
+		ID:             series.ID,
+		Name:           series.Name,
+		Description:    series.Description,
+		GameCount:      len(games),
+		CompletedCount: completed,
+		CreatedAt:      series.CreatedAt,
+	}
+	if len(games) > 0 {
+		res.CompletionPercent = math.Round(float64(completed)/float64(len(games))*1000) / 10
+	}
+	if withEntries {
+		res.Entries = entries
+	}
+	return res
+}
+
+func releaseSortKey(game models.Game) int64 {
+	if game.ReleaseDate != nil {
+		return game.ReleaseDate.Unix()
+	}
+	if game.ReleaseYear > 0 {
+		return int64(game.ReleaseYear-1970) * 365 * 24 * 3600
+	}
+	return math.MaxInt64
+}
+
+func userOwnsSeries(userID uuid.UUID, seriesID uuid.UUID) bool {
+	var count int64
+	database.DB.Model(&models.Series{}).Where("id = ? AND user_id = ?", seriesID, userID).Count(&count)
+	return count > 0
+}
+
+// GetSeries lists the user's series with completion percentages
+func (h *Handler) GetSeries(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	var series []models.Series
+	if err := database.DB.Preload("Games").Where("user_id = ?", userID).Order("name asc").Find(&series).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch series", "error": err.Error()})
+	}
+
+	res := make([]dto.SeriesResponse, 0, len(series))
+	for _, s := range series {
+		res = append(res, h.mapSeriesToResponse(s, false))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// GetSeriesByID returns a series with its entries in release order
+func (h *Handler) GetSeriesByID(c *fiber.Ctx) error {
+	series, err := findUserSeries(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": h.mapSeriesToResponse(series, true)})
+}
+
+func (h *Handler) CreateSeries(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	var req dto.SeriesRequest
+	if err := c.BodyParser(&req); err != nil || req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+
+	series := models.Series{UserID: userID, Name: req.Name, Description: req.Description}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&series).Error; err != nil {
+			return err
+		}
+		return assignGamesToSeries(tx, userID, series.ID, req.GameIDs)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create series", "error": err.Error()})
+	}
+
+	database.DB.Preload("Games").First(&series, "id = ?", series.ID)
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": h.mapSeriesToResponse(series, true)})
+}
+
+func (h *Handler) UpdateSeries(c *fiber.Ctx) error {
+	series, err := findUserSeries(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+	}
+
+	var req dto.SeriesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if req.Name != "" {
+		series.Name = req.Name
+	}
+	series.Description = req.Description
+
+	if err := database.DB.Omit("Games").Save(&series).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update series", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": h.mapSeriesToResponse(series, true)})
+}
+
+// DeleteSeries removes the series; its games stay in the library without a series
+func (h *Handler) DeleteSeries(c *fiber.Ctx) error {
+	series, err := findUserSeries(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Game{}).Where("series_id = ?", series.ID).Update("series_id", nil).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&series).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not delete series", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "Series deleted"})
+}
+
+// AddGamesToSeries moves the given games into the series
+func (h *Handler) AddGamesToSeries(c *fiber.Ctx) error {
+	series, err := findUserSeries(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+	}
+
+	var req dto.SeriesGamesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if err := assignGamesToSeries(database.DB, series.UserID, series.ID, req.GameIDs); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Could not add games", "error": err.Error()})
+	}
+
+	database.DB.Preload("Games").First(&series, "id = ?", series.ID)
+	return c.JSON(fiber.Map{"status": "success", "data": h.mapSeriesToResponse(series, true)})
+}
+
+func (h *Handler) RemoveGameFromSeries(c *fiber.Ctx) error {
+	series, err := findUserSeries(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Series not found"})
+	}
+
+	result := database.DB.Model(&models.Game{}).
+		Where("id = ? AND series_id = ?", c.Params("gameId"), series.ID).
+		Update("series_id", nil)
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not in series"})
+	}
+
+	database.DB.Preload("Games").First(&series, "id = ?", series.ID)
+	return c.JSON(fiber.Map{"status": "success", "data": h.mapSeriesToResponse(series, true)})
+}
+
+// GetSeriesStats returns completion per series plus overall totals
+func (h *Handler) GetSeriesStats(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	var series []models.Series
+	database.DB.Preload("Games").Where("user_id = ?", userID).Find(&series)
+
+	stats := make([]dto.SeriesResponse, 0, len(series))
+	total, completed, finishedSeries := 0, 0, 0
+	for _, s := range series {
+		res := h.mapSeriesToResponse(s, false)
+		total += res.GameCount
+		completed += res.CompletedCount
+		if res.GameCount > 0 && res.CompletedCount == res.GameCount {
+			finishedSeries++
+		}
+		stats = append(stats, res)
+	}
+	sort.SliceStable(stats, func(i, j int) bool { return stats[i].CompletionPercent > stats[j].CompletionPercent })
+
+	overall := 0.0
+	if total > 0 {
+		overall = math.Round(float64(completed)/float64(total)*1000) / 10
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": fiber.Map{
+		"series_count":       len(series),
+		"completed_series":   finishedSeries,
+		"completion_percent": overall,
+		"series":             stats,
+	}})
+}
+
+// GetGameRelations lists relations in both directions for a game
+func (h *Handler) GetGameRelations(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var relations []models.GameRelation
+	database.DB.Preload("Game").Preload("RelatedGame").
+		Where("game_id = ? OR related_game_id = ?", game.ID, game.ID).
+		Order("created_at asc").
+		Find(&relations)
+
+	res := make([]dto.GameRelationResponse, 0, len(relations))
+	for _, r := range relations {
+		res = append(res, mapRelationToResponse(r))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+func (h *Handler) CreateGameRelation(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	var req dto.GameRelationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	relType := models.RelationType(req.Type)
+	if !relType.Valid() {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid relation type. Allowed: dlc_of, expansion_of, sequel_of, remaster_of"})
+	}
+	if req.RelatedGameID == game.ID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "A game cannot relate to itself"})
+	}
+
+	var related models.Game
+	if err := database.DB.Where("user_id = ?", game.UserID).First(&related, "id = ?", req.RelatedGameID).Error; err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Related game not found"})
+	}
+
+	relation := models.GameRelation{GameID: game.ID, RelatedGameID: related.ID, Type: relType}
+	if err := database.DB.Omit("Game", "RelatedGame").Create(&relation).Error; err != nil {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"status": "error", "message": "Could not create relation", "error": err.Error()})
+	}
+	relation.Game = game
+	relation.RelatedGame = related
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": mapRelationToResponse(relation)})
+}
+
+func (h *Handler) DeleteGameRelation(c *fiber.Ctx) error {
+	game, err := findUserGame(c)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Game not found"})
+	}
+
+	result := database.DB.
+		Where("id = ? AND (game_id = ? OR related_game_id = ?)", c.Params("relationId"), game.ID, game.ID).
+		Delete(&models.GameRelation{})
+	if result.RowsAffected == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Relation not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "message": "Relation deleted"})
+}
+
+func mapRelationToResponse(r models.GameRelation) dto.GameRelationResponse {
+	return dto.GameRelationResponse{
+		ID:               r.ID,
+		Type:             string(r.Type),
+		GameID:           r.GameID,
+		GameTitle:        r.Game.Title,
+		RelatedGameID:    r.RelatedGameID,
+		RelatedGameTitle: r.RelatedGame.Title,
+	}
+}
+
+func assignGamesToSeries(tx *gorm.DB, userID uuid.UUID, seriesID uuid.UUID, gameIDs []uuid.UUID) error {
+	if len(gameIDs) == 0 {
+		return nil
+	}
+	// A game listed twice is updated once
+	seen := make(map[uuid.UUID]bool, len(gameIDs))
+	unique := gameIDs[:0:0]
+	for _, id := range gameIDs {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	gameIDs = unique
+	result := tx.Model(&models.Game{}).
+		Where("user_id = ? AND id IN ?", userID, gameIDs).
+		Update("series_id", seriesID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if int(result.RowsAffected) != len(gameIDs) {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func findUserSeries(c *fiber.Ctx) (models.Series, error) {
+	var series models.Series
+	err := database.DB.Preload("Games").
+		Where("user_id = ?", c.Locals("user_id")).
+		First(&series, "id = ?", c.Params("id")).Error
+	return series, err
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// newShareSlug returns an unguessable 128-bit URL-safe identifier
+func newShareSlug() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func mapShareSettings(t models.TierList) dto.ShareSettingsResponse {
+	res := dto.ShareSettingsResponse{Visibility: string(t.Visibility), PublishedAt: t.PublishedAt}
+	if res.Visibility == "" {
+		res.Visibility = string(models.VisibilityPrivate)
+	}
+	if t.ShareSlug != nil && t.Visibility != models.VisibilityPrivate {
+		res.Slug = *t.ShareSlug
+		res.URL = "/api/share/tier-lists/" + *t.ShareSlug
+	}
+	return res
+}
+
+func (h *Handler) mapSharedTierList(t models.TierList, author string, withRows bool) dto.SharedTierListResponse {
+	res := dto.SharedTierListResponse{Name: t.Name, Author: author, UpdatedAt: t.UpdatedAt}
+	if t.ShareSlug != nil {
+		res.Slug = *t.ShareSlug
+	}
+	if !withRows {
+		return res
+	}
+	res.Rows = make([]dto.SharedTierRow, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		items := make([]dto.SharedTierItem, 0, len(row.Items))
+		for _, item := range row.Items {
+			items = append(items, dto.SharedTierItem{
+				ID:        item.ID,
+				SortOrder: item.SortOrder,
+				Game:      dto.SharedGame{Title: item.Game.Title, CoverURL: h.signImageURL(item.Game.CoverURL), Score: item.Game.Score},
+			})
+		}
+		res.Rows = append(res.Rows, dto.SharedTierRow{
+			ID:        row.ID,
+			Label:     row.Label,
+			Color:     row.Color,
+			SortOrder: row.SortOrder,
+			Items:     items,
+		})
+	}
+	return res
+}
+
+// GetShareSettings returns the visibility and share link of a tier list
+func (h *Handler) GetShareSettings(c *fiber.Ctx) error {
+	var tierList models.TierList
+	if err := database.DB.Where("id = ? AND user_id = ?", c.Params("id"), c.Locals("user_id")).First(&tierList).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": mapShareSettings(tierList)})
+}
+
+// UpdateShareSettings changes the visibility, creating a share link on first publish.
+// Going back to private keeps the slug so re-publishing restores the same link;
+// use revoke or rotate to invalidate it.
+func (h *Handler) UpdateShareSettings(c *fiber.Ctx) error {
+	var req dto.ShareTierListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	visibility := models.TierListVisibility(req.Visibility)
+	if visibility != models.VisibilityPrivate && visibility != models.VisibilityUnlisted && visibility != models.VisibilityPublic {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid visibility. Allowed: private, unlisted, public"})
+	}
+
+	return updateSharing(c, func(t *models.TierList) error {
+		t.Visibility = visibility
+		if visibility == models.VisibilityPrivate {
+			return nil
+		}
+		if t.ShareSlug == nil {
+			slug, err := newShareSlug()
+			if err != nil {
+				return err
+			}
+			t.ShareSlug = &slug
+		}
+		if t.PublishedAt == nil {
+			now := time.Now()
+			t.PublishedAt = &now
+		}
+		return nil
+	})
+}
+
+// RotateShareLink replaces the share slug so the previous link stops working
+func (h *Handler) RotateShareLink(c *fiber.Ctx) error {
+	return updateSharing(c, func(t *models.TierList) error {
+		slug, err := newShareSlug()
+		if err != nil {
+			return err
+		}
+		t.ShareSlug = &slug
+		return nil
+	})
+}
+
+// RevokeShareLink makes the tier list private and discards its slug
+func (h *Handler) RevokeShareLink(c *fiber.Ctx) error {
+	return updateSharing(c, func(t *models.TierList) error {
+		t.Visibility = models.VisibilityPrivate
+		t.ShareSlug = nil
+		t.PublishedAt = nil
+		return nil
+	})
+}
+
+func updateSharing(c *fiber.Ctx, change func(t *models.TierList) error) error {
+	var tierList models.TierList
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", c.Params("id"), c.Locals("user_id")).First(&tierList).Error; err != nil {
+			return err
+		}
+		if err := change(&tierList); err != nil {
+			return err
+		}
+		// Select the columns explicitly so a nil slug is written as NULL
+		return tx.Model(&tierList).Select("visibility", "share_slug", "published_at").Updates(&tierList).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update sharing", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": mapShareSettings(tierList)})
+}
+
+// GetSharedTierList serves a published tier list without authentication
+func (h *Handler) GetSharedTierList(c *fiber.Ctx) error {
+	var tierList models.TierList
+	err := database.DB.
+		Preload("Rows", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc") }).
+		Preload("Rows.Items", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc") }).
+		Preload("Rows.Items.Game").
+		Where("share_slug = ? AND visibility IN ?", c.Params("slug"),
+			[]models.TierListVisibility{models.VisibilityUnlisted, models.VisibilityPublic}).
+		First(&tierList).Error
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+
+	var owner models.User
+	database.DB.Select("username").First(&owner, "id = ?", tierList.UserID)
+
+	// Items pointing at games the owner no longer has are left out
+	for i := range tierList.Rows {
+		items := tierList.Rows[i].Items[:0]
+		for _, item := range tierList.Rows[i].Items {
+			if item.Game.UserID == tierList.UserID {
+				items = append(items, item)
+			}
+		}
+		tierList.Rows[i].Items = items
+	}
+
+	c.Set(fiber.HeaderCacheControl, "public, max-age=60")
+	return c.JSON(fiber.Map{"status": "success", "data": h.mapSharedTierList(tierList, owner.Username, true)})
+}
+
+// GetPublicTierLists lists tier lists published as public, newest first
+func (h *Handler) GetPublicTierLists(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	var tierLists []models.TierList
+	if err := database.DB.Where("visibility = ?", models.VisibilityPublic).
+		Order("published_at desc").Limit(limit).Offset(offset).
+		Find(&tierLists).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier lists"})
+	}
+
+	authors := map[uuid.UUID]string{}
+	res := make([]dto.SharedTierListResponse, 0, len(tierLists))
+	for _, t := range tierLists {
+		name, ok := authors[t.UserID]
+		if !ok {
+			var owner models.User
+			database.DB.Select("username").First(&owner, "id = ?", t.UserID)
+			name = owner.Username
+			authors[t.UserID] = name
+		}
+		res = append(res, h.mapSharedTierList(t, name, false))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"backlog-backend/config"
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrQuotaExceeded is returned by saveImage when an upload would take the
+// user past their storage quota
+var ErrQuotaExceeded = errors.New("storage quota exceeded")
+
+// UnlimitedQuota as a quota disables the limit
+const UnlimitedQuota = int64(config.Unlimited)
+
+// roleQuota is the configured quota of a role. Roles without one get the
+// quota of "user".
+func (h *Handler) roleQuota(role string) int64 {
+	if q, ok := h.cfg.Storage.Quotas[strings.ToLower(role)]; ok {
+		return int64(q)
+	}
+	return int64(h.cfg.Storage.Quotas["user"])
+}
+
+// storageQuota is the quota of a user: their account override if an admin
+// set one, otherwise the quota of their role
+func (h *Handler) storageQuota(user *models.User) (quota int64, source string) {
+	if user.StorageQuotaBytes != nil {
+		return *user.StorageQuotaBytes, "account"
+	}
+	return h.roleQuota(user.Role), "role"
+}
+
+// storageUsage sums the bytes of every image the user uploaded, derived
+// files included. A file shared by several uploaders counts for each of them.
+func storageUsage(db *gorm.DB, userID uuid.UUID) (used int64, count int64, err error) {
+	var row struct {
+		Used  int64
+		Count int64
+	}
+	err = db.Model(&models.Image{}).Select("COALESCE(SUM(size), 0) AS used, COUNT(*) AS count").Where("user_id = ?", userID).Scan(&row).Error
+	return row.Used, row.Count, err
+}
+
+// checkStorageQuota fails with ErrQuotaExceeded if storing size more bytes
+// under name would take the user past their quota. Uploading a file the user
+// already has costs nothing. Within a transaction the user row stays locked
+// until it ends, so concurrent uploads are checked one after the other.
+func (h *Handler) checkStorageQuota(db *gorm.DB, userID uuid.UUID, name string, size int64) error {
+	var user models.User
+	if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, "id = ?", userID).Error; err != nil {
+		return err
+	}
+	quota, _ := h.storageQuota(&user)
+	if quota == UnlimitedQuota {
+		return nil
+	}
+	var existing int64
+	if err := db.Model(&models.Image{}).Where("user_id = ? AND name = ?", userID, name).Count(&existing).Error; err != nil {
+		return err
+	}
+	if existing > 0 {
+		return nil
+	}
+	used, _, err := storageUsage(db, userID)
+	if err != nil {
+		return err
+	}
+	if used+size > quota {
+		return fmt.Errorf("%w: %d of %d bytes used, the image needs %d", ErrQuotaExceeded, used, quota, size)
+	}
+	return nil
+}
+
+func (h *Handler) storageUsageResponse(user *models.User) (*dto.StorageUsageResponse, error) {
+	used, count, err := storageUsage(database.DB, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	quota, source := h.storageQuota(user)
+	res := &dto.StorageUsageResponse{
+		UserID:      user.ID,
+		Role:        user.Role,
+		UsedBytes:   used,
+		ImageCount:  count,
+		QuotaSource: source,
+	}
+	if quota != UnlimitedQuota {
+		remaining := max(quota-used, 0)
+		res.QuotaBytes = &quota
+		res.RemainingBytes = &remaining
+	}
+	return res, nil
+}
+
+// GetMyStorage reports the current user's storage usage and quota
+func (h *Handler) GetMyStorage(c *fiber.Ctx) error {
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", c.Locals("user_id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+	}
+	res, err := h.storageUsageResponse(&user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not compute storage usage", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// GetUserStorage reports the storage usage and quota of any user (admin only)
+func (h *Handler) GetUserStorage(c *fiber.Ctx) error {
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+	}
+	res, err := h.storageUsageResponse(&user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not compute storage usage", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// UpdateStorageQuota overrides the quota of one account (admin only). A null
+// quota_bytes returns the account to its role quota, -1 makes it unlimited.
+// Lowering a quota below the current usage keeps the existing images but
+// blocks further uploads.
+func (h *Handler) UpdateStorageQuota(c *fiber.Ctx) error {
+	if _, err := uuid.Parse(c.Params("id")); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ID format"})
+	}
+	var user models.User
+	if err := database.DB.First(&user, "id = ?", c.Params("id")).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "User not found"})
+	}
+
+	var req dto.UpdateStorageQuotaRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if req.QuotaBytes != nil && *req.QuotaBytes < 0 && *req.QuotaBytes != UnlimitedQuota {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "quota_bytes must be at least 0, -1 for unlimited or null for the role quota"})
+	}
+
+	if err := database.DB.Model(&user).Update("storage_quota_bytes", req.QuotaBytes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update quota", "error": err.Error()})
+	}
+	user.StorageQuotaBytes = req.QuotaBytes
+
+	res, err := h.storageUsageResponse(&user)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not compute storage usage", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
@@ -9,7 +9,7 @@ import (
 	"github.com/google/uuid"
 )
 
-func GetTags(c *fiber.Ctx) error {
+func (h *Handler) GetTags(c *fiber.Ctx) error {
 	var tags []models.GameTag
 	database.DB.Find(&tags)
 
@@ -23,7 +23,7 @@ func GetTags(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func CreateTag(c *fiber.Ctx) error {
+func (h *Handler) CreateTag(c *fiber.Ctx) error {
 	var req dto.CreateTagRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
@@ -48,7 +48,7 @@ func CreateTag(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func UpdateTag(c *fiber.Ctx) error {
+func (h *Handler) UpdateTag(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var tag models.GameTag
 	if err := database.DB.First(&tag, "id = ?", id).Error; err != nil {
@@ -70,7 +70,7 @@ func UpdateTag(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func DeleteTag(c *fiber.Ctx) error {
+func (h *Handler) DeleteTag(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var tag models.GameTag
 	if err := database.DB.First(&tag, "id = ?", id).Error; err != nil {
@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultHoldingLabel = "Unranked"
+
+func mapTemplateToResponse(t models.TierListTemplate) dto.TemplateResponse {
+	rows := append([]models.TierTemplateRow(nil), t.Rows...)
+	sort.SliceStable(rows, func(i, j int) bool { return rows[i].SortOrder < rows[j].SortOrder })
+
+	res := dto.TemplateResponse{
+		ID:          t.ID,
+		Name:        t.Name,
+		Description: t.Description,
+		BuiltIn:     t.BuiltIn,
+		Rows:        make([]dto.TemplateRowResponse, 0, len(rows)),
+	}
+	if !t.BuiltIn {
+		res.CreatedAt = &t.CreatedAt
+	}
+	for _, row := range rows {
+		res.Rows = append(res.Rows, dto.TemplateRowResponse{
+			ID:        row.ID,
+			Label:     row.Label,
+			Color:     row.Color,
+			SortOrder: row.SortOrder,
+			MinScore:  row.MinScore,
+		})
+	}
+	return res
+}
+
+// findTemplate returns a built-in template or one of the user's own
+func findTemplate(userID interface{}, id string) (models.TierListTemplate, error) {
+	parsed, err := uuid.Parse(id)
+	if err != nil {
+		return models.TierListTemplate{}, gorm.ErrRecordNotFound
+	}
+	if t, ok := models.FindBuiltinTemplate(parsed); ok {
+		return t, nil
+	}
+	var t models.TierListTemplate
+	err = database.DB.Preload("Rows").Where("user_id = ?", userID).First(&t, "id = ?", parsed).Error
+	return t, err
+}
+
+func buildTemplateRows(req dto.TemplateRequest) ([]models.TierTemplateRow, error) {
+	if strings.TrimSpace(req.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if len(req.Rows) == 0 {
+		return nil, errors.New("at least one row is required")
+	}
+	rows := make([]models.TierTemplateRow, 0, len(req.Rows))
+	for i, r := range req.Rows {
+		color := r.Color
+		if color == "" {
+			color = "#FFFFFF"
+		}
+		if strings.TrimSpace(r.Label) == "" || !hexColorRegex.MatchString(color) {
+			return nil, fmt.Errorf("row %d needs a label and a #RRGGBB color", i)
+		}
+		rows = append(rows, models.TierTemplateRow{Label: r.Label, Color: color, SortOrder: i, MinScore: r.MinScore})
+	}
+	return rows, nil
+}
+
+// GetTemplates lists the built-in templates followed by the user's own
+func (h *Handler) GetTemplates(c *fiber.Ctx) error {
+	userID := c.Locals("user_id")
+	var own []models.TierListTemplate
+	if err := database.DB.Preload("Rows").Where("user_id = ?", userID).Order("created_at asc").Find(&own).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch templates", "error": err.Error()})
+	}
+
+	res := make([]dto.TemplateResponse, 0, len(models.BuiltinTemplates)+len(own))
+	for _, t := range models.BuiltinTemplates {
+		res = append(res, mapTemplateToResponse(t))
+	}
+	for _, t := range own {
+		res = append(res, mapTemplateToResponse(t))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+func (h *Handler) GetTemplate(c *fiber.Ctx) error {
+	t, err := findTemplate(c.Locals("user_id"), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Template not found"})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": mapTemplateToResponse(t)})
+}
+
+func (h *Handler) CreateTemplate(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("user_id").(string))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	var req dto.TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	rows, err := buildTemplateRows(req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid template", "error": err.Error()})
+	}
+
+	t := models.TierListTemplate{UserID: userID, Name: req.Name, Description: req.Description, Rows: rows}
+	if err := database.DB.Create(&t).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create template", "error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": mapTemplateToResponse(t)})
+}
+
+func (h *Handler) UpdateTemplate(c *fiber.Ctx) error {
+	t, err := findTemplate(c.Locals("user_id"), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Template not found"})
+	}
+	if t.BuiltIn {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Built-in templates cannot be changed"})
+	}
+
+	var req dto.TemplateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	rows, err := buildTemplateRows(req)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid template", "error": err.Error()})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("template_id = ?", t.ID).Delete(&models.TierTemplateRow{}).Error; err != nil {
+			return err
+		}
+		t.Name = req.Name
+		t.Description = req.Description
+		t.Rows = nil
+		if err := tx.Save(&t).Error; err != nil {
+			return err
+		}
+		for i := range rows {
+			rows[i].TemplateID = t.ID
+		}
+		t.Rows = rows
+		return tx.Create(&t.Rows).Error
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not update template", "error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": mapTemplateToResponse(t)})
+}
+
+func (h *Handler) DeleteTemplate(c *fiber.Ctx) error {
+	t, err := findTemplate(c.Locals("user_id"), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Template not found"})
+	}
+	if t.BuiltIn {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Built-in templates cannot be deleted"})
+	}
+	database.DB.Delete(&t)
+	return c.JSON(fiber.Map{"status": "success", "message": "Template deleted"})
+}
+
+// applyGameFilter narrows a games query to what the filter selects
+func applyGameFilter(query *gorm.DB, f dto.GameFilter) *gorm.DB {
+	if len(f.GameIDs) > 0 {
+		query = query.Where("games.id IN ?", f.GameIDs)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("games.status IN ?", f.Statuses)
+	}
+	if len(f.TagIDs) > 0 {
+		query = query.Where("games.id IN (?)",
+			database.DB.Table("game_related_tags").Select("game_id").Where("game_tag_id IN ?", f.TagIDs))
+	}
+	if f.Platform != "" {
+		// Games owned on the platform (and storefront, for "steam" and the
+		// like), or whose legacy platform text is the same
+		if platform, storefront, ok := models.ParsePlatform(f.Platform); ok {
+			owned := database.DB.Table("ownerships").Select("game_id").Where("platform = ?", platform)
+			if storefront != "" {
+				owned = owned.Where("LOWER(storefront) = LOWER(?)", storefront)
+			}
+			query = query.Where("games.id IN (?) OR LOWER(games.platform) = LOWER(?)", owned, f.Platform)
+		} else {
+			query = query.Where("LOWER(games.platform) = LOWER(?)", f.Platform)
+		}
+	}
+	if f.Genre != "" {
+		query = query.Where("LOWER(games.genre) = LOWER(?)", f.Genre)
+	}
+	if f.ReleaseYearFrom > 0 {
+		query = query.Where("games.release_year >= ?", f.ReleaseYearFrom)
+	}
+	if f.ReleaseYearTo > 0 {
+		query = query.Where("games.release_year <= ?", f.ReleaseYearTo)
+	}
+	return query
+}
+
+// GenerateTierList creates a tier list from a template and places the
+// filtered games by score or hours played. Games without a value, or below
+// the lowest threshold, go to a holding row at the bottom.
+func (h *Handler) GenerateTierList(c *fiber.Ctx) error {
+	userIdStr := c.Locals("user_id").(string)
+	userId, err := uuid.Parse(userIdStr)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
+	}
+
+	var req dto.GenerateTierListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if req.By == "" {
+		req.By = "score"
+	}
+	if req.By != "score" && req.By != "hours" && req.By != "none" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid 'by'. Allowed: score, hours, none"})
+	}
+
+	template := models.BuiltinTemplates[0]
+	if req.TemplateID != nil {
+		if template, err = findTemplate(userIdStr, req.TemplateID.String()); err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Template not found"})
+		}
+	}
+	template.Rows = append([]models.TierTemplateRow(nil), template.Rows...) // built-ins are shared
+	sort.SliceStable(template.Rows, func(i, j int) bool { return template.Rows[i].SortOrder < template.Rows[j].SortOrder })
+	if len(req.Thresholds) > 0 && len(req.Thresholds) != len(template.Rows) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": fmt.Sprintf("Expected %d thresholds, one per row", len(template.Rows))})
+	}
+
+	var games []models.Game
+	query := applyGameFilter(database.DB.Where("games.user_id = ?", userId), req.Filter)
+	if err := query.Order("games.title asc").Find(&games).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch games"})
+	}
+
+	name := req.Name
+	if name == "" {
+		name = template.Name
+	}
+	holding := req.HoldingLabel
+	if holding == "" {
+		holding = defaultHoldingLabel
+	}
+
+	placement := placeGames(games, template.Rows, req.By, req.Thresholds)
+	tierList := models.TierList{UserID: userId, Name: name}
+	for i, row := range template.Rows {
+		tierList.Rows = append(tierList.Rows, models.TierRow{Label: row.Label, Color: row.Color, SortOrder: i, Items: placement[i]})
+	}
+	tierList.Rows = append(tierList.Rows, models.TierRow{
+		Label: holding, Color: "#CCCCCC", SortOrder: len(template.Rows), Items: placement[len(template.Rows)],
+	})
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&tierList).Error; err != nil {
+			return err
+		}
+		return recordTierListVersion(tx, &tierList, models.VersionSourceGenerate)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create tier list"})
+	}
+
+	return h.respondWithTierList(c, tierList.ID.String(), userIdStr, fiber.StatusCreated)
+}
+
+// placeGames returns the items for each template row plus a final holding
+// bucket. Without explicit thresholds, score uses the template's MinScore (or
+// an even 0-10 split) and hours splits the ranked games into equal groups.
+func placeGames(games []models.Game, rows []models.TierTemplateRow, by string, thresholds []float64) [][]models.TierItem {
+	buckets := make([][]models.TierItem, len(rows)+1)
+	holding := len(rows)
+
+	type ranked struct {
+		game  models.Game
+		value float64
+	}
+	var valued []ranked
+	for _, g := range games {
+		switch {
+		case by == "score" && g.Score != nil:
+			valued = append(valued, ranked{g, *g.Score})
+		case by == "hours" && g.HoursPlayed > 0:
+			valued = append(valued, ranked{g, float64(g.HoursPlayed)})
+		default:
+			buckets[holding] = append(buckets[holding], models.TierItem{GameID: g.ID})
+		}
+	}
+	sort.SliceStable(valued, func(i, j int) bool { return valued[i].value > valued[j].value })
+
+	if len(thresholds) == 0 && by == "score" {
+		thresholds = make([]float64, len(rows))
+		for i, row := range rows {
+			if row.MinScore != nil {
+				thresholds[i] = *row.MinScore
+			} else {
+				thresholds[i] = 10 * float64(len(rows)-1-i) / float64(len(rows))
+			}
+		}
+	}
+
+	for rank, v := range valued {
+		target := holding
+		if len(thresholds) == 0 {
+			// Equal-sized groups by rank
+			target = rank * len(rows) / len(valued)
+		} else {
+			for i, min := range thresholds {
+				if v.value >= min {
+					target = i
+					break
+				}
+			}
+		}
+		buckets[target] = append(buckets[target], models.TierItem{GameID: v.game.ID})
+	}
+
+	for i := range buckets {
+		for j := range buckets[i] {
+			buckets[i][j].SortOrder = j
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"backlog-backend/models"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestPlaceGames(t *testing.T) {
+	score := func(v float64) *float64 { return &v }
+	rows := func(mins ...*float64) []models.TierTemplateRow {
+		out := make([]models.TierTemplateRow, len(mins))
+		for i, min := range mins {
+			out[i] = models.TierTemplateRow{MinScore: min}
+		}
+		return out
+	}
+	type game struct {
+		name  string
+		score *float64
+		hours int
+	}
+
+	tests := []struct {
+		name       string
+		games      []game
+		rows       []models.TierTemplateRow
+		by         string
+		thresholds []float64
+		want       [][]string // One bucket per row, then the holding bucket
+	}{
+		{
+			name: "score by template minimums",
+			games: []game{
+				{name: "seven", score: score(7)}, {name: "unscored"}, {name: "nine and a half", score: score(9.5)},
+				{name: "three", score: score(3)}, {name: "eight", score: score(8)},
+			},
+			rows: rows(score(9), score(7), nil),
+			by:   "score",
+			want: [][]string{{"nine and a half"}, {"eight", "seven"}, {"three"}, {"unscored"}},
+		},
+		{
+			name: "score split evenly without minimums",
+			games: []game{
+				{name: "ten", score: score(10)}, {name: "one", score: score(1)},
+				{name: "six", score: score(6)}, {name: "five", score: score(5)},
+			},
+			rows: rows(nil, nil, nil, nil),
+			by:   "score",
+			want: [][]string{{"ten"}, {"six", "five"}, nil, {"one"}, nil},
+		},
+		{
+			name:       "below every explicit threshold is held back",
+			games:      []game{{name: "nine", score: score(9)}, {name: "six", score: score(6)}, {name: "four", score: score(4)}},
+			rows:       rows(nil, nil, nil),
+			by:         "score",
+			thresholds: []float64{8, 5},
+			want:       [][]string{{"nine"}, {"six"}, nil, {"four"}},
+		},
+		{
+			name: "hours in equal groups",
+			games: []game{
+				{name: "10h", hours: 10}, {name: "50h", hours: 50}, {name: "unplayed"},
+				{name: "30h", hours: 30}, {name: "20h", hours: 20}, {name: "40h", hours: 40},
+			},
+			rows: rows(nil, nil, nil),
+			by:   "hours",
+			want: [][]string{{"50h", "40h"}, {"30h", "20h"}, {"10h"}, {"unplayed"}},
+		},
+		{
+			name:  "unknown criterion holds everything back",
+			games: []game{{name: "a", score: score(9), hours: 3}, {name: "b"}},
+			rows:  rows(nil, nil),
+			by:    "release",
+			want:  [][]string{nil, nil, {"a", "b"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := map[uuid.UUID]string{}
+			games := make([]models.Game, len(tt.games))
+			for i, g := range tt.games {
+				games[i] = models.Game{ID: uuid.New(), Score: g.score, HoursPlayed: g.hours}
+				names[games[i].ID] = g.name
+			}
+
+			buckets := placeGames(games, tt.rows, tt.by, tt.thresholds)
+			got := make([][]string, len(buckets))
+			for i, bucket := range buckets {
+				for j, item := range bucket {
+					if item.SortOrder != j {
+						t.Errorf("bucket %d item %d has sort order %d", i, j, item.SortOrder)
+					}
+					got[i] = append(got[i], names[item.GameID])
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("placeGames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
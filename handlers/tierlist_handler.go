@@ -2,147 +2,378 @@ package handlers
 
 import (
 	"backlog-backend/database"
+	"backlog-backend/dto"
 	"backlog-backend/models"
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
+// coverPreviewSize is how many covers a tier list summary shows
+const coverPreviewSize = 4
+
+func (h *Handler) mapTierListToResponse(t models.TierList) dto.TierListResponse {
+	res := dto.TierListResponse{
+		ID:           t.ID,
+		Name:         t.Name,
+		Visibility:   string(t.Visibility),
+		ForkedFromID: t.ForkedFromID,
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+		Rows:         make([]dto.TierRowResponse, 0, len(t.Rows)),
+	}
+	if res.Visibility == "" {
+		res.Visibility = string(models.VisibilityPrivate)
+	}
+	for _, row := range t.Rows {
+		items := make([]dto.TierItemResponse, 0, len(row.Items))
+		for _, item := range row.Items {
+			items = append(items, dto.TierItemResponse{
+				ID:        item.ID,
+				GameID:    item.GameID,
+				SortOrder: item.SortOrder,
+				Game: dto.TierGame{
+					ID:       item.Game.ID,
+					Title:    item.Game.Title,
+					CoverURL: h.signImageURL(item.Game.CoverURL),
+					Status:   string(item.Game.Status),
+					Score:    item.Game.Score,
+				},
+			})
+		}
+		res.Rows = append(res.Rows, dto.TierRowResponse{
+			ID:        row.ID,
+			Label:     row.Label,
+			Color:     row.Color,
+			SortOrder: row.SortOrder,
+			Items:     items,
+		})
+	}
+	return res
+}
+
+func (h *Handler) mapTierListToSummary(t models.TierList) dto.TierListSummaryResponse {
+	res := dto.TierListSummaryResponse{
+		ID:           t.ID,
+		Name:         t.Name,
+		Visibility:   string(t.Visibility),
+		ForkedFromID: t.ForkedFromID,
+		RowCount:     len(t.Rows),
+		CoverPreview: []string{},
+		CreatedAt:    t.CreatedAt,
+		UpdatedAt:    t.UpdatedAt,
+	}
+	if res.Visibility == "" {
+		res.Visibility = string(models.VisibilityPrivate)
+	}
+	for _, row := range t.Rows {
+		res.ItemCount += len(row.Items)
+		for _, item := range row.Items {
+			if len(res.CoverPreview) < coverPreviewSize && item.Game.CoverURL != "" {
+				res.CoverPreview = append(res.CoverPreview, h.signImageURL(item.Game.CoverURL))
+			}
+		}
+	}
+	return res
+}
+
+// forkOrigin describes the list a tier list was cloned from, if the caller can
+// still read it by ID: their own or a public one. An unlisted origin is only
+// readable through its share link, which may have been rotated since.
+func forkOrigin(t models.TierList, userID interface{}) *dto.ForkOrigin {
+	if t.ForkedFromID == nil {
+		return nil
+	}
+	var origin models.TierList
+	if err := database.DB.Where("id = ? AND (user_id = ? OR visibility = ?)", *t.ForkedFromID, userID,
+		models.VisibilityPublic).First(&origin).Error; err != nil {
+		return nil
+	}
+	var author models.User
+	database.DB.Select("username").First(&author, "id = ?", origin.UserID)
+	return &dto.ForkOrigin{ID: origin.ID, Name: origin.Name, Author: author.Username}
+}
+
+// validateTierListRequest checks the fields shared by create and replace
+func validateTierListRequest(name string, rows []dto.TierRowRequest) error {
+	if strings.TrimSpace(name) == "" {
+		return errors.New("name is required")
+	}
+	for i, row := range rows {
+		if strings.TrimSpace(row.Label) == "" {
+			return fmt.Errorf("row %d: label is required", i)
+		}
+		if row.Color != "" && !hexColorRegex.MatchString(row.Color) {
+			return fmt.Errorf("row %d: invalid color %q", i, row.Color)
+		}
+	}
+	return nil
+}
+
 // CreateTierList handles creating a new tier list
-func CreateTierList(c *fiber.Ctx) error {
+func (h *Handler) CreateTierList(c *fiber.Ctx) error {
 	userIdStr := c.Locals("user_id").(string)
 	userId, err := uuid.Parse(userIdStr)
 	if err != nil {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "Invalid user ID"})
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid user ID"})
 	}
 
-	var tierList models.TierList
-	if err := c.BodyParser(&tierList); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	var req dto.CreateTierListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if err := validateTierListRequest(req.Name, req.Rows); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tier list", "error": err.Error()})
 	}
 
-	tierList.UserID = userId
+	// New lists start private; sharing is managed through the share endpoints.
+	// IDs are always generated by the server.
+	tierList := models.TierList{UserID: userId, Name: req.Name, Visibility: models.VisibilityPrivate}
+	for i, inRow := range req.Rows {
+		row := models.TierRow{Label: inRow.Label, Color: inRow.Color, SortOrder: i}
+		if row.Color == "" {
+			row.Color = defaultRowColor(userId, inRow.Items)
+		}
+		for j, inItem := range inRow.Items {
+			row.Items = append(row.Items, models.TierItem{GameID: inItem.GameID, SortOrder: j})
+		}
+		tierList.Rows = append(tierList.Rows, row)
+	}
 
-	// Ensure IDs are generated for Rows and Items if not present (GORM hooks handle this, but explicit check is good)
-	// Actually, the BeforeCreate hooks I added to models should handle it.
+	if err := checkTierListGames(database.DB, userIdStr, &tierList); err != nil {
+		return respondTierListError(c, err, "Could not create tier list")
+	}
 
-	if err := database.DB.Create(&tierList).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not create tier list"})
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("ForkedFrom").Create(&tierList).Error; err != nil {
+			return err
+		}
+		return recordTierListVersion(tx, &tierList, models.VersionSourceCreate)
+	})
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not create tier list", "error": err.Error()})
 	}
 
-	return c.Status(fiber.StatusCreated).JSON(tierList)
+	return h.respondWithTierList(c, tierList.ID.String(), userIdStr, fiber.StatusCreated)
 }
 
-// GetTierLists returns all tier lists for the user (lightweight, no rows/items)
-func GetTierLists(c *fiber.Ctx) error {
+// GetTierLists returns all tier lists for the user as summaries without rows
+func (h *Handler) GetTierLists(c *fiber.Ctx) error {
 	userIdStr := c.Locals("user_id").(string)
 
 	var tierLists []models.TierList
-	if err := database.DB.Where("user_id = ?", userIdStr).Order("created_at desc").Find(&tierLists).Error; err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch tier lists"})
+	if err := database.DB.
+		Preload("Rows", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc") }).
+		Preload("Rows.Items", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc") }).
+		Preload("Rows.Items.Game", func(db *gorm.DB) *gorm.DB {
+			return db.Select("id", "cover_url").Where("user_id = ?", userIdStr)
+		}).
+		Where("user_id = ?", userIdStr).Order("created_at desc").Find(&tierLists).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier lists", "error": err.Error()})
 	}
 
-	return c.JSON(tierLists)
+	res := make([]dto.TierListSummaryResponse, 0, len(tierLists))
+	for _, t := range tierLists {
+		res = append(res, h.mapTierListToSummary(t))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
 // GetTierList returns a single tier list with full details
-func GetTierList(c *fiber.Ctx) error {
+func (h *Handler) GetTierList(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userIdStr := c.Locals("user_id").(string)
 
-	var tierList models.TierList
+	tierList, err := loadTierList(database.DB, id, userIdStr)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier list"})
+	}
 
-	// Preload Rows, Items, and the Game details for each Item
-	if err := database.DB.
+	res := h.mapTierListToResponse(tierList)
+	res.ForkedFrom = forkOrigin(tierList, userIdStr)
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// loadTierList fetches a tier list with ordered rows, items and item games
+func loadTierList(db *gorm.DB, id string, userID interface{}) (models.TierList, error) {
+	var tierList models.TierList
+	err := db.
 		Preload("Rows", func(db *gorm.DB) *gorm.DB {
 			return db.Order("sort_order asc") // Ensure rows are ordered
 		}).
 		Preload("Rows.Items", func(db *gorm.DB) *gorm.DB {
 			return db.Order("sort_order asc") // Ensure games in rows are ordered
 		}).
-		Preload("Rows.Items.Game").
-		Where("id = ? AND user_id = ?", id, userIdStr).
-		First(&tierList).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tier list not found"})
+		Preload("Rows.Items.Game", "user_id = ?", userID). // never expose another user's game
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&tierList).Error
+	return tierList, err
+}
+
+// UpdateTierList replaces the whole tier list with the payload. Rows and items
+// sent with an existing ID keep it, so clients get stable IDs across saves.
+func (h *Handler) UpdateTierList(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userIdStr := c.Locals("user_id").(string)
+
+	var req dto.UpdateTierListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
+	}
+	if err := validateTierListRequest(req.Name, req.Rows); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid tier list", "error": err.Error()})
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockTierList(tx, id, userIdStr)
+		if err != nil {
+			return err
+		}
+
+		edited := buildTierListFromInput(existing, req)
+		if err := checkTierListGames(tx, userIdStr, &edited); err != nil {
+			return err
+		}
+		if err := saveTierListState(tx, &existing, &edited); err != nil {
+			return err
 		}
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not fetch tier list"})
+		return recordTierListVersion(tx, &edited, models.VersionSourceUpdate)
+	})
+	if err != nil {
+		return respondTierListError(c, err, "Could not update tier list")
 	}
+	h.notifyLiveChange(id)
 
-	return c.JSON(tierList)
+	return h.respondWithTierList(c, id, userIdStr, fiber.StatusOK)
 }
 
-// UpdateTierList updates the tier list and strictly syncs rows/items
-func UpdateTierList(c *fiber.Ctx) error {
+// PatchTierList applies a list of granular operations in one transaction.
+// Either every operation applies or none does.
+func (h *Handler) PatchTierList(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userIdStr := c.Locals("user_id").(string)
 
-	var input models.TierList
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Cannot parse JSON"})
+	var req dto.PatchTierListRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
 	}
-
-	var existingTierList models.TierList
-	if err := database.DB.Where("id = ? AND user_id = ?", id, userIdStr).First(&existingTierList).Error; err != nil {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tier list not found"})
+	if len(req.Operations) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "No operations given"})
 	}
 
-	// Transaction to ensure atomicity
 	err := database.DB.Transaction(func(tx *gorm.DB) error {
-		// 1. Update basic info
-		existingTierList.Name = input.Name
-		if err := tx.Save(&existingTierList).Error; err != nil {
+		existing, err := lockTierList(tx, id, userIdStr)
+		if err != nil {
 			return err
 		}
 
-		// 2. Delete existing rows (Cascade should handle items)
-		if err := tx.Where("tier_list_id = ?", existingTierList.ID).Delete(&models.TierRow{}).Error; err != nil {
+		edited := cloneTierList(existing)
+		if err := applyTierListOperations(&edited, req.Operations); err != nil {
 			return err
 		}
-
-		// 3. Create new rows
-		for i := range input.Rows {
-			input.Rows[i].TierListID = existingTierList.ID
-			input.Rows[i].ID = uuid.Nil // Force new ID generation
-			fmt.Printf("DEBUG: Processing Row %s (Items: %d)\n", input.Rows[i].Label, len(input.Rows[i].Items))
-			for j := range input.Rows[i].Items {
-				input.Rows[i].Items[j].TierRowID = uuid.Nil // Set to nil, will be auto-filled
-				input.Rows[i].Items[j].ID = uuid.Nil
-				fmt.Printf("DEBUG:   - Item GameID: %s\n", input.Rows[i].Items[j].GameID)
-			}
+		if err := checkTierListGames(tx, userIdStr, &edited); err != nil {
+			return err
 		}
-
-		if len(input.Rows) > 0 {
-			if err := tx.Create(&input.Rows).Error; err != nil {
-				return err
-			}
+		if err := saveTierListState(tx, &existing, &edited); err != nil {
+			return err
 		}
-
-		return nil
+		return recordTierListVersion(tx, &edited, models.VersionSourcePatch)
 	})
+	if err != nil {
+		return respondTierListError(c, err, "Could not update tier list")
+	}
+	h.notifyLiveChange(id)
+
+	return h.respondWithTierList(c, id, userIdStr, fiber.StatusOK)
+}
 
+// respondTierListError maps errors from tier list writes to responses
+func respondTierListError(c *fiber.Ctx, err error, message string) error {
+	var opErr *tierListOpError
+	var itemErr *tierItemValidationError
+	switch {
+	case errors.As(err, &opErr):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"status":  "error",
+			"message": "Invalid operation",
+			"error":   opErr.Error(),
+			"data":    fiber.Map{"index": opErr.Index},
+		})
+	case errors.As(err, &itemErr):
+		return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"status": "error", "message": itemErr.Error(), "data": itemErr.Items})
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": message, "error": err.Error()})
+}
+
+func (h *Handler) respondWithTierList(c *fiber.Ctx, id string, userID interface{}, status int) error {
+	tierList, err := loadTierList(database.DB, id, userID)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not update tier list", "details": err.Error()})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch tier list"})
+	}
+	return c.Status(status).JSON(fiber.Map{"status": "success", "data": h.mapTierListToResponse(tierList)})
+}
+
+// buildTierListFromInput maps a full PUT payload onto the stored list, reusing
+// IDs the list already knows and generating new ones for everything else
+func buildTierListFromInput(existing models.TierList, input dto.UpdateTierListRequest) models.TierList {
+	knownRows := map[uuid.UUID]bool{}
+	knownItems := map[uuid.UUID]bool{}
+	for _, row := range existing.Rows {
+		knownRows[row.ID] = true
+		for _, item := range row.Items {
+			knownItems[item.ID] = true
+		}
 	}
 
-	// Fetch updated structure to return
-	// Reuse GetTierList logic or just return success
-	return c.JSON(fiber.Map{"message": "Updated successfully"})
+	edited := existing
+	edited.Name = input.Name
+	edited.Rows = make([]models.TierRow, 0, len(input.Rows))
+	for _, inRow := range input.Rows {
+		row := models.TierRow{ID: inRow.ID, Label: inRow.Label, Color: inRow.Color}
+		if !knownRows[row.ID] {
+			row.ID = uuid.New()
+		}
+		knownRows[row.ID] = false // a repeated ID becomes a new row
+		if row.Color == "" {
+			row.Color = "#FFFFFF"
+		}
+		for _, inItem := range inRow.Items {
+			item := models.TierItem{ID: inItem.ID, GameID: inItem.GameID}
+			if !knownItems[item.ID] {
+				item.ID = uuid.New()
+			}
+			knownItems[item.ID] = false
+			row.Items = append(row.Items, item)
+		}
+		edited.Rows = append(edited.Rows, row)
+	}
+	return edited
 }
 
 // DeleteTierList deletes a tier list
-func DeleteTierList(c *fiber.Ctx) error {
+func (h *Handler) DeleteTierList(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userIdStr := c.Locals("user_id").(string)
 
 	result := database.DB.Where("id = ? AND user_id = ?", id, userIdStr).Delete(&models.TierList{})
 	if result.Error != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Could not delete tier list"})
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not delete tier list"})
 	}
 	if result.RowsAffected == 0 {
-		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Tier list not found"})
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+	h.renders.InvalidateRenders(id)
+	if tierListID, err := uuid.Parse(id); err == nil {
+		h.live.closeRoom(tierListID)
 	}
 
 	return c.SendStatus(fiber.StatusNoContent)
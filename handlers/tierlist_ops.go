@@ -0,0 +1,348 @@
+package handlers
+
+import (
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var hexColorRegex = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// tierListOpError reports which operation of a PATCH failed
+type tierListOpError struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *tierListOpError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *tierListOpError) Unwrap() error {
+	return e.Err
+}
+
+// lockTierList loads a tier list with its rows and items for modification,
+// holding a row lock so concurrent saves to the same list are serialised
+func lockTierList(tx *gorm.DB, id string, userID interface{}) (models.TierList, error) {
+	var tierList models.TierList
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND user_id = ?", id, userID).
+		First(&tierList).Error; err != nil {
+		return tierList, err
+	}
+	err := tx.
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("sort_order asc") }).
+		Where("tier_list_id = ?", tierList.ID).
+		Order("sort_order asc").
+		Find(&tierList.Rows).Error
+	return tierList, err
+}
+
+// cloneTierList deep-copies the rows and items so the original survives in-memory edits
+func cloneTierList(t models.TierList) models.TierList {
+	out := t
+	out.Rows = make([]models.TierRow, len(t.Rows))
+	for i, row := range t.Rows {
+		out.Rows[i] = row
+		out.Rows[i].Items = append([]models.TierItem(nil), row.Items...)
+	}
+	return out
+}
+
+// applyTierListOperations edits the tier list in memory. Nothing is written
+// until saveTierListState, so a failing operation leaves the list untouched.
+func applyTierListOperations(t *models.TierList, ops []dto.TierListOperation) error {
+	for i, op := range ops {
+		if err := applyTierListOperation(t, op); err != nil {
+			return &tierListOpError{Index: i, Op: op.Op, Err: err}
+		}
+	}
+	return nil
+}
+
+func applyTierListOperation(t *models.TierList, op dto.TierListOperation) error {
+	switch op.Op {
+	case dto.OpRename:
+		if strings.TrimSpace(op.Name) == "" {
+			return errors.New("name is required")
+		}
+		t.Name = op.Name
+
+	case dto.OpAddRow:
+		if strings.TrimSpace(op.Label) == "" {
+			return errors.New("label is required")
+		}
+		color := op.Color
+		if color == "" {
+			color = "#FFFFFF"
+		}
+		if !hexColorRegex.MatchString(color) {
+			return fmt.Errorf("invalid color %q", op.Color)
+		}
+		id := op.RowID
+		if id == uuid.Nil {
+			id = uuid.New()
+		} else if findRowIndex(t, id) >= 0 {
+			return fmt.Errorf("row %s already exists", id)
+		}
+		row := models.TierRow{ID: id, TierListID: t.ID, Label: op.Label, Color: color}
+		idx, err := insertIndex(op.Index, len(t.Rows))
+		if err != nil {
+			return err
+		}
+		t.Rows = insertAt(t.Rows, idx, row)
+
+	case dto.OpRemoveRow:
+		idx := findRowIndex(t, op.RowID)
+		if idx < 0 {
+			return fmt.Errorf("row %s not found", op.RowID)
+		}
+		t.Rows = append(t.Rows[:idx], t.Rows[idx+1:]...)
+
+	case dto.OpRenameRow:
+		idx := findRowIndex(t, op.RowID)
+		if idx < 0 {
+			return fmt.Errorf("row %s not found", op.RowID)
+		}
+		if strings.TrimSpace(op.Label) == "" {
+			return errors.New("label is required")
+		}
+		t.Rows[idx].Label = op.Label
+
+	case dto.OpRecolorRow:
+		idx := findRowIndex(t, op.RowID)
+		if idx < 0 {
+			return fmt.Errorf("row %s not found", op.RowID)
+		}
+		if !hexColorRegex.MatchString(op.Color) {
+			return fmt.Errorf("invalid color %q", op.Color)
+		}
+		t.Rows[idx].Color = op.Color
+
+	case dto.OpMoveRow:
+		idx := findRowIndex(t, op.RowID)
+		if idx < 0 {
+			return fmt.Errorf("row %s not found", op.RowID)
+		}
+		row := t.Rows[idx]
+		t.Rows = append(t.Rows[:idx], t.Rows[idx+1:]...)
+		target, err := insertIndex(op.Index, len(t.Rows))
+		if err != nil {
+			return err
+		}
+		t.Rows = insertAt(t.Rows, target, row)
+
+	case dto.OpReorderRows:
+		if len(op.RowIDs) != len(t.Rows) {
+			return fmt.Errorf("row_ids must list all %d rows", len(t.Rows))
+		}
+		reordered := make([]models.TierRow, 0, len(t.Rows))
+		seen := map[uuid.UUID]bool{}
+		for _, id := range op.RowIDs {
+			idx := findRowIndex(t, id)
+			if idx < 0 || seen[id] {
+				return fmt.Errorf("row %s not found or repeated", id)
+			}
+			seen[id] = true
+			reordered = append(reordered, t.Rows[idx])
+		}
+		t.Rows = reordered
+
+	case dto.OpAddItem:
+		rowIdx := findRowIndex(t, op.RowID)
+		if rowIdx < 0 {
+			return fmt.Errorf("row %s not found", op.RowID)
+		}
+		if op.GameID == uuid.Nil {
+			return errors.New("game_id is required")
+		}
+		id := op.ItemID
+		if id == uuid.Nil {
+			id = uuid.New()
+		} else if r, _ := findItemIndex(t, id); r >= 0 {
+			return fmt.Errorf("item %s already exists", id)
+		}
+		idx, err := insertIndex(op.Index, len(t.Rows[rowIdx].Items))
+		if err != nil {
+			return err
+		}
+		item := models.TierItem{ID: id, TierRowID: op.RowID, GameID: op.GameID}
+		t.Rows[rowIdx].Items = insertAt(t.Rows[rowIdx].Items, idx, item)
+
+	case dto.OpRemoveItem:
+		rowIdx, itemIdx := findItemIndex(t, op.ItemID)
+		if rowIdx < 0 {
+			return fmt.Errorf("item %s not found", op.ItemID)
+		}
+		items := t.Rows[rowIdx].Items
+		t.Rows[rowIdx].Items = append(items[:itemIdx], items[itemIdx+1:]...)
+
+	case dto.OpMoveItem:
+		rowIdx, itemIdx := findItemIndex(t, op.ItemID)
+		if rowIdx < 0 {
+			return fmt.Errorf("item %s not found", op.ItemID)
+		}
+		targetRow := rowIdx
+		if op.RowID != uuid.Nil {
+			if targetRow = findRowIndex(t, op.RowID); targetRow < 0 {
+				return fmt.Errorf("row %s not found", op.RowID)
+			}
+		}
+		item := t.Rows[rowIdx].Items[itemIdx]
+		items := t.Rows[rowIdx].Items
+		t.Rows[rowIdx].Items = append(items[:itemIdx], items[itemIdx+1:]...)
+		idx, err := insertIndex(op.Index, len(t.Rows[targetRow].Items))
+		if err != nil {
+			return err
+		}
+		t.Rows[targetRow].Items = insertAt(t.Rows[targetRow].Items, idx, item)
+
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+	return nil
+}
+
+// saveTierListState writes the difference between the stored list and the
+// edited one. Rows and items keep their IDs; sort orders follow slice order.
+// Every save bumps the live revision, so callers hold the row lock of
+// lockTierList.
+func saveTierListState(tx *gorm.DB, original, edited *models.TierList) error {
+	oldRows := map[uuid.UUID]models.TierRow{}
+	oldItems := map[uuid.UUID]models.TierItem{}
+	for _, row := range original.Rows {
+		oldRows[row.ID] = row
+		for _, item := range row.Items {
+			oldItems[item.ID] = item
+		}
+	}
+
+	keptRows := map[uuid.UUID]bool{}
+	keptItems := map[uuid.UUID]bool{}
+	for i := range edited.Rows {
+		row := &edited.Rows[i]
+		row.TierListID = edited.ID
+		row.SortOrder = i
+		keptRows[row.ID] = true
+		for j := range row.Items {
+			row.Items[j].TierRowID = row.ID
+			row.Items[j].SortOrder = j
+			keptItems[row.Items[j].ID] = true
+		}
+	}
+
+	// Removed rows are deleted last, after their surviving items have moved on,
+	// so the cascade only takes items that were removed anyway
+	var removedItems, removedRows []uuid.UUID
+	for id := range oldItems {
+		if !keptItems[id] {
+			removedItems = append(removedItems, id)
+		}
+	}
+	for id := range oldRows {
+		if !keptRows[id] {
+			removedRows = append(removedRows, id)
+		}
+	}
+
+	if len(removedItems) > 0 {
+		if err := tx.Where("id IN ?", removedItems).Delete(&models.TierItem{}).Error; err != nil {
+			return err
+		}
+	}
+
+	for _, row := range edited.Rows {
+		old, exists := oldRows[row.ID]
+		if !exists {
+			newRow := row
+			newRow.Items = nil
+			if err := tx.Create(&newRow).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		if old.Label != row.Label || old.Color != row.Color || old.SortOrder != row.SortOrder {
+			if err := tx.Model(&models.TierRow{}).Where("id = ?", row.ID).
+				Updates(map[string]interface{}{"label": row.Label, "color": row.Color, "sort_order": row.SortOrder}).Error; err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, row := range edited.Rows {
+		for _, item := range row.Items {
+			old, exists := oldItems[item.ID]
+			if !exists {
+				newItem := item
+				newItem.Game = models.Game{}
+				if err := tx.Omit("Game").Create(&newItem).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if old.TierRowID != item.TierRowID || old.SortOrder != item.SortOrder {
+				if err := tx.Model(&models.TierItem{}).Where("id = ?", item.ID).
+					Updates(map[string]interface{}{"tier_row_id": item.TierRowID, "sort_order": item.SortOrder}).Error; err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if len(removedRows) > 0 {
+		if err := tx.Where("id IN ?", removedRows).Delete(&models.TierRow{}).Error; err != nil {
+			return err
+		}
+	}
+
+	edited.LiveRevision = original.LiveRevision + 1
+	return tx.Model(&models.TierList{}).Where("id = ?", edited.ID).
+		Updates(map[string]interface{}{"name": edited.Name, "live_revision": edited.LiveRevision}).Error
+}
+
+func findRowIndex(t *models.TierList, id uuid.UUID) int {
+	for i, row := range t.Rows {
+		if row.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func findItemIndex(t *models.TierList, id uuid.UUID) (int, int) {
+	for i, row := range t.Rows {
+		for j, item := range row.Items {
+			if item.ID == id {
+				return i, j
+			}
+		}
+	}
+	return -1, -1
+}
+
+// insertIndex resolves an optional target index against a slice length; nil appends
+func insertIndex(index *int, length int) (int, error) {
+	if index == nil {
+		return length, nil
+	}
+	if *index < 0 || *index > length {
+		return 0, fmt.Errorf("index %d out of range 0..%d", *index, length)
+	}
+	return *index, nil
+}
+
+func insertAt[T any](s []T, idx int, v T) []T {
+	s = append(s, v)
+	copy(s[idx+1:], s[idx:])
+	s[idx] = v
+	return s
+}
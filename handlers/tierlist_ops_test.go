@@ -0,0 +1,369 @@
+package handlers
+
+import (
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// tierListFixture is a list "Games" with rows A (games 1 and 2) and B (empty)
+type tierListFixture struct {
+	list                models.TierList
+	rowA, rowB          uuid.UUID
+	item1, item2        uuid.UUID
+	game1, game2, game3 uuid.UUID
+	games               map[uuid.UUID]string
+}
+
+func newTierListFixture() *tierListFixture {
+	f := &tierListFixture{
+		rowA: uuid.New(), rowB: uuid.New(),
+		item1: uuid.New(), item2: uuid.New(),
+		game1: uuid.New(), game2: uuid.New(), game3: uuid.New(),
+	}
+	f.games = map[uuid.UUID]string{f.game1: "1", f.game2: "2", f.game3: "3"}
+	id := uuid.New()
+	f.list = models.TierList{ID: id, Name: "Games", Rows: []models.TierRow{
+		{ID: f.rowA, TierListID: id, Label: "A", Color: "#FF0000", SortOrder: 0, Items: []models.TierItem{
+			{ID: f.item1, TierRowID: f.rowA, GameID: f.game1, SortOrder: 0},
+			{ID: f.item2, TierRowID: f.rowA, GameID: f.game2, SortOrder: 1},
+		}},
+		{ID: f.rowB, TierListID: id, Label: "B", Color: "#00FF00", SortOrder: 1},
+	}}
+	return f
+}
+
+// layout renders a list as "Name | A #FF0000: 1,2 | B #00FF00:"
+func (f *tierListFixture) layout(t *models.TierList) string {
+	parts := []string{t.Name}
+	for _, row := range t.Rows {
+		games := make([]string, len(row.Items))
+		for i, item := range row.Items {
+			games[i] = f.games[item.GameID]
+		}
+		parts = append(parts, strings.TrimSpace(fmt.Sprintf("%s %s: %s", row.Label, row.Color, strings.Join(games, ","))))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func TestApplyTierListOperation(t *testing.T) {
+	at := func(i int) *int { return &i }
+	unknown := uuid.New()
+
+	tests := []struct {
+		name    string
+		op      func(f *tierListFixture) dto.TierListOperation
+		want    string
+		wantErr string
+	}{
+		{
+			name: "rename",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRename, Name: "Best of 2024"}
+			},
+			want: "Best of 2024 | A #FF0000: 1,2 | B #00FF00:",
+		},
+		{
+			name: "rename to blank",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRename, Name: "  "}
+			},
+			wantErr: "name is required",
+		},
+		{
+			name: "add row with the default color",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "S", Index: at(0)}
+			},
+			want: "Games | S #FFFFFF: | A #FF0000: 1,2 | B #00FF00:",
+		},
+		{
+			name: "add row at the end",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C", Color: "#0000FF"}
+			},
+			want: "Games | A #FF0000: 1,2 | B #00FF00: | C #0000FF:",
+		},
+		{
+			name: "add row with an invalid color",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C", Color: "red"}
+			},
+			wantErr: `invalid color "red"`,
+		},
+		{
+			name: "add row with an existing id",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C", RowID: f.rowA}
+			},
+			wantErr: "already exists",
+		},
+		{
+			name: "add row past the end",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddRow, Label: "C", Index: at(3)}
+			},
+			wantErr: "index 3 out of range 0..2",
+		},
+		{
+			name: "remove row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRemoveRow, RowID: f.rowA}
+			},
+			want: "Games | B #00FF00:",
+		},
+		{
+			name: "remove unknown row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRemoveRow, RowID: unknown}
+			},
+			wantErr: "not found",
+		},
+		{
+			name: "rename row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRenameRow, RowID: f.rowB, Label: "Meh"}
+			},
+			want: "Games | A #FF0000: 1,2 | Meh #00FF00:",
+		},
+		{
+			name: "recolor row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRecolorRow, RowID: f.rowB, Color: "#123abc"}
+			},
+			want: "Games | A #FF0000: 1,2 | B #123abc:",
+		},
+		{
+			name: "recolor row without a color",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRecolorRow, RowID: f.rowB}
+			},
+			wantErr: "invalid color",
+		},
+		{
+			name: "move row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveRow, RowID: f.rowB, Index: at(0)}
+			},
+			want: "Games | B #00FF00: | A #FF0000: 1,2",
+		},
+		{
+			name: "reorder rows",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpReorderRows, RowIDs: []uuid.UUID{f.rowB, f.rowA}}
+			},
+			want: "Games | B #00FF00: | A #FF0000: 1,2",
+		},
+		{
+			name: "reorder rows missing one",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpReorderRows, RowIDs: []uuid.UUID{f.rowB}}
+			},
+			wantErr: "row_ids must list all 2 rows",
+		},
+		{
+			name: "reorder rows repeating one",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpReorderRows, RowIDs: []uuid.UUID{f.rowB, f.rowB}}
+			},
+			wantErr: "not found or repeated",
+		},
+		{
+			name: "add item",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddItem, RowID: f.rowA, GameID: f.game3, Index: at(1)}
+			},
+			want: "Games | A #FF0000: 1,3,2 | B #00FF00:",
+		},
+		{
+			name: "add item without a game",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddItem, RowID: f.rowA}
+			},
+			wantErr: "game_id is required",
+		},
+		{
+			name: "add item with an existing id",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpAddItem, RowID: f.rowB, GameID: f.game3, ItemID: f.item1}
+			},
+			wantErr: "already exists",
+		},
+		{
+			name: "remove item",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpRemoveItem, ItemID: f.item1}
+			},
+			want: "Games | A #FF0000: 2 | B #00FF00:",
+		},
+		{
+			name: "move item to another row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: f.item2, RowID: f.rowB}
+			},
+			want: "Games | A #FF0000: 1 | B #00FF00: 2",
+		},
+		{
+			name: "move item within its row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: f.item2, Index: at(0)}
+			},
+			want: "Games | A #FF0000: 2,1 | B #00FF00:",
+		},
+		{
+			name: "move item past the end of its row",
+			op: func(f *tierListFixture) dto.TierListOperation {
+				return dto.TierListOperation{Op: dto.OpMoveItem, ItemID: f.item2, Index: at(2)}
+			},
+			wantErr: "index 2 out of range 0..1",
+		},
+		{
+			name:    "unknown op",
+			op:      func(f *tierListFixture) dto.TierListOperation { return dto.TierListOperation{Op: "shuffle"} },
+			wantErr: `unknown op "shuffle"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTierListFixture()
+			err := applyTierListOperation(&f.list, tt.op(f))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := f.layout(&f.list); got != tt.want {
+				t.Errorf("layout = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTierListOperationsReportsTheFailingIndex(t *testing.T) {
+	f := newTierListFixture()
+	err := applyTierListOperations(&f.list, []dto.TierListOperation{
+		{Op: dto.OpRename, Name: "Renamed"},
+		{Op: dto.OpRemoveRow, RowID: uuid.New()},
+	})
+	var opErr *tierListOpError
+	if !errors.As(err, &opErr) || opErr.Index != 1 || opErr.Op != dto.OpRemoveRow {
+		t.Fatalf("error = %v, want operation 1 (remove_row)", err)
+	}
+}
+
+// dryRunDB builds SQL without a database. It returns the statements each
+// write would have run, as "<verb> <table>".
+func dryRunDB(t *testing.T) (*gorm.DB, *[]string) {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost"}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var statements []string
+	record := func(verb string) func(*gorm.DB) {
+		return func(tx *gorm.DB) {
+			statements = append(statements, verb+" "+tx.Statement.Table)
+		}
+	}
+	db.Callback().Create().After("gorm:create").Register("test:record", record("INSERT"))
+	db.Callback().Update().After("gorm:update").Register("test:record", record("UPDATE"))
+	db.Callback().Delete().After("gorm:delete").Register("test:record", record("DELETE"))
+	return db, &statements
+}
+
+func TestSaveTierListState(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  func(f *tierListFixture) []dto.TierListOperation
+		want []string
+	}{
+		{
+			name: "nothing changed",
+			ops:  func(f *tierListFixture) []dto.TierListOperation { return nil },
+			want: []string{"UPDATE tier_lists"},
+		},
+		{
+			name: "renamed row",
+			ops: func(f *tierListFixture) []dto.TierListOperation {
+				return []dto.TierListOperation{{Op: dto.OpRenameRow, RowID: f.rowA, Label: "S"}}
+			},
+			want: []string{"UPDATE tier_rows", "UPDATE tier_lists"},
+		},
+		{
+			name: "swapped rows update both sort orders",
+			ops: func(f *tierListFixture) []dto.TierListOperation {
+				return []dto.TierListOperation{{Op: dto.OpReorderRows, RowIDs: []uuid.UUID{f.rowB, f.rowA}}}
+			},
+			want: []string{"UPDATE tier_rows", "UPDATE tier_rows", "UPDATE tier_lists"},
+		},
+		{
+			name: "new row and item",
+			ops: func(f *tierListFixture) []dto.TierListOperation {
+				row := uuid.New()
+				return []dto.TierListOperation{
+					{Op: dto.OpAddRow, RowID: row, Label: "C"},
+					{Op: dto.OpAddItem, RowID: row, GameID: f.game3},
+				}
+			},
+			want: []string{"INSERT tier_rows", "INSERT tier_items", "UPDATE tier_lists"},
+		},
+		{
+			name: "removed item shifts the one after it",
+			ops: func(f *tierListFixture) []dto.TierListOperation {
+				return []dto.TierListOperation{{Op: dto.OpRemoveItem, ItemID: f.item1}}
+			},
+			want: []string{"DELETE tier_items", "UPDATE tier_items", "UPDATE tier_lists"},
+		},
+		{
+			name: "items leave a removed row before it is deleted",
+			ops: func(f *tierListFixture) []dto.TierListOperation {
+				return []dto.TierListOperation{
+					{Op: dto.OpMoveItem, ItemID: f.item1, RowID: f.rowB},
+					{Op: dto.OpMoveItem, ItemID: f.item2, RowID: f.rowB},
+					{Op: dto.OpRemoveRow, RowID: f.rowA},
+				}
+			},
+			want: []string{"UPDATE tier_rows", "UPDATE tier_items", "UPDATE tier_items", "DELETE tier_rows", "UPDATE tier_lists"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newTierListFixture()
+			edited := cloneTierList(f.list)
+			if err := applyTierListOperations(&edited, tt.ops(f)); err != nil {
+				t.Fatal(err)
+			}
+			db, statements := dryRunDB(t)
+			if err := saveTierListState(db, &f.list, &edited); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(*statements, ", "); got != strings.Join(tt.want, ", ") {
+				t.Errorf("statements = %s, want %s", got, strings.Join(tt.want, ", "))
+			}
+			if edited.LiveRevision != f.list.LiveRevision+1 {
+				t.Errorf("live revision = %d, want %d", edited.LiveRevision, f.list.LiveRevision+1)
+			}
+			for i, row := range edited.Rows {
+				if row.SortOrder != i || row.TierListID != edited.ID {
+					t.Errorf("row %s: sort order %d, list %s", row.Label, row.SortOrder, row.TierListID)
+				}
+				for j, item := range row.Items {
+					if item.SortOrder != j || item.TierRowID != row.ID {
+						t.Errorf("item %s: sort order %d, row %s", f.games[item.GameID], item.SortOrder, item.TierRowID)
+					}
+				}
+			}
+		})
+	}
+}
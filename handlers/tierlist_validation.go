@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"backlog-backend/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// tierItemError describes why a single tier item was rejected
+type tierItemError struct {
+	RowIndex  int       `json:"row_index"`
+	ItemIndex int       `json:"item_index"`
+	GameID    uuid.UUID `json:"game_id"`
+	Error     string    `json:"error"`
+}
+
+// validateTierListGames checks that every item points at one of the user's own
+// games and that no game is placed twice in the list
+func validateTierListGames(db *gorm.DB, userID interface{}, tierList *models.TierList) ([]tierItemError, error) {
+	ids := make([]uuid.UUID, 0)
+	for _, row := range tierList.Rows {
+		for _, item := range row.Items {
+			ids = append(ids, item.GameID)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var owned []uuid.UUID
+	if err := db.Model(&models.Game{}).
+		Where("user_id = ? AND id IN ?", userID, ids).
+		Pluck("id", &owned).Error; err != nil {
+		return nil, err
+	}
+	ownedSet := make(map[uuid.UUID]bool, len(owned))
+	for _, id := range owned {
+		ownedSet[id] = true
+	}
+
+	var errs []tierItemError
+	seen := map[uuid.UUID]bool{}
+	for i, row := range tierList.Rows {
+		for j, item := range row.Items {
+			switch {
+			case item.GameID == uuid.Nil:
+				errs = append(errs, tierItemError{i, j, item.GameID, "game_id is required"})
+			case !ownedSet[item.GameID]:
+				// Foreign and nonexistent games get the same answer so IDs cannot be probed
+				errs = append(errs, tierItemError{i, j, item.GameID, "game not found in your library"})
+			case seen[item.GameID]:
+				errs = append(errs, tierItemError{i, j, item.GameID, "game already placed in this tier list"})
+			}
+			seen[item.GameID] = true
+		}
+	}
+	return errs, nil
+}
+
+// tierItemValidationError carries the per-item errors out of a transaction
+type tierItemValidationError struct {
+	Items []tierItemError
+}
+
+func (e *tierItemValidationError) Error() string {
+	return "tier list contains invalid games"
+}
+
+// checkTierListGames wraps validateTierListGames for use inside transactions
+func checkTierListGames(tx *gorm.DB, userID interface{}, tierList *models.TierList) error {
+	errs, err := validateTierListGames(tx, userID, tierList)
+	if err != nil {
+		return err
+	}
+	if len(errs) > 0 {
+		return &tierItemValidationError{Items: errs}
+	}
+	return nil
+}
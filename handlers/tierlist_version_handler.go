@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"encoding/json"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// maxTierListVersions is how many snapshots are kept per tier list
+const maxTierListVersions = 50
+
+func snapshotTierList(t *models.TierList) models.TierListSnapshot {
+	snap := models.TierListSnapshot{Name: t.Name, Rows: make([]models.SnapshotRow, 0, len(t.Rows))}
+	for _, row := range t.Rows {
+		items := make([]models.SnapshotItem, 0, len(row.Items))
+		for _, item := range row.Items {
+			items = append(items, models.SnapshotItem{ID: item.ID, GameID: item.GameID})
+		}
+		snap.Rows = append(snap.Rows, models.SnapshotRow{ID: row.ID, Label: row.Label, Color: row.Color, Items: items})
+	}
+	return snap
+}
+
+// recordTierListVersion stores the saved state of a tier list as its next
+// version and prunes the oldest ones beyond maxTierListVersions
+func recordTierListVersion(tx *gorm.DB, t *models.TierList, source string) error {
+	data, err := json.Marshal(snapshotTierList(t))
+	if err != nil {
+		return err
+	}
+
+	var last int
+	if err := tx.Model(&models.TierListVersion{}).Where("tier_list_id = ?", t.ID).
+		Select("COALESCE(MAX(version), 0)").Scan(&last).Error; err != nil {
+		return err
+	}
+
+	version := models.TierListVersion{TierListID: t.ID, Version: last + 1, Source: source, Snapshot: data}
+	if err := tx.Omit("TierList").Create(&version).Error; err != nil {
+		return err
+	}
+	return tx.Where("tier_list_id = ? AND version <= ?", t.ID, version.Version-maxTierListVersions).
+		Delete(&models.TierListVersion{}).Error
+}
+
+func decodeSnapshot(v models.TierListVersion) (models.TierListSnapshot, error) {
+	var snap models.TierListSnapshot
+	err := json.Unmarshal(v.Snapshot, &snap)
+	return snap, err
+}
+
+// diffSnapshots reports row changes and which games moved between tiers.
+// Rows are matched by ID, so renaming a row does not move its games.
+func diffSnapshots(prev, cur models.TierListSnapshot, titles map[uuid.UUID]string) dto.VersionDiff {
+	diff := dto.VersionDiff{
+		Moved: []dto.GameMove{}, Added: []dto.GameMove{}, Removed: []dto.GameMove{},
+		RowsAdded: []string{}, RowsRemoved: []string{}, RowsRenamed: []dto.RowRename{},
+	}
+	if prev.Name != cur.Name {
+		diff.Renamed = &dto.RowRename{From: prev.Name, To: cur.Name}
+	}
+
+	prevRows := map[uuid.UUID]string{}
+	prevGames := map[uuid.UUID]models.SnapshotRow{}
+	for _, row := range prev.Rows {
+		prevRows[row.ID] = row.Label
+		for _, item := range row.Items {
+			prevGames[item.GameID] = row
+		}
+	}
+	curRows := map[uuid.UUID]bool{}
+	curGames := map[uuid.UUID]bool{}
+	for _, row := range cur.Rows {
+		curRows[row.ID] = true
+		if label, ok := prevRows[row.ID]; !ok {
+			diff.RowsAdded = append(diff.RowsAdded, row.Label)
+		} else if label != row.Label {
+			diff.RowsRenamed = append(diff.RowsRenamed, dto.RowRename{From: label, To: row.Label})
+		}
+		for _, item := range row.Items {
+			curGames[item.GameID] = true
+			from, ok := prevGames[item.GameID]
+			switch {
+			case !ok:
+				diff.Added = append(diff.Added, dto.GameMove{GameID: item.GameID, Title: titles[item.GameID], To: row.Label})
+			case from.ID != row.ID:
+				diff.Moved = append(diff.Moved, dto.GameMove{GameID: item.GameID, Title: titles[item.GameID], From: from.Label, To: row.Label})
+			}
+		}
+	}
+	for _, row := range prev.Rows {
+		if !curRows[row.ID] {
+			diff.RowsRemoved = append(diff.RowsRemoved, row.Label)
+		}
+		for _, item := range row.Items {
+			if !curGames[item.GameID] {
+				diff.Removed = append(diff.Removed, dto.GameMove{GameID: item.GameID, Title: titles[item.GameID], From: row.Label})
+			}
+		}
+	}
+	return diff
+}
+
+func mapVersionToResponse(v models.TierListVersion, snap models.TierListSnapshot, changes dto.VersionDiff) dto.TierListVersionResponse {
+	res := dto.TierListVersionResponse{
+		Version: v.Version, Source: v.Source, Name: snap.Name,
+		RowCount: len(snap.Rows), CreatedAt: v.CreatedAt, Changes: changes,
+	}
+	for _, row := range snap.Rows {
+		res.ItemCount += len(row.Items)
+	}
+	return res
+}
+
+// gameTitles looks up the titles of the games referenced by the snapshots
+func gameTitles(userID interface{}, snaps ...models.TierListSnapshot) map[uuid.UUID]string {
+	var ids []uuid.UUID
+	for _, snap := range snaps {
+		for _, row := range snap.Rows {
+			for _, item := range row.Items {
+				ids = append(ids, item.GameID)
+			}
+		}
+	}
+	titles := map[uuid.UUID]string{}
+	if len(ids) == 0 {
+		return titles
+	}
+	var games []models.Game
+	database.DB.Select("id", "title").Where("user_id = ? AND id IN ?", userID, ids).Find(&games)
+	for _, g := range games {
+		titles[g.ID] = g.Title
+	}
+	return titles
+}
+
+// GetTierListVersions lists the saved versions of a tier list, newest first,
+// each with a summary of what changed since the version before it
+func (h *Handler) GetTierListVersions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userIdStr := c.Locals("user_id").(string)
+
+	var tierList models.TierList
+	if err := database.DB.Select("id").Where("id = ? AND user_id = ?", id, userIdStr).First(&tierList).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list not found"})
+	}
+
+	limit := c.QueryInt("limit", 20)
+	if limit < 1 || limit > maxTierListVersions {
+		limit = 20
+	}
+	offset := c.QueryInt("offset", 0)
+	if offset < 0 {
+		offset = 0
+	}
+
+	// One extra version is loaded so the oldest one on the page has something to diff against
+	var versions []models.TierListVersion
+	if err := database.DB.Where("tier_list_id = ?", tierList.ID).
+		Order("version desc").Limit(limit + 1).Offset(offset).
+		Find(&versions).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not fetch versions"})
+	}
+
+	snaps := make([]models.TierListSnapshot, len(versions))
+	for i, v := range versions {
+		snaps[i], _ = decodeSnapshot(v)
+	}
+	titles := gameTitles(userIdStr, snaps...)
+
+	res := make([]dto.TierListVersionResponse, 0, limit)
+	for i := 0; i < len(versions) && i < limit; i++ {
+		var prev models.TierListSnapshot
+		if i+1 < len(versions) {
+			prev = snaps[i+1]
+		}
+		res = append(res, mapVersionToResponse(versions[i], snaps[i], diffSnapshots(prev, snaps[i], titles)))
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// GetTierListVersion returns a single version with its rows and games
+func (h *Handler) GetTierListVersion(c *fiber.Ctx) error {
+	userIdStr := c.Locals("user_id").(string)
+
+	version, err := findTierListVersion(database.DB, c.Params("id"), userIdStr, c.Params("version"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Version not found"})
+	}
+	snap, err := decodeSnapshot(version)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not read version"})
+	}
+
+	var prev models.TierListSnapshot
+	var prevVersion models.TierListVersion
+	if err := database.DB.Where("tier_list_id = ? AND version < ?", version.TierListID, version.Version).
+		Order("version desc").First(&prevVersion).Error; err == nil {
+		prev, _ = decodeSnapshot(prevVersion)
+	}
+	titles := gameTitles(userIdStr, snap, prev)
+
+	res := mapVersionToResponse(version, snap, diffSnapshots(prev, snap, titles))
+	for _, row := range snap.Rows {
+		items := make([]dto.VersionItem, 0, len(row.Items))
+		for _, item := range row.Items {
+			items = append(items, dto.VersionItem{GameID: item.GameID, Title: titles[item.GameID]})
+		}
+		res.Rows = append(res.Rows, dto.VersionRow{Label: row.Label, Color: row.Color, Items: items})
+	}
+	return c.JSON(fiber.Map{"status": "success", "data": res})
+}
+
+// RestoreTierListVersion reverts the tier list to a saved version in one
+// transaction. Items of games deleted since then are left out. The restore is
+// itself recorded as a new version, so it can be undone.
+func (h *Handler) RestoreTierListVersion(c *fiber.Ctx) error {
+	id := c.Params("id")
+	userIdStr := c.Locals("user_id").(string)
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		existing, err := lockTierList(tx, id, userIdStr)
+		if err != nil {
+			return err
+		}
+		version, err := findTierListVersion(tx, id, userIdStr, c.Params("version"))
+		if err != nil {
+			return err
+		}
+		snap, err := decodeSnapshot(version)
+		if err != nil {
+			return err
+		}
+
+		var gameIDs []uuid.UUID
+		for _, row := range snap.Rows {
+			for _, item := range row.Items {
+				gameIDs = append(gameIDs, item.GameID)
+			}
+		}
+		alive := map[uuid.UUID]bool{}
+		if len(gameIDs) > 0 {
+			var found []uuid.UUID
+			if err := tx.Model(&models.Game{}).Where("user_id = ? AND id IN ?", userIdStr, gameIDs).
+				Pluck("id", &found).Error; err != nil {
+				return err
+			}
+			for _, gid := range found {
+				alive[gid] = true
+			}
+		}
+
+		input := dto.UpdateTierListRequest{Name: snap.Name}
+		for _, row := range snap.Rows {
+			inRow := dto.TierRowRequest{ID: row.ID, Label: row.Label, Color: row.Color}
+			for _, item := range row.Items {
+				if alive[item.GameID] {
+					inRow.Items = append(inRow.Items, dto.TierItemRequest{ID: item.ID, GameID: item.GameID})
+				}
+			}
+			input.Rows = append(input.Rows, inRow)
+		}
+
+		edited := buildTierListFromInput(existing, input)
+		if err := checkTierListGames(tx, userIdStr, &edited); err != nil {
+			return err
+		}
+		if err := saveTierListState(tx, &existing, &edited); err != nil {
+			return err
+		}
+		return recordTierListVersion(tx, &edited, models.VersionSourceRestore)
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"status": "error", "message": "Tier list or version not found"})
+		}
+		return respondTierListError(c, err, "Could not restore tier list")
+	}
+	h.notifyLiveChange(id)
+
+	return h.respondWithTierList(c, id, userIdStr, fiber.StatusOK)
+}
+
+func findTierListVersion(db *gorm.DB, tierListID string, userID interface{}, rawVersion string) (models.TierListVersion, error) {
+	var version models.TierListVersion
+	number, err := strconv.Atoi(rawVersion)
+	if err != nil {
+		return version, gorm.ErrRecordNotFound
+	}
+	err = db.Joins("JOIN tier_lists ON tier_lists.id = tier_list_versions.tier_list_id").
+		Where("tier_list_versions.tier_list_id = ? AND tier_lists.user_id = ? AND tier_list_versions.version = ?", tierListID, userID, number).
+		First(&version).Error
+	return version, err
+}
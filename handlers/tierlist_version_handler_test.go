@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"backlog-backend/dto"
+	"backlog-backend/models"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	rowS, rowA, rowB, rowC := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	hades, celeste, tunic, outer := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	titles := map[uuid.UUID]string{hades: "Hades", celeste: "Celeste", tunic: "Tunic", outer: "Outer Wilds"}
+
+	row := func(id uuid.UUID, label string, games ...uuid.UUID) models.SnapshotRow {
+		r := models.SnapshotRow{ID: id, Label: label, Color: "#FFFFFF", Items: []models.SnapshotItem{}}
+		for _, g := range games {
+			r.Items = append(r.Items, models.SnapshotItem{ID: uuid.New(), GameID: g})
+		}
+		return r
+	}
+	prev := models.TierListSnapshot{Name: "2024", Rows: []models.SnapshotRow{
+		row(rowS, "S", hades), row(rowA, "A", celeste), row(rowB, "B", tunic),
+	}}
+	empty := func() dto.VersionDiff {
+		return dto.VersionDiff{
+			Moved: []dto.GameMove{}, Added: []dto.GameMove{}, Removed: []dto.GameMove{},
+			RowsAdded: []string{}, RowsRemoved: []string{}, RowsRenamed: []dto.RowRename{},
+		}
+	}
+
+	tests := []struct {
+		name string
+		cur  models.TierListSnapshot
+		want func(d *dto.VersionDiff)
+	}{
+		{
+			name: "unchanged",
+			cur:  prev,
+			want: func(d *dto.VersionDiff) {},
+		},
+		{
+			name: "renamed list and row keep their games in place",
+			cur: models.TierListSnapshot{Name: "2025", Rows: []models.SnapshotRow{
+				row(rowS, "S", hades), row(rowA, "Great", celeste), row(rowB, "B", tunic),
+			}},
+			want: func(d *dto.VersionDiff) {
+				d.Renamed = &dto.RowRename{From: "2024", To: "2025"}
+				d.RowsRenamed = []dto.RowRename{{From: "A", To: "Great"}}
+			},
+		},
+		{
+			name: "moved between rows",
+			cur: models.TierListSnapshot{Name: "2024", Rows: []models.SnapshotRow{
+				row(rowS, "S", hades, celeste), row(rowA, "A"), row(rowB, "B", tunic),
+			}},
+			want: func(d *dto.VersionDiff) {
+				d.Moved = []dto.GameMove{{GameID: celeste, Title: "Celeste", From: "A", To: "S"}}
+			},
+		},
+		{
+			name: "moved between rows with the same label",
+			cur: models.TierListSnapshot{Name: "2024", Rows: []models.SnapshotRow{
+				row(rowS, "S", hades), row(rowA, "A"), row(rowB, "B", tunic), row(rowC, "A", celeste),
+			}},
+			want: func(d *dto.VersionDiff) {
+				d.RowsAdded = []string{"A"}
+				d.Moved = []dto.GameMove{{GameID: celeste, Title: "Celeste", From: "A", To: "A"}}
+			},
+		},
+		{
+			name: "added and removed games",
+			cur: models.TierListSnapshot{Name: "2024", Rows: []models.SnapshotRow{
+				row(rowS, "S", hades, outer), row(rowA, "A", celeste), row(rowB, "B"),
+			}},
+			want: func(d *dto.VersionDiff) {
+				d.Added = []dto.GameMove{{GameID: outer, Title: "Outer Wilds", To: "S"}}
+				d.Removed = []dto.GameMove{{GameID: tunic, Title: "Tunic", From: "B"}}
+			},
+		},
+		{
+			name: "removed row with its games, added row",
+			cur: models.TierListSnapshot{Name: "2024", Rows: []models.SnapshotRow{
+				row(rowS, "S", hades), row(rowB, "B", tunic), row(rowC, "C"),
+			}},
+			want: func(d *dto.VersionDiff) {
+				d.RowsAdded = []string{"C"}
+				d.RowsRemoved = []string{"A"}
+				d.Removed = []dto.GameMove{{GameID: celeste, Title: "Celeste", From: "A"}}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := empty()
+			tt.want(&want)
+			if got := diffSnapshots(prev, tt.cur, titles); !reflect.DeepEqual(got, want) {
+				t.Errorf("diffSnapshots() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -3,8 +3,8 @@ package handlers
 import (
 	"backlog-backend/database"
 	"backlog-backend/dto"
+	"backlog-backend/middleware"
 	"backlog-backend/models"
-	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -19,7 +19,7 @@ func hashPassword(password string) (string, error) {
 	return string(bytes), err
 }
 
-func CreateUser(c *fiber.Ctx) error {
+func (h *Handler) CreateUser(c *fiber.Ctx) error {
 	var req dto.CreateUserRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input", "error": err.Error()})
@@ -59,7 +59,7 @@ func CreateUser(c *fiber.Ctx) error {
 	}
 
 	// Generate Token
-	token, err := generateToken(user)
+	token, err := h.generateToken(user)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not login after signup"})
 	}
@@ -76,7 +76,7 @@ func CreateUser(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func Login(c *fiber.Ctx) error {
+func (h *Handler) Login(c *fiber.Ctx) error {
 	var req dto.LoginRequest
 	if err := c.BodyParser(&req); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid input"})
@@ -91,7 +91,7 @@ func Login(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Invalid credentials"})
 	}
 
-	token, err := generateToken(user)
+	token, err := h.generateToken(user)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"status": "error", "message": "Could not generate token"})
 	}
@@ -108,23 +108,19 @@ func Login(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func generateToken(user models.User) (string, error) {
+func (h *Handler) generateToken(user models.User) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id":  user.ID,
 		"email":    user.Email,
 		"username": user.Username,
 		"role":     user.Role,
-		"exp":      time.Now().Add(time.Hour * 72).Unix(),
+		"exp":      time.Now().Add(h.cfg.Auth.TokenTTL).Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "secret"
-	}
-	return token.SignedString([]byte(secret))
+	return token.SignedString([]byte(h.cfg.Auth.JWTSecret))
 }
 
-func GetUsers(c *fiber.Ctx) error {
+func (h *Handler) GetUsers(c *fiber.Ctx) error {
 	var users []models.User
 	database.DB.Find(&users)
 
@@ -142,7 +138,7 @@ func GetUsers(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func GetUser(c *fiber.Ctx) error {
+func (h *Handler) GetUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var user models.User
 	result := database.DB.First(&user, "id = ?", id)
@@ -160,7 +156,7 @@ func GetUser(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func UpdateUser(c *fiber.Ctx) error {
+func (h *Handler) UpdateUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var user models.User
 	if err := database.DB.First(&user, "id = ?", id).Error; err != nil {
@@ -178,7 +174,11 @@ func UpdateUser(c *fiber.Ctx) error {
 	if req.Email != "" {
 		user.Email = req.Email
 	}
-	if req.Role != "" {
+	if req.Role != "" && req.Role != user.Role {
+		// Roles decide storage quotas, so only admins may change them
+		if !middleware.IsAdmin(c.Locals("user_id")) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Only admins can change roles"})
+		}
 		user.Role = req.Role
 	}
 	if req.Password != "" {
@@ -201,7 +201,7 @@ func UpdateUser(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"status": "success", "data": res})
 }
 
-func DeleteUser(c *fiber.Ctx) error {
+func (h *Handler) DeleteUser(c *fiber.Ctx) error {
 	id := c.Params("id")
 	if _, err := uuid.Parse(id); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"status": "error", "message": "Invalid ID format"})
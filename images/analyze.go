@@ -0,0 +1,211 @@
+package images
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// Analysis describes an image for placeholders shown while it loads
+type Analysis struct {
+	BlurHash      string   // https://blurha.sh, decoded by the client at any size
+	DominantColor string   // #RRGGBB
+	Palette       []string // Most common distinct colors, dominant first
+	AspectRatio   float64  // Width / height
+}
+
+const (
+	analyzeSampleSize = 64 // Pixels per side of the downscaled copy analysed
+	paletteSize       = 5
+	// Palette colors closer than this (RGB distance) count as the same color
+	paletteMinDistance = 48
+)
+
+// Analyze computes the placeholder data of an image. It works on a small
+// downscaled copy, so it is cheap even for large uploads. Transparent pixels
+// are left out of the palette and drawn over white for the BlurHash.
+func Analyze(img image.Image) Analysis {
+	b := img.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return Analysis{}
+	}
+	sample := image.NewNRGBA(image.Rect(0, 0, min(b.Dx(), analyzeSampleSize), min(b.Dy(), analyzeSampleSize)))
+	xdraw.CatmullRom.Scale(sample, sample.Bounds(), img, b, xdraw.Src, nil)
+
+	// Portrait covers get more vertical detail, landscape images horizontal
+	xComponents, yComponents := 4, 3
+	if b.Dy() > b.Dx() {
+		xComponents, yComponents = 3, 4
+	}
+	palette := dominantColors(sample, paletteSize)
+	a := Analysis{
+		BlurHash:    blurHash(sample, xComponents, yComponents),
+		Palette:     palette,
+		AspectRatio: math.Round(float64(b.Dx())/float64(b.Dy())*10000) / 10000,
+	}
+	if len(palette) > 0 {
+		a.DominantColor = palette[0]
+	}
+	return a
+}
+
+// dominantColors buckets the opaque pixels by color (4 bits per channel)
+// and returns the average color of the most populated buckets, skipping
+// buckets too close to one already picked
+func dominantColors(img *image.NRGBA, n int) []string {
+	type bucket struct {
+		r, g, b, count int
+	}
+	buckets := map[int]*bucket{}
+	for y := 0; y < img.Rect.Dy(); y++ {
+		for x := 0; x < img.Rect.Dx(); x++ {
+			p := img.Pix[y*img.Stride+x*4:]
+			if p[3] < 128 {
+				continue
+			}
+			key := int(p[0]>>4)<<8 | int(p[1]>>4)<<4 | int(p[2]>>4)
+			bk := buckets[key]
+			if bk == nil {
+				bk = &bucket{}
+				buckets[key] = bk
+			}
+			bk.r += int(p[0])
+			bk.g += int(p[1])
+			bk.b += int(p[2])
+			bk.count++
+		}
+	}
+
+	sorted := make([]*bucket, 0, len(buckets))
+	for _, bk := range buckets {
+		sorted = append(sorted, bk)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		// Deterministic order for ties
+		return sorted[i].r*65536+sorted[i].g*256+sorted[i].b < sorted[j].r*65536+sorted[j].g*256+sorted[j].b
+	})
+
+	var picked [][3]int
+	colors := []string{}
+	for _, bk := range sorted {
+		c := [3]int{bk.r / bk.count, bk.g / bk.count, bk.b / bk.count}
+		distinct := true
+		for _, p := range picked {
+			dr, dg, db := c[0]-p[0], c[1]-p[1], c[2]-p[2]
+			if dr*dr+dg*dg+db*db < paletteMinDistance*paletteMinDistance {
+				distinct = false
+				break
+			}
+		}
+		if !distinct {
+			continue
+		}
+		picked = append(picked, c)
+		colors = append(colors, fmt.Sprintf("#%02X%02X%02X", c[0], c[1], c[2]))
+		if len(colors) == n {
+			break
+		}
+	}
+	return colors
+}
+
+// blurHash encodes an image following the BlurHash algorithm: the first
+// xComponents x yComponents terms of its cosine transform in linear RGB,
+// quantised and written in base 83
+func blurHash(img *image.NRGBA, xComponents, yComponents int) string {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+	factors := make([][3]float64, 0, xComponents*yComponents)
+	for j := 0; j < yComponents; j++ {
+		for i := 0; i < xComponents; i++ {
+			normalisation := 2.0
+			if i == 0 && j == 0 {
+				normalisation = 1
+			}
+			var r, g, b float64
+			for y := 0; y < h; y++ {
+				for x := 0; x < w; x++ {
+					basis := math.Cos(math.Pi*float64(i)*float64(x)/float64(w)) * math.Cos(math.Pi*float64(j)*float64(y)/float64(h))
+					p := img.Pix[y*img.Stride+x*4:]
+					r += basis * sRGBToLinear(overWhite(p[0], p[3]))
+					g += basis * sRGBToLinear(overWhite(p[1], p[3]))
+					b += basis * sRGBToLinear(overWhite(p[2], p[3]))
+				}
+			}
+			scale := normalisation / float64(w*h)
+			factors = append(factors, [3]float64{r * scale, g * scale, b * scale})
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(base83((xComponents-1)+(yComponents-1)*9, 1))
+
+	maximumValue := 1.0
+	if len(factors) > 1 {
+		actualMaximum := 0.0
+		for _, f := range factors[1:] {
+			actualMaximum = math.Max(actualMaximum, math.Max(math.Abs(f[0]), math.Max(math.Abs(f[1]), math.Abs(f[2]))))
+		}
+		quantisedMaximum := clampInt(int(math.Floor(actualMaximum*166-0.5)), 0, 82)
+		maximumValue = float64(quantisedMaximum+1) / 166
+		sb.WriteString(base83(quantisedMaximum, 1))
+	} else {
+		sb.WriteString(base83(0, 1))
+	}
+
+	dc := factors[0]
+	sb.WriteString(base83(linearToSRGB(dc[0])<<16|linearToSRGB(dc[1])<<8|linearToSRGB(dc[2]), 4))
+	for _, f := range factors[1:] {
+		quant := func(v float64) int {
+			return clampInt(int(math.Floor(signPow(v/maximumValue, 0.5)*9+9.5)), 0, 18)
+		}
+		sb.WriteString(base83(quant(f[0])*19*19+quant(f[1])*19+quant(f[2]), 2))
+	}
+	return sb.String()
+}
+
+const base83Chars = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz#$%*+,-.:;=?@[]^_{|}~"
+
+func base83(value, length int) string {
+	out := make([]byte, length)
+	for i := 1; i <= length; i++ {
+		digit := (value / int(math.Pow(83, float64(length-i)))) % 83
+		out[i-1] = base83Chars[digit]
+	}
+	return string(out)
+}
+
+// overWhite composites a non-premultiplied channel over a white background
+func overWhite(c, alpha uint8) uint8 {
+	return uint8((int(c)*int(alpha) + 255*(255-int(alpha))) / 255)
+}
+
+func sRGBToLinear(c uint8) float64 {
+	v := float64(c) / 255
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) int {
+	v = math.Max(0, math.Min(1, v))
+	if v <= 0.0031308 {
+		return int(v*12.92*255 + 0.5)
+	}
+	return int((1.055*math.Pow(v, 1/2.4)-0.055)*255 + 0.5)
+}
+
+func signPow(v, exp float64) float64 {
+	return math.Copysign(math.Pow(math.Abs(v), exp), v)
+}
+
+func clampInt(v, lo, hi int) int {
+	return max(lo, min(hi, v))
+}
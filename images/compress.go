@@ -0,0 +1,47 @@
+package images
+
+import (
+	"backlog-backend/storage"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"strings"
+)
+
+// Precompressed copies live under precompressed/ as <name>.gz
+const precompressedPrefix = "precompressed/"
+
+// PrecompressedName is the storage name of the gzip copy of a stored file
+func PrecompressedName(name string) string {
+	return precompressedPrefix + name + ".gz"
+}
+
+// PrecompressedOriginal returns the file a gzip copy belongs to, and
+// whether name is a gzip copy at all
+func PrecompressedOriginal(name string) (string, bool) {
+	if !strings.HasPrefix(name, precompressedPrefix) || !strings.HasSuffix(name, ".gz") {
+		return "", false
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(name, precompressedPrefix), ".gz"), true
+}
+
+// SavePrecompressed stores a gzip copy of a file next to it when that is
+// worthwhile. JPEGs are already compressed, so only PNGs get a gzip copy,
+// and only when it is noticeably smaller.
+func SavePrecompressed(ctx context.Context, store storage.Storage, name string, data []byte, contentType string) error {
+	if contentType != "image/png" {
+		return nil
+	}
+	var buf bytes.Buffer
+	zw, _ := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	if buf.Len() > len(data)*9/10 {
+		return nil // Saves less than 10%, not worth a second file
+	}
+	return store.Put(ctx, PrecompressedName(name), &buf, int64(buf.Len()), "application/gzip")
+}
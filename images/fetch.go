@@ -0,0 +1,175 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Errors returned by Fetch besides the validation errors of Process
+var (
+	ErrFetchBlocked = errors.New("remote URL is not allowed")
+	ErrFetchFailed  = errors.New("could not download the remote image")
+)
+
+const (
+	fetchTimeout      = 10 * time.Second
+	fetchMaxRedirects = 3
+)
+
+// fetchTransport is shared by every Fetcher so connections are pooled
+var fetchTransport = &http.Transport{
+	Proxy: nil, // A proxy would resolve the host itself and bypass the IP check
+	DialContext: (&net.Dialer{
+		Timeout: 5 * time.Second,
+		// Checked on the resolved address of every connection, so neither
+		// redirects nor DNS rebinding can reach a private network
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w: %s is not a public address", ErrFetchBlocked, host)
+			}
+			return nil
+		},
+	}).DialContext,
+	TLSHandshakeTimeout:   5 * time.Second,
+	ResponseHeaderTimeout: fetchTimeout,
+	MaxIdleConns:          10,
+	IdleConnTimeout:       30 * time.Second,
+}
+
+// Fetcher downloads remote images. Only public http(s) hosts on the default
+// ports are reached, optionally restricted to a list of allowed hosts.
+type Fetcher struct {
+	allowedHosts []string
+	client       *http.Client
+}
+
+// NewFetcher returns a Fetcher limited to allowedHosts, lowercase hosts where
+// an entry "*.example.com" also allows its subdomains. Empty allows any
+// public host.
+func NewFetcher(allowedHosts []string) *Fetcher {
+	f := &Fetcher{allowedHosts: allowedHosts}
+	f.client = &http.Client{
+		Timeout:   fetchTimeout,
+		Transport: fetchTransport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) > fetchMaxRedirects {
+				return fmt.Errorf("%w: too many redirects", ErrFetchBlocked)
+			}
+			return checkFetchURL(req.URL, f.allowedHosts)
+		},
+	}
+	return f
+}
+
+// publicIP rejects loopback, private, link-local (cloud metadata),
+// multicast and unspecified addresses
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsMulticast() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return false
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		// Carrier-grade NAT 100.64.0.0/10 and 0.0.0.0/8
+		if (ip4[0] == 100 && ip4[1]&0xC0 == 64) || ip4[0] == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// checkFetchURL validates the scheme, port and host of a URL before any
+// connection is made
+func checkFetchURL(u *url.URL, allowedHosts []string) error {
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: only http and https URLs can be fetched", ErrFetchBlocked)
+	}
+	if u.User != nil {
+		return fmt.Errorf("%w: URLs with credentials are not allowed", ErrFetchBlocked)
+	}
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		return fmt.Errorf("%w: only the default ports are allowed", ErrFetchBlocked)
+	}
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return fmt.Errorf("%w: missing host", ErrFetchBlocked)
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrFetchBlocked, host)
+	}
+	if len(allowedHosts) == 0 {
+		return nil
+	}
+	for _, allowed := range allowedHosts {
+		if host == allowed || (strings.HasPrefix(allowed, "*.") && strings.HasSuffix(host, allowed[1:])) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s is not in the allowed hosts", ErrFetchBlocked, host)
+}
+
+// Download fetches the raw bytes at a remote URL with the SSRF protections
+// of Fetch, without decoding them
+func (f *Fetcher) Download(ctx context.Context, rawURL string, maxBytes int64) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid URL", ErrFetchBlocked)
+	}
+	if err := checkFetchURL(u, f.allowedHosts); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, fetchTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid URL", ErrFetchBlocked)
+	}
+	req.Header.Set("Accept", "image/*")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		if errors.Is(err, ErrFetchBlocked) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrFetchFailed, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: remote server answered %s", ErrFetchFailed, resp.Status)
+	}
+	if resp.ContentLength > maxBytes {
+		return nil, ErrTooLarge
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFetchFailed, err)
+	}
+	if int64(len(data)) > maxBytes {
+		return nil, ErrTooLarge
+	}
+	return data, nil
+}
+
+// Fetch downloads an image from a remote URL and runs it through the same
+// validation and re-encoding as an upload. Redirects are limited and
+// re-checked, and the download is capped in size and time.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string, limits Limits) (*Result, error) {
+	data, err := f.Download(ctx, rawURL, limits.MaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	return ProcessBytes(data, limits)
+}
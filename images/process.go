@@ -0,0 +1,141 @@
+// Package images validates uploaded images and turns them into the
+// canonical files the API stores and serves.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+
+	_ "image/gif"
+
+	_ "golang.org/x/image/webp"
+)
+
+// Errors returned by Process. Size errors map to 413, the others to 415.
+var (
+	ErrTooLarge        = errors.New("image exceeds the maximum file size")
+	ErrTooManyPixels   = errors.New("image dimensions exceed the maximum")
+	ErrUnsupportedType = errors.New("unsupported image type, allowed: jpeg, png, gif, webp")
+	ErrInvalidImage    = errors.New("file is not a valid image")
+)
+
+const (
+	MaxUploadBytes = 10 << 20
+	MaxPixels      = 40_000_000 // About 6300x6300; decoding allocates 4 bytes per pixel
+	MaxDimension   = 10_000
+
+	jpegQuality = 90
+)
+
+type Limits struct {
+	MaxBytes     int64
+	MaxPixels    int
+	MaxDimension int
+}
+
+var DefaultLimits = Limits{MaxBytes: MaxUploadBytes, MaxPixels: MaxPixels, MaxDimension: MaxDimension}
+
+// Result is a validated image re-encoded without metadata
+type Result struct {
+	Data        []byte
+	Ext         string // ".jpg" or ".png"
+	ContentType string
+	Width       int
+	Height      int
+	Image       image.Image // Decoded pixels, already upright
+}
+
+// Sniff identifies the image format from its magic bytes
+func Sniff(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8, 0xFF}):
+		return "jpeg"
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return "png"
+	case bytes.HasPrefix(data, []byte("GIF87a")), bytes.HasPrefix(data, []byte("GIF89a")):
+		return "gif"
+	case len(data) >= 12 && bytes.Equal(data[0:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return "webp"
+	}
+	return ""
+}
+
+// Process reads an upload, checks that it really is an image within the
+// limits, and re-encodes it. Re-encoding drops EXIF/GPS and any other
+// metadata; the EXIF orientation of JPEGs is applied to the pixels first.
+// Images with transparency become PNG, everything else JPEG. Animated GIFs
+// keep their first frame.
+func Process(r io.Reader, limits Limits) (*Result, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limits.MaxBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	return ProcessBytes(data, limits)
+}
+
+// ProcessBytes is Process for data already in memory
+func ProcessBytes(data []byte, limits Limits) (*Result, error) {
+	if int64(len(data)) > limits.MaxBytes {
+		return nil, ErrTooLarge
+	}
+	img, err := Decode(data, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Image: img, Width: img.Bounds().Dx(), Height: img.Bounds().Dy()}
+	res.Data, res.Ext, res.ContentType, err = Encode(img)
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// Decode sniffs and decodes an image, checking its dimensions from the
+// header before any pixels are allocated (decompression-bomb protection)
+func Decode(data []byte, limits Limits) (image.Image, error) {
+	format := Sniff(data)
+	if format == "" {
+		return nil, ErrUnsupportedType
+	}
+	cfg, decoded, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || decoded != format {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrInvalidImage
+	}
+	if cfg.Width > limits.MaxDimension || cfg.Height > limits.MaxDimension || cfg.Width*cfg.Height > limits.MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if format == "jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, nil
+}
+
+func isOpaque(img image.Image) bool {
+	if o, ok := img.(interface{ Opaque() bool }); ok {
+		return o.Opaque()
+	}
+	return false
+}
+
+// toNRGBA copies any image into an NRGBA with bounds starting at 0,0
+func toNRGBA(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, b.Min, draw.Src)
+	return dst
+}
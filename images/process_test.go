@@ -0,0 +1,222 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func testImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{uint8(x * 255 / w), uint8(y * 255 / h), 128, 255})
+		}
+	}
+	return img
+}
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// withPNGSize rewrites the dimensions in a PNG header, as a decompression
+// bomb would claim them
+func withPNGSize(data []byte, w, h uint32) []byte {
+	out := append([]byte(nil), data...)
+	ihdr := out[8+8 : 8+8+13] // Signature, chunk length and type, then the IHDR data
+	binary.BigEndian.PutUint32(ihdr[0:], w)
+	binary.BigEndian.PutUint32(ihdr[4:], h)
+	binary.BigEndian.PutUint32(out[8+8+13:], crc32.ChecksumIEEE(out[8+4:8+8+13]))
+	return out
+}
+
+// withOrientation inserts an EXIF APP1 segment holding an orientation tag
+// after the SOI marker of a JPEG
+func withOrientation(data []byte, order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)       // IFD0 offset
+	order.PutUint16(tiff[8:], 1)       // One entry
+	order.PutUint16(tiff[10:], 0x0112) // Orientation
+	order.PutUint16(tiff[12:], 3)      // SHORT
+	order.PutUint32(tiff[14:], 1)
+	order.PutUint16(tiff[18:], orientation)
+
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	app1 := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(app1[2:], uint16(len(segment)+2))
+	app1 = append(app1, segment...)
+
+	out := append([]byte(nil), data[:2]...)
+	out = append(out, app1...)
+	return append(out, data[2:]...)
+}
+
+func TestSniff(t *testing.T) {
+	var gifData bytes.Buffer
+	gif.Encode(&gifData, testImage(2, 2), nil)
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"jpeg", encodeJPEG(t, testImage(2, 2)), "jpeg"},
+		{"png", encodePNG(t, testImage(2, 2)), "png"},
+		{"gif89a", gifData.Bytes(), "gif"},
+		{"gif87a", []byte("GIF87a......"), "gif"},
+		{"webp", []byte("RIFF\x10\x00\x00\x00WEBPVP8 "), "webp"},
+		{"riff that is not webp", []byte("RIFF\x10\x00\x00\x00WAVEfmt "), ""},
+		{"truncated riff", []byte("RIFF\x10\x00"), ""},
+		{"svg", []byte(`<svg xmlns="http://www.w3.org/2000/svg"></svg>`), ""},
+		{"empty", nil, ""},
+	}
+	for _, tt := range tests {
+		if got := Sniff(tt.data); got != tt.want {
+			t.Errorf("%s: Sniff() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeLimits(t *testing.T) {
+	small := encodePNG(t, testImage(20, 10))
+	limits := Limits{MaxBytes: MaxUploadBytes, MaxPixels: 1000, MaxDimension: 100}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		limits  Limits
+		wantErr error
+	}{
+		{"within limits", small, limits, nil},
+		{"too wide", small, Limits{MaxBytes: MaxUploadBytes, MaxPixels: 1000, MaxDimension: 15}, ErrTooManyPixels},
+		{"too many pixels", small, Limits{MaxBytes: MaxUploadBytes, MaxPixels: 199, MaxDimension: 100}, ErrTooManyPixels},
+		{"bomb header", withPNGSize(small, 50_000, 50_000), DefaultLimits, ErrTooManyPixels},
+		{"zero size header", withPNGSize(small, 0, 10), DefaultLimits, ErrInvalidImage},
+		{"truncated", small[:len(small)/2], limits, ErrInvalidImage},
+		{"magic bytes only", []byte("\x89PNG\r\n\x1a\ngarbage"), limits, ErrInvalidImage},
+		{"jpeg magic on a png", append([]byte{0xFF, 0xD8, 0xFF}, small...), limits, ErrInvalidImage},
+		{"not an image", []byte("hello"), limits, ErrUnsupportedType},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := Decode(tt.data, tt.limits)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Decode() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && (img.Bounds().Dx() != 20 || img.Bounds().Dy() != 10) {
+				t.Errorf("Decode() bounds = %v", img.Bounds())
+			}
+		})
+	}
+}
+
+func TestProcessBytesTooLarge(t *testing.T) {
+	data := encodePNG(t, testImage(20, 10))
+	_, err := ProcessBytes(data, Limits{MaxBytes: int64(len(data) - 1), MaxPixels: MaxPixels, MaxDimension: MaxDimension})
+	if !errors.Is(err, ErrTooLarge) {
+		t.Fatalf("ProcessBytes() error = %v, want %v", err, ErrTooLarge)
+	}
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeJPEG(t, testImage(4, 2))
+	truncated := withOrientation(plain, binary.LittleEndian, 6)[:20]
+
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", plain, 1},
+		{"little endian", withOrientation(plain, binary.LittleEndian, 6), 6},
+		{"big endian", withOrientation(plain, binary.BigEndian, 8), 8},
+		{"upright", withOrientation(plain, binary.BigEndian, 1), 1},
+		{"out of range", withOrientation(plain, binary.LittleEndian, 9), 1},
+		{"truncated segment", truncated, 1},
+		{"not a jpeg", encodePNG(t, testImage(4, 2)), 1},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation() = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDecodeAppliesOrientation(t *testing.T) {
+	plain := encodeJPEG(t, testImage(4, 2))
+	for orientation, want := range map[uint16]image.Point{1: {4, 2}, 3: {4, 2}, 6: {2, 4}, 8: {2, 4}} {
+		img, err := Decode(withOrientation(plain, binary.BigEndian, orientation), DefaultLimits)
+		if err != nil {
+			t.Fatalf("orientation %d: %v", orientation, err)
+		}
+		if got := img.Bounds().Size(); got != want {
+			t.Errorf("orientation %d: size %v, want %v", orientation, got, want)
+		}
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// A red pixel left of a blue one, as stored with each orientation tag
+	red, blue := color.NRGBA{255, 0, 0, 255}, color.NRGBA{0, 0, 255, 255}
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 1))
+	src.Set(0, 0, red)
+	src.Set(1, 0, blue)
+	name := map[color.Color]string{red: "red", blue: "blue"}
+
+	tests := []struct {
+		orientation int
+		want        string // Pixels of the upright image, row by row
+	}{
+		{1, "red blue"},
+		{2, "blue red"},
+		{3, "blue red"},
+		{4, "red blue"},
+		{5, "red / blue"},
+		{6, "red / blue"},
+		{7, "blue / red"},
+		{8, "blue / red"},
+	}
+	for _, tt := range tests {
+		dst := applyOrientation(src, tt.orientation)
+		var rows []string
+		for y := 0; y < dst.Bounds().Dy(); y++ {
+			var row []string
+			for x := 0; x < dst.Bounds().Dx(); x++ {
+				row = append(row, name[color.NRGBAModel.Convert(dst.At(x, y))])
+			}
+			rows = append(rows, strings.Join(row, " "))
+		}
+		if got := strings.Join(rows, " / "); got != tt.want {
+			t.Errorf("orientation %d: %s, want %s", tt.orientation, got, tt.want)
+		}
+	}
+}
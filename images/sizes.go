@@ -0,0 +1,383 @@
+package images
+
+import (
+	"backlog-backend/storage"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/HugoSmits86/nativewebp"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Size is a derived width generated for every upload. Heights follow the
+// aspect ratio and images are never upscaled.
+type Size struct {
+	Name  string
+	Width int
+}
+
+var Sizes = []Size{
+	{Name: "thumb", Width: 200},
+	{Name: "card", Width: 480},
+	{Name: "full", Width: 1280},
+}
+
+// Fit modes for Resize
+const (
+	FitInside = "inside" // Scale down to fit within the box, keeping the aspect ratio
+	FitCover  = "cover"  // Fill the box exactly, cropping the overflow
+)
+
+// ResizeSteps are the only dimensions on-demand resizes are rendered at.
+// Requested sizes are rounded up to the next step, so an image has at most
+// a few hundred cached resizes whatever sizes clients ask for.
+var ResizeSteps = []int{64, 128, 256, 480, 640, 960, 1280, 1920}
+
+// MaxResizeDimension is the largest dimension a resize can ask for
+const MaxResizeDimension = 1920
+
+// ErrResizeBusy is returned by Resized when no render slot frees up in time
+var ErrResizeBusy = errors.New("too many images are being resized, try again later")
+
+// Renders are CPU and memory heavy, so only a few run at once; the others
+// wait up to resizeWait for a slot
+var (
+	resizeSlots = make(chan struct{}, max(runtime.NumCPU()/2, 1))
+	resizeWait  = 10 * time.Second
+)
+
+// SnapResizeBox rounds a requested box up to the resize steps. Zero, which
+// follows the aspect ratio, is kept. A cover box keeps its aspect ratio: the
+// long side is snapped and the short side rounded up to a sixteenth of it.
+func SnapResizeBox(w, h int, fit string) (int, int) {
+	if fit != FitCover || w <= 0 || h <= 0 {
+		return snapResizeDimension(w), snapResizeDimension(h)
+	}
+	long, short := w, h
+	if h > w {
+		long, short = h, w
+	}
+	snapped := snapResizeDimension(long)
+	unit := snapped / 16
+	short = min((short*snapped/long+unit-1)/unit*unit, snapped)
+	short = max(short, unit)
+	if h > w {
+		return short, snapped
+	}
+	return snapped, short
+}
+
+func snapResizeDimension(d int) int {
+	if d <= 0 {
+		return 0
+	}
+	for _, step := range ResizeSteps {
+		if d <= step {
+			return step
+		}
+	}
+	return ResizeSteps[len(ResizeSteps)-1]
+}
+
+// Variant is one derived size of an image
+type Variant struct {
+	Size        string
+	Name        string // Storage name
+	Width       int
+	Height      int
+	ContentType string
+	Data        []byte
+}
+
+// VariantName is the file name of a derived size: <stem>.<size><ext>.
+// Upload names never contain a dot before the extension, so the extra
+// segment tells variants apart from originals.
+func VariantName(name, size, ext string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name)) + "." + size + ext
+}
+
+// IsVariant reports whether a storage name is a derived size
+func IsVariant(name string) bool {
+	return strings.Contains(strings.TrimSuffix(name, filepath.Ext(name)), ".")
+}
+
+// VariantSize returns the size of a derived name, e.g. "thumb"
+func VariantSize(name string) string {
+	s := stem(name)
+	return s[strings.LastIndex(s, ".")+1:]
+}
+
+// EncodeWebP writes an image as lossless WebP, the only kind the encoder
+// produces. It is several times smaller than PNG, but often ten times the
+// size of a JPEG of a photo.
+func EncodeWebP(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := nativewebp.Encode(&buf, img, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Encode writes an image as JPEG when it is opaque and PNG otherwise
+func Encode(img image.Image) (data []byte, ext, contentType string, err error) {
+	var buf bytes.Buffer
+	if isOpaque(img) {
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		ext, contentType = ".jpg", "image/jpeg"
+	} else {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(&buf, img)
+		ext, contentType = ".png", "image/png"
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), ext, contentType, nil
+}
+
+// Resize scales an image into a w x h box. A zero dimension follows the
+// aspect ratio. Images are never upscaled.
+func Resize(img image.Image, w, h int, fit string) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if w <= 0 && h <= 0 {
+		return img
+	}
+	derived := w <= 0 || h <= 0 // The box already has the source's aspect ratio
+	if w <= 0 {
+		w = sw * h / sh
+	}
+	if h <= 0 {
+		h = sh * w / sw
+	}
+
+	src := b
+	if fit == FitCover {
+		// Crop the source to the box's aspect ratio, then scale
+		if sw*h > sh*w {
+			cw := sh * w / h
+			off := (sw - cw) / 2
+			src = image.Rect(b.Min.X+off, b.Min.Y, b.Min.X+off+cw, b.Max.Y)
+		} else {
+			ch := sw * h / w
+			off := (sh - ch) / 2
+			src = image.Rect(b.Min.X, b.Min.Y+off, b.Max.X, b.Min.Y+off+ch)
+		}
+		if w > src.Dx() || h > src.Dy() {
+			w, h = src.Dx(), src.Dy()
+		}
+	} else {
+		// Largest size with the source's aspect ratio that fits the box
+		if !derived && sw*h > sh*w {
+			h = sh * w / sw
+		} else if !derived {
+			w = sw * h / sh
+		}
+		if w > sw || h > sh {
+			w, h = sw, sh
+		}
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	if src == b && w == sw && h == sh {
+		return img
+	}
+
+	var dst xdraw.Image
+	if isOpaque(img) {
+		dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	} else {
+		dst = image.NewNRGBA(image.Rect(0, 0, w, h))
+	}
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src, xdraw.Src, nil)
+	return dst
+}
+
+// HasWebPVariants reports whether the sizes of an image stored with ext get
+// WebP copies. Only PNG sizes do: lossless WebP is much smaller than PNG,
+// while for JPEG photos it would be far larger and a lossy WebP encoder
+// needs libwebp.
+func HasWebPVariants(ext string) bool {
+	return ext == ".png"
+}
+
+// VariantCount is the number of derived files of an image stored with ext
+func VariantCount(ext string) int {
+	if HasWebPVariants(ext) {
+		return 2 * len(Sizes)
+	}
+	return len(Sizes)
+}
+
+// Variants renders every configured size of an image as JPEG, or as PNG
+// followed by the same size as WebP when the image has transparency
+func Variants(name string, img image.Image) ([]Variant, error) {
+	variants := make([]Variant, 0, 2*len(Sizes))
+	for _, s := range Sizes {
+		resized := Resize(img, s.Width, 0, FitInside)
+		data, ext, contentType, err := Encode(resized)
+		if err != nil {
+			return nil, err
+		}
+		w, h := resized.Bounds().Dx(), resized.Bounds().Dy()
+		variants = append(variants, Variant{Size: s.Name, Name: VariantName(name, s.Name, ext), Width: w, Height: h, ContentType: contentType, Data: data})
+		if !HasWebPVariants(ext) {
+			continue
+		}
+		webp, err := EncodeWebP(resized)
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, Variant{Size: s.Name, Name: VariantName(name, s.Name, ".webp"), Width: w, Height: h, ContentType: "image/webp", Data: webp})
+	}
+	return variants, nil
+}
+
+// SaveVariants renders every size of an image stored as name and writes
+// them next to it, with gzip copies when precompress is set
+func SaveVariants(ctx context.Context, store storage.Storage, name string, img image.Image, precompress bool) ([]Variant, error) {
+	variants, err := Variants(name, img)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range variants {
+		if err := store.Put(ctx, v.Name, bytes.NewReader(v.Data), int64(len(v.Data)), v.ContentType); err != nil {
+			return nil, err
+		}
+		if !precompress {
+			continue
+		}
+		if err := SavePrecompressed(ctx, store, v.Name, v.Data, v.ContentType); err != nil {
+			return nil, err
+		}
+	}
+	return variants, nil
+}
+
+// Load reads and decodes a stored image
+func Load(ctx context.Context, store storage.Storage, name string) (image.Image, error) {
+	r, _, err := store.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, MaxUploadBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	return Decode(data, DefaultLimits)
+}
+
+// Related lists the derived sizes stored for an image, excluding the
+// original itself
+func Related(ctx context.Context, store storage.Storage, name string) ([]storage.Object, error) {
+	objects, err := store.List(ctx, stem(name)+".")
+	if err != nil {
+		return nil, err
+	}
+	related := objects[:0]
+	for _, o := range objects {
+		if o.Name != name && IsVariant(o.Name) {
+			related = append(related, o)
+		}
+	}
+	return related, nil
+}
+
+// Remove deletes an image together with its derived sizes, cached resizes
+// and precompressed copies. It returns storage.ErrNotFound when the image
+// does not exist.
+func Remove(ctx context.Context, store storage.Storage, name string) error {
+	derived, _ := Related(ctx, store, name)
+	cached, _ := store.List(ctx, resizedPrefix(name))
+	compressed, _ := store.List(ctx, precompressedPrefix+stem(name)+".")
+	for _, o := range append(append(derived, cached...), compressed...) {
+		store.Delete(ctx, o.Name)
+	}
+	return store.Delete(ctx, name)
+}
+
+// StoredSize is the space an image takes in the storage: the original with
+// its derived sizes, cached resizes and precompressed copies
+func StoredSize(ctx context.Context, store storage.Storage, name string) (int64, error) {
+	obj, err := store.Stat(ctx, name)
+	if err != nil {
+		return 0, err
+	}
+	size := obj.Size
+	for _, prefix := range []string{stem(name) + ".", resizedPrefix(name), precompressedPrefix + stem(name) + "."} {
+		objects, err := store.List(ctx, prefix)
+		if err != nil {
+			return 0, err
+		}
+		for _, o := range objects {
+			if o.Name != name {
+				size += o.Size
+			}
+		}
+	}
+	return size, nil
+}
+
+func stem(name string) string {
+	return strings.TrimSuffix(name, filepath.Ext(name))
+}
+
+// Resizes live under resized/ as <stem>_<w>x<h>_<fit>.<ext>
+func resizedPrefix(name string) string {
+	return "resized/" + stem(name) + "_"
+}
+
+// Resized returns the storage name of a resize of the image stored as name,
+// rendering and storing it on first use. The box is snapped to the resize
+// steps first, and only a few renders run at once. added is the size of the
+// file stored by this call, zero when the resize was already cached.
+func Resized(ctx context.Context, store storage.Storage, name string, w, h int, fit string) (resized string, added int64, err error) {
+	w, h = SnapResizeBox(w, h, fit)
+	prefix := fmt.Sprintf("%s%dx%d_%s.", resizedPrefix(name), w, h, fit)
+	if cached, err := store.List(ctx, prefix); err == nil && len(cached) > 0 {
+		return cached[0].Name, 0, nil
+	}
+
+	timer := time.NewTimer(resizeWait)
+	defer timer.Stop()
+	select {
+	case resizeSlots <- struct{}{}:
+		defer func() { <-resizeSlots }()
+	case <-timer.C:
+		return "", 0, ErrResizeBusy
+	case <-ctx.Done():
+		return "", 0, ctx.Err()
+	}
+	// Another request may have rendered it while this one waited
+	if cached, err := store.List(ctx, prefix); err == nil && len(cached) > 0 {
+		return cached[0].Name, 0, nil
+	}
+
+	img, err := Load(ctx, store, name)
+	if err != nil {
+		return "", 0, err
+	}
+	out, ext, contentType, err := Encode(Resize(img, w, h, fit))
+	if err != nil {
+		return "", 0, err
+	}
+	resized = prefix + strings.TrimPrefix(ext, ".")
+	if err := store.Put(ctx, resized, bytes.NewReader(out), int64(len(out)), contentType); err != nil {
+		return "", 0, err
+	}
+	return resized, int64(len(out)), nil
+}
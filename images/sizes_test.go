@@ -0,0 +1,160 @@
+package images
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+)
+
+func TestResize(t *testing.T) {
+	src := testImage(400, 200)
+
+	tests := []struct {
+		name string
+		w, h int
+		fit  string
+		want image.Point
+	}{
+		{"width only", 100, 0, FitInside, image.Pt(100, 50)},
+		{"height only", 0, 100, FitInside, image.Pt(200, 100)},
+		{"inside a square", 100, 100, FitInside, image.Pt(100, 50)},
+		{"inside a tall box", 100, 400, FitInside, image.Pt(100, 50)},
+		{"never upscaled", 1000, 1000, FitInside, image.Pt(400, 200)},
+		{"never below one pixel", 1, 0, FitInside, image.Pt(1, 1)},
+		{"cover a square", 100, 100, FitCover, image.Pt(100, 100)},
+		{"cover a tall box", 50, 100, FitCover, image.Pt(50, 100)},
+		{"cover a box wider than the source", 1000, 100, FitCover, image.Pt(400, 40)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Resize(src, tt.w, tt.h, tt.fit).Bounds().Size(); got != tt.want {
+				t.Errorf("Resize(%d, %d, %s) = %v, want %v", tt.w, tt.h, tt.fit, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResizeReturnsTheSourceWhenNothingChanges(t *testing.T) {
+	src := testImage(400, 200)
+	for _, box := range [][2]int{{0, 0}, {400, 0}, {400, 200}, {800, 800}} {
+		if got := Resize(src, box[0], box[1], FitInside); got != image.Image(src) {
+			t.Errorf("Resize(%d, %d) copied the image", box[0], box[1])
+		}
+	}
+}
+
+func TestResizeCoverCropsTheCenter(t *testing.T) {
+	// Red, green and blue thirds; a square crop keeps the green one
+	src := image.NewNRGBA(image.Rect(0, 0, 300, 100))
+	thirds := []color.NRGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}}
+	for y := 0; y < 100; y++ {
+		for x := 0; x < 300; x++ {
+			src.Set(x, y, thirds[x/100])
+		}
+	}
+	dst := Resize(src, 50, 50, FitCover)
+	for _, p := range []image.Point{{0, 0}, {25, 25}, {49, 49}} {
+		r, g, b, _ := dst.At(p.X, p.Y).RGBA()
+		if g>>8 < 200 || r>>8 > 50 || b>>8 > 50 {
+			t.Errorf("pixel %v = %v, want green", p, dst.At(p.X, p.Y))
+		}
+	}
+}
+
+func TestResizeKeepsTransparency(t *testing.T) {
+	opaque := testImage(40, 20)
+	transparent := testImage(40, 20)
+	transparent.Set(0, 0, color.NRGBA{0, 0, 0, 0})
+
+	if _, ok := Resize(opaque, 20, 0, FitInside).(*image.RGBA); !ok {
+		t.Error("opaque resize should be RGBA")
+	}
+	if _, ok := Resize(transparent, 20, 0, FitInside).(*image.NRGBA); !ok {
+		t.Error("transparent resize should keep its alpha as NRGBA")
+	}
+}
+
+func TestSnapResizeBox(t *testing.T) {
+	tests := []struct {
+		w, h         int
+		fit          string
+		wantW, wantH int
+	}{
+		{0, 0, FitInside, 0, 0},
+		{100, 0, FitInside, 128, 0},
+		{0, 1, FitInside, 0, 64},
+		{5000, 0, FitInside, 1920, 0},
+		{300, 200, FitInside, 480, 256},
+		{100, 0, FitCover, 128, 0},
+		{100, 100, FitCover, 128, 128},
+		{300, 200, FitCover, 480, 330},
+		{200, 300, FitCover, 330, 480},
+		{4000, 2000, FitCover, 1920, 960},
+		{1000, 10, FitCover, 1280, 80},
+	}
+	for _, tt := range tests {
+		if w, h := SnapResizeBox(tt.w, tt.h, tt.fit); w != tt.wantW || h != tt.wantH {
+			t.Errorf("SnapResizeBox(%d, %d, %s) = %d, %d, want %d, %d", tt.w, tt.h, tt.fit, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestSnapResizeBoxCoverKeepsTheAspectRatio(t *testing.T) {
+	for w := 1; w <= MaxResizeDimension; w += 37 {
+		for h := 1; h <= MaxResizeDimension; h += 41 {
+			sw, sh := SnapResizeBox(w, h, FitCover)
+			long, short := max(sw, sh), min(sw, sh)
+			unit := long / 16
+			if !slices.Contains(ResizeSteps, long) || short%unit != 0 {
+				t.Fatalf("SnapResizeBox(%d, %d) = %d, %d, not on the steps", w, h, sw, sh)
+			}
+			// The short side is scaled with the long one, then rounded up
+			// by less than a sixteenth of it
+			scaled := float64(min(w, h)) * float64(long) / float64(max(w, h))
+			if extra := float64(short) - scaled; extra < -1 || (extra >= float64(unit) && short != unit) {
+				t.Fatalf("SnapResizeBox(%d, %d) = %d, %d, short side %.1f before rounding", w, h, sw, sh, scaled)
+			}
+		}
+	}
+}
+
+func TestVariants(t *testing.T) {
+	opaque := testImage(600, 300)
+	transparent := testImage(600, 300)
+	transparent.Set(0, 0, color.NRGBA{0, 0, 0, 0})
+
+	jpegs, err := Variants("cover.jpg", opaque)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jpegs) != VariantCount(".jpg") {
+		t.Fatalf("got %d variants of a JPEG, want %d", len(jpegs), VariantCount(".jpg"))
+	}
+	for _, v := range jpegs {
+		if v.ContentType != "image/jpeg" || v.Name != VariantName("cover.jpg", v.Size, ".jpg") {
+			t.Errorf("variant %s is %s", v.Name, v.ContentType)
+		}
+	}
+
+	pngs, err := Variants("cover.png", transparent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pngs) != VariantCount(".png") {
+		t.Fatalf("got %d variants of a PNG, want %d", len(pngs), VariantCount(".png"))
+	}
+	for i := 0; i < len(pngs); i += 2 {
+		png, webp := pngs[i], pngs[i+1]
+		if png.ContentType != "image/png" || webp.ContentType != "image/webp" || webp.Size != png.Size {
+			t.Fatalf("variants %s (%s) and %s (%s) are not a PNG and its WebP copy", png.Name, png.ContentType, webp.Name, webp.ContentType)
+		}
+		img, err := Decode(webp.Data, DefaultLimits)
+		if err != nil {
+			t.Fatalf("%s: %v", webp.Name, err)
+		}
+		if got := img.Bounds().Size(); got != image.Pt(png.Width, png.Height) {
+			t.Errorf("%s is %v, its PNG %dx%d", webp.Name, got, png.Width, png.Height)
+		}
+	}
+}
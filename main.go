@@ -4,53 +4,71 @@ import (
 	"log"
 	"os"
 
+	"backlog-backend/config"
 	"backlog-backend/database"
+	"backlog-backend/handlers"
+	"backlog-backend/images"
+	"backlog-backend/middleware"
 	"backlog-backend/routes"
+	"backlog-backend/storage"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
-	"github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/joho/godotenv"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, using default/env vars")
+	// Configuration from the environment, .env and config.yaml/config.toml
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+
+	// Image storage, local directory or S3-compatible bucket
+	store, err := storage.FromConfig(cfg.Storage)
+	if err != nil {
+		log.Fatalf("Error configuring image storage: %v", err)
+	}
+	h := handlers.New(cfg, store)
+
+	// Admin commands run instead of the server, e.g. `go run . backfill-images`
+	if len(os.Args) > 1 {
+		if err := runCommand(commandEnv{cfg: cfg, store: store, h: h}, os.Args[1], os.Args[2:]); err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
 
 	// Connect to Database
-	database.Connect()
+	database.Connect(cfg.Database)
+
+	// Remove uploads no game ended up using
+	h.StartImageGC()
+
+	// Relay live tier list edits between instances
+	h.StartLiveSync()
 
 	// Initialize Fiber app
-	app := fiber.New()
+	app := fiber.New(fiber.Config{
+		ErrorHandler: handlers.ErrorHandler,
+		BodyLimit:    images.MaxUploadBytes + 1<<20, // Room for the multipart envelope around an upload
+	})
 
 	// CORS Middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins: "*",
 		AllowHeaders: "Origin, Content-Type, Accept, Authorization",
 	}))
-	app.Use(logger.New())
-
-	// Ensure images directory exists
-	if _, err := os.Stat("./images"); os.IsNotExist(err) {
-		if err := os.Mkdir("./images", 0755); err != nil {
-			log.Printf("Warning: Could not create images directory: %v", err)
-		}
-	}
-	app.Static("/images", "./images")
+	app.Use(middleware.Logger())
+	// Panics become 500s through ErrorHandler instead of killing the server
+	app.Use(recover.New())
 
 	// Setup Routes
-	routes.SetupRoutes(app)
+	routes.SetupRoutes(app, cfg, h)
 
 	// Start Server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
-	}
-
-	log.Printf("Server starting on port %s", port)
-	if err := app.Listen(":" + port); err != nil {
+	log.Printf("Server starting on port %s", cfg.Port)
+	if err := app.Listen(":" + cfg.Port); err != nil {
 		log.Fatalf("Error starting server: %v", err)
 	}
 }
@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"backlog-backend/database"
+	"backlog-backend/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RoleAdmin is the role allowed through AdminOnly
+const RoleAdmin = "admin"
+
+// IsAdmin reports whether the user currently has the admin role. The role is
+// read from the database rather than the token, so a demoted admin loses
+// access without waiting for their token to expire.
+func IsAdmin(userID any) bool {
+	var user models.User
+	if err := database.DB.Select("role").First(&user, "id = ?", userID).Error; err != nil {
+		return false
+	}
+	return user.Role == RoleAdmin
+}
+
+// AdminOnly restricts a route to admins. It runs after Protected.
+func AdminOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !IsAdmin(c.Locals("user_id")) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"status": "error", "message": "Admin access required"})
+		}
+		return c.Next()
+	}
+}
@@ -1,7 +1,6 @@
 package middleware
 
 import (
-	"os"
 	"strings"
 	"time"
 
@@ -9,18 +8,20 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func Protected() fiber.Handler {
+// Protected accepts requests with a valid token signed with secret and puts
+// the user ID in Locals("user_id")
+func Protected(secret string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		authHeader := c.Get("Authorization")
+		// Browsers cannot set headers on WebSocket handshakes, so those may pass the token in the query
+		if authHeader == "" && strings.EqualFold(c.Get("Upgrade"), "websocket") && c.Query("access_token") != "" {
+			authHeader = "Bearer " + c.Query("access_token")
+		}
 		if authHeader == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"status": "error", "message": "Missing Authorization header"})
 		}
 
 		tokenString := strings.Replace(authHeader, "Bearer ", "", 1)
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "secret" // Default fallback
-		}
 
 		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
 			if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -0,0 +1,20 @@
+package middleware
+
+import "testing"
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		path, query, want string
+	}{
+		{"/api/games", "", "/api/games"},
+		{"/api/games", "status=backlog&sort=title", "/api/games?sort=title&status=backlog"},
+		{"/api/tier-lists/1/live", "access_token=eyJhbGciOi.x.y", "/api/tier-lists/1/live?access_token=REDACTED"},
+		{"/api/tier-lists/1/live", "a=1&access_token=one&access_token=two", "/api/tier-lists/1/live?a=1&access_token=REDACTED"},
+		{"/api/tier-lists/1/live", "access_token=%zz", "/api/tier-lists/1/live"},
+	}
+	for _, tt := range tests {
+		if got := redactURL(tt.path, tt.query); got != tt.want {
+			t.Errorf("redactURL(%q, %q) = %q, want %q", tt.path, tt.query, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type Achievement struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	GameID      uuid.UUID  `gorm:"type:uuid;not null;index" json:"game_id"`
+	ExternalID  string     `gorm:"type:varchar(100)" json:"external_id"` // Provider's identifier, used to match on re-import
+	Name        string     `gorm:"not null" json:"name"`
+	Description string     `gorm:"type:text" json:"description"`
+	IconURL     string     `json:"icon_url"`
+	Unlocked    bool       `gorm:"default:false" json:"unlocked"`
+	UnlockedAt  *time.Time `json:"unlocked_at"`
+	Rarity      *float64   `json:"rarity"` // Percentage of players who unlocked it
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func (a *Achievement) BeforeCreate(tx *gorm.DB) (err error) {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CollaboratorRole string
+
+const (
+	CollaboratorViewer CollaboratorRole = "viewer" // Sees live changes
+	CollaboratorEditor CollaboratorRole = "editor" // Can also edit rows and items
+)
+
+// TierListCollaborator gives another user access to a tier list. The invite
+// only takes effect once the invited user accepts it.
+type TierListCollaborator struct {
+	ID         uuid.UUID        `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TierListID uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_tier_list_collaborator" json:"tier_list_id"`
+	UserID     uuid.UUID        `gorm:"type:uuid;not null;uniqueIndex:idx_tier_list_collaborator;index" json:"user_id"`
+	Role       CollaboratorRole `gorm:"type:varchar(10);not null" json:"role"`
+	InvitedBy  uuid.UUID        `gorm:"type:uuid;not null" json:"invited_by"`
+	AcceptedAt *time.Time       `json:"accepted_at"`
+	CreatedAt  time.Time        `json:"created_at"`
+	UpdatedAt  time.Time        `json:"updated_at"`
+
+	TierList TierList `gorm:"foreignKey:TierListID;constraint:OnDelete:CASCADE;" json:"-"`
+	User     User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+func (r CollaboratorRole) Valid() bool {
+	return r == CollaboratorViewer || r == CollaboratorEditor
+}
+
+func (c *TierListCollaborator) BeforeCreate(tx *gorm.DB) (err error) {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return
+}
@@ -14,28 +14,40 @@ const (
 	StatusPlaying   GameStatus = "playing"
 	StatusCompleted GameStatus = "completed"
 	StatusDropped   GameStatus = "dropped"
+	StatusWishlist  GameStatus = "wishlist" // Wanted but not owned yet
 )
 
 type Game struct {
-	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
-	Title        string     `gorm:"not null" json:"title"`
-	CoverURL     string     `json:"cover_url"`
-	Genre        string     `json:"genre"`
-	Status       GameStatus `gorm:"type:varchar(20);default:'backlog'" json:"status"`
-	Platform     string     `gorm:"type:varchar(50)" json:"platform"` // Steam, Xbox, Epic, Switch, GOG, Pirated
-	Platinum     bool       `gorm:"default:false" json:"platinum"`
-	Score        *float64   `json:"score"` // Pointer to allow null (0.0 is a valid score)
-	HoursPlayed  int        `json:"hours_played"`
-	HLTBEstimate int        `json:"hltb_estimate"`
-	ReleaseYear  int        `json:"release_year"`
-	DateFinished *time.Time `json:"date_finished"`
-	LastPlayedAt *time.Time `json:"last_played_at"`
-	ReviewText   string     `gorm:"type:text" json:"review_text"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID       uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	Title    string     `gorm:"not null" json:"title"`
+	CoverURL string     `json:"cover_url"`
+	Genre    string     `json:"genre"`
+	Status   GameStatus `gorm:"type:varchar(20);default:'backlog'" json:"status"`
+	Platform string     `gorm:"type:varchar(50)" json:"platform"` // Legacy free text; mirrors the primary Ownership when there is one
+	Platinum bool       `gorm:"default:false" json:"platinum"`
+	// Achievement counts, kept in sync with the Achievement rows
+	AchievementsTotal    int        `gorm:"default:0" json:"achievements_total"`
+	AchievementsUnlocked int        `gorm:"default:0" json:"achievements_unlocked"`
+	Score                *float64   `json:"score"`        // Pointer to allow null (0.0 is a valid score)
+	HoursPlayed          int        `json:"hours_played"` // Sum of Ownership hours when ownerships exist
+	HLTBEstimate         int        `json:"hltb_estimate"`
+	ReleaseYear          int        `json:"release_year"`
+	ReleaseDate          *time.Time `gorm:"index" json:"release_date"` // Full date when known, ReleaseYear is kept in sync
+	AcquiredAt           *time.Time `json:"acquired_at"`               // Set when a wishlist game becomes owned
+	PricePaid            *float64   `json:"price_paid"`
+	DateFinished         *time.Time `json:"date_finished"`
+	LastPlayedAt         *time.Time `json:"last_played_at"`
+	ReviewText           string     `gorm:"type:text" json:"review_text"`
+	SeriesID             *uuid.UUID `gorm:"type:uuid;index" json:"series_id"`
+	ExternalID           string     `gorm:"type:varchar(100);index" json:"external_id"` // Identifier in external catalogues and achievement providers
+	QueuePosition        *int       `gorm:"index" json:"queue_position"`                // Backlog priority, 1 = play next. Null when not queued
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
 
-	Tags []*GameTag `gorm:"many2many:game_related_tags;" json:"tags"`
+	Tags         []*GameTag    `gorm:"many2many:game_related_tags;" json:"tags"`
+	Ownerships   []Ownership   `gorm:"foreignKey:GameID;constraint:OnDelete:CASCADE;" json:"ownerships,omitempty"`
+	Achievements []Achievement `gorm:"foreignKey:GameID;constraint:OnDelete:CASCADE;" json:"achievements,omitempty"`
 }
 
 func (g *Game) BeforeCreate(tx *gorm.DB) (err error) {
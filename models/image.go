@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Image records that a user uploaded a file to the image storage. Files are
+// content-addressed (<sha256><ext>), so identical uploads share one file and
+// each uploader gets their own row for it. Size counts the file together
+// with its derived sizes, resizes and precompressed copies and is the same on
+// every row of a file. RefCount is the number of games whose CoverURL points
+// at the file, kept the same on every row of a file;
+// unreferenced files are removed by the garbage collector once their latest
+// upload is older than its grace period. The placeholder fields come from
+// images.Analyze and are the same on every row of a file.
+type Image struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_image_user_name" json:"user_id"`         // Uploader
+	Name        string    `gorm:"not null;uniqueIndex:idx_image_user_name;index:idx_image_name" json:"name"` // Storage name, served at /images/<name>
+	Size        int64     `gorm:"not null" json:"size"`
+	Hash        string    `gorm:"type:varchar(64);not null;index" json:"hash"` // Hex SHA-256 of the stored bytes, empty for files uploaded before hashing
+	ContentType string    `gorm:"type:varchar(50);not null" json:"content_type"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	// Placeholder data shown while the image loads, empty until analysed
+	BlurHash      string    `gorm:"type:varchar(100)" json:"blurhash"`
+	DominantColor string    `gorm:"type:varchar(7)" json:"dominant_color"` // #RRGGBB
+	Palette       []string  `gorm:"type:jsonb;serializer:json" json:"palette"`
+	AspectRatio   float64   `json:"aspect_ratio"`
+	RefCount      int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+func (i *Image) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return
+}
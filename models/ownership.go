@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OwnershipFormat string
+
+const (
+	FormatDigital  OwnershipFormat = "digital"
+	FormatPhysical OwnershipFormat = "physical"
+)
+
+// Ownership is one copy of a game on a platform. A game owned on several
+// platforms has one Ownership each instead of duplicate Game rows.
+type Ownership struct {
+	ID          uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	GameID      uuid.UUID       `gorm:"type:uuid;not null;index" json:"game_id"`
+	Platform    Platform        `gorm:"type:varchar(20);not null" json:"platform"`
+	Storefront  string          `gorm:"type:varchar(50)" json:"storefront"` // Steam, Epic, GOG, eShop, Retail...
+	Edition     string          `gorm:"type:varchar(100)" json:"edition"`
+	Format      OwnershipFormat `gorm:"type:varchar(10);default:'digital'" json:"format"`
+	AcquiredAt  *time.Time      `json:"acquired_at"`
+	Price       *float64        `json:"price"`
+	HoursPlayed int             `json:"hours_played"`
+	Completed   bool            `gorm:"default:false" json:"completed"`
+	CompletedAt *time.Time      `json:"completed_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+func (o *Ownership) BeforeCreate(tx *gorm.DB) (err error) {
+	if o.ID == uuid.Nil {
+		o.ID = uuid.New()
+	}
+	return
+}
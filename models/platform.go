@@ -0,0 +1,76 @@
+package models
+
+import "strings"
+
+type Platform string
+
+const (
+	PlatformPC          Platform = "pc"
+	PlatformPlayStation Platform = "playstation"
+	PlatformXbox        Platform = "xbox"
+	PlatformSwitch      Platform = "switch"
+	PlatformMobile      Platform = "mobile"
+	PlatformRetro       Platform = "retro"
+	PlatformOther       Platform = "other"
+)
+
+// Platforms is the controlled vocabulary accepted for ownerships, in display order
+var Platforms = []Platform{PlatformPC, PlatformPlayStation, PlatformXbox, PlatformSwitch, PlatformMobile, PlatformRetro, PlatformOther}
+
+var platformNames = map[Platform]string{
+	PlatformPC:          "PC",
+	PlatformPlayStation: "PlayStation",
+	PlatformXbox:        "Xbox",
+	PlatformSwitch:      "Nintendo Switch",
+	PlatformMobile:      "Mobile",
+	PlatformRetro:       "Retro",
+	PlatformOther:       "Other",
+}
+
+// Legacy free-text values of Game.Platform and common spellings.
+// Storefronts map to their platform plus the storefront they imply.
+var platformAliases = map[string]struct {
+	Platform   Platform
+	Storefront string
+}{
+	"steam":           {PlatformPC, "Steam"},
+	"epic":            {PlatformPC, "Epic"},
+	"gog":             {PlatformPC, "GOG"},
+	"pirated":         {PlatformPC, "Pirated"},
+	"windows":         {PlatformPC, ""},
+	"ps":              {PlatformPlayStation, ""},
+	"ps4":             {PlatformPlayStation, ""},
+	"ps5":             {PlatformPlayStation, ""},
+	"psn":             {PlatformPlayStation, "PlayStation Store"},
+	"xbox one":        {PlatformXbox, ""},
+	"xbox series":     {PlatformXbox, ""},
+	"game pass":       {PlatformXbox, "Game Pass"},
+	"nintendo":        {PlatformSwitch, ""},
+	"nintendo switch": {PlatformSwitch, ""},
+	"eshop":           {PlatformSwitch, "Nintendo eShop"},
+	"android":         {PlatformMobile, "Google Play"},
+	"ios":             {PlatformMobile, "App Store"},
+}
+
+// DisplayName returns the human readable platform name
+func (p Platform) DisplayName() string {
+	if name, ok := platformNames[p]; ok {
+		return name
+	}
+	return string(p)
+}
+
+// ParsePlatform resolves a platform slug, display name or legacy alias.
+// The returned storefront is non-empty when the input named a store (e.g. "Steam").
+func ParsePlatform(raw string) (Platform, string, bool) {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	for _, p := range Platforms {
+		if key == string(p) || key == strings.ToLower(p.DisplayName()) {
+			return p, "", true
+		}
+	}
+	if alias, ok := platformAliases[key]; ok {
+		return alias.Platform, alias.Storefront, true
+	}
+	return "", "", false
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Series groups the entries of a franchise. Games join through Game.SeriesID.
+type Series struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID      uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name        string    `gorm:"not null" json:"name"`
+	Description string    `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	Games       []Game    `gorm:"foreignKey:SeriesID;constraint:OnDelete:SET NULL;" json:"games,omitempty"`
+}
+
+type RelationType string
+
+const (
+	RelationDLCOf       RelationType = "dlc_of"
+	RelationExpansionOf RelationType = "expansion_of"
+	RelationSequelOf    RelationType = "sequel_of"
+	RelationRemasterOf  RelationType = "remaster_of"
+)
+
+// RelationTypes lists the accepted relation types
+var RelationTypes = []RelationType{RelationDLCOf, RelationExpansionOf, RelationSequelOf, RelationRemasterOf}
+
+// IsAddOn reports whether the relation makes the game an add-on of the related
+// game, i.e. it has no reason to exist once the base game is deleted
+func (t RelationType) IsAddOn() bool {
+	return t == RelationDLCOf || t == RelationExpansionOf
+}
+
+// AddOnRelationTypes lists the relation types that make a game an add-on
+func AddOnRelationTypes() []RelationType {
+	var types []RelationType
+	for _, t := range RelationTypes {
+		if t.IsAddOn() {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func (t RelationType) Valid() bool {
+	for _, v := range RelationTypes {
+		if v == t {
+			return true
+		}
+	}
+	return false
+}
+
+// GameRelation reads "Game <Type> RelatedGame", e.g. a DLC is dlc_of its base game
+type GameRelation struct {
+	ID            uuid.UUID    `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	GameID        uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_game_relation" json:"game_id"`
+	RelatedGameID uuid.UUID    `gorm:"type:uuid;not null;uniqueIndex:idx_game_relation;index" json:"related_game_id"`
+	Type          RelationType `gorm:"type:varchar(20);not null;uniqueIndex:idx_game_relation" json:"type"`
+	CreatedAt     time.Time    `json:"created_at"`
+
+	Game        Game `gorm:"foreignKey:GameID;constraint:OnDelete:CASCADE;" json:"-"`
+	RelatedGame Game `gorm:"foreignKey:RelatedGameID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+func (s *Series) BeforeCreate(tx *gorm.DB) (err error) {
+	if s.ID == uuid.Nil {
+		s.ID = uuid.New()
+	}
+	return
+}
+
+func (r *GameRelation) BeforeCreate(tx *gorm.DB) (err error) {
+	if r.ID == uuid.Nil {
+		r.ID = uuid.New()
+	}
+	return
+}
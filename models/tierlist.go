@@ -7,13 +7,28 @@ import (
 	"gorm.io/gorm"
 )
 
+type TierListVisibility string
+
+const (
+	VisibilityPrivate  TierListVisibility = "private"  // Owner only
+	VisibilityUnlisted TierListVisibility = "unlisted" // Anyone with the share link
+	VisibilityPublic   TierListVisibility = "public"   // Share link and the public listing
+)
+
 type TierList struct {
-	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
-	Name      string    `gorm:"not null" json:"name"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
-	Rows      []TierRow `gorm:"foreignKey:TierListID;constraint:OnDelete:CASCADE;" json:"rows"`
+	ID           uuid.UUID          `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID       uuid.UUID          `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name         string             `gorm:"not null" json:"name"`
+	Visibility   TierListVisibility `gorm:"type:varchar(10);default:'private';not null" json:"visibility"`
+	ShareSlug    *string            `gorm:"type:varchar(32);uniqueIndex" json:"share_slug"`
+	PublishedAt  *time.Time         `json:"published_at"`
+	ForkedFromID *uuid.UUID         `gorm:"type:uuid;index" json:"forked_from_id"` // Tier list this one was cloned from
+	LiveRevision int                `gorm:"not null;default:0" json:"-"`           // Bumped by every saved change; orders live edits across instances
+	CreatedAt    time.Time          `json:"created_at"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+	Rows         []TierRow          `gorm:"foreignKey:TierListID;constraint:OnDelete:CASCADE;" json:"rows"`
+
+	ForkedFrom *TierList `gorm:"foreignKey:ForkedFromID;constraint:OnDelete:SET NULL;" json:"-"`
 }
 
 type TierRow struct {
@@ -31,7 +46,7 @@ type TierItem struct {
 	GameID    uuid.UUID `gorm:"type:uuid;not null;index" json:"game_id"`
 	SortOrder int       `gorm:"not null" json:"sort_order"`
 
-	Game Game `gorm:"foreignKey:GameID" json:"game"`
+	Game Game `gorm:"foreignKey:GameID;constraint:OnDelete:CASCADE;" json:"game"`
 }
 
 func (t *TierList) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// TierListTemplate is a reusable set of rows for starting tier lists
+type TierListTemplate struct {
+	ID          uuid.UUID         `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	UserID      uuid.UUID         `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name        string            `gorm:"not null" json:"name"`
+	Description string            `gorm:"type:text" json:"description"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Rows        []TierTemplateRow `gorm:"foreignKey:TemplateID;constraint:OnDelete:CASCADE;" json:"rows"`
+
+	BuiltIn bool `gorm:"-" json:"built_in"`
+}
+
+type TierTemplateRow struct {
+	ID         uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TemplateID uuid.UUID `gorm:"type:uuid;not null;index" json:"template_id"`
+	Label      string    `gorm:"not null" json:"label"`
+	Color      string    `gorm:"type:varchar(7);default:'#FFFFFF'" json:"color"`
+	SortOrder  int       `gorm:"not null" json:"sort_order"`
+	MinScore   *float64  `json:"min_score"` // Lowest Score placed in this row when generating by score
+}
+
+func (t *TierListTemplate) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+func (t *TierTemplateRow) BeforeCreate(tx *gorm.DB) (err error) {
+	if t.ID == uuid.Nil {
+		t.ID = uuid.New()
+	}
+	return
+}
+
+// BuiltinTemplates are available to every user and are not stored in the database
+var BuiltinTemplates = []TierListTemplate{
+	builtinTemplate("classic", "Classic S-D", "The usual S, A, B, C, D tiers", []TierTemplateRow{
+		{Label: "S", Color: "#FF7F7F", MinScore: scorePtr(9)},
+		{Label: "A", Color: "#FFBF7F", MinScore: scorePtr(8)},
+		{Label: "B", Color: "#FFDF7F", MinScore: scorePtr(7)},
+		{Label: "C", Color: "#FFFF7F", MinScore: scorePtr(6)},
+		{Label: "D", Color: "#BFFF7F", MinScore: scorePtr(0)},
+	}),
+	builtinTemplate("extended", "Extended S-F", "S to F with an extra failing tier", []TierTemplateRow{
+		{Label: "S", Color: "#FF7F7F", MinScore: scorePtr(9.5)},
+		{Label: "A", Color: "#FFBF7F", MinScore: scorePtr(8.5)},
+		{Label: "B", Color: "#FFDF7F", MinScore: scorePtr(7.5)},
+		{Label: "C", Color: "#FFFF7F", MinScore: scorePtr(6.5)},
+		{Label: "D", Color: "#BFFF7F", MinScore: scorePtr(5)},
+		{Label: "F", Color: "#7FBFFF", MinScore: scorePtr(0)},
+	}),
+	builtinTemplate("verdict", "Verdict", "Masterpiece, great, good, meh, skip", []TierTemplateRow{
+		{Label: "Masterpiece", Color: "#FFD700", MinScore: scorePtr(9)},
+		{Label: "Great", Color: "#7FFF7F", MinScore: scorePtr(7.5)},
+		{Label: "Good", Color: "#7FBFFF", MinScore: scorePtr(6)},
+		{Label: "Meh", Color: "#BFBFBF", MinScore: scorePtr(4)},
+		{Label: "Skip", Color: "#FF7F7F", MinScore: scorePtr(0)},
+	}),
+}
+
+// FindBuiltinTemplate returns the built-in template with the given ID
+func FindBuiltinTemplate(id uuid.UUID) (TierListTemplate, bool) {
+	for _, t := range BuiltinTemplates {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return TierListTemplate{}, false
+}
+
+// builtinTemplate derives stable IDs from the key so clients can bookmark them
+func builtinTemplate(key, name, description string, rows []TierTemplateRow) TierListTemplate {
+	id := uuid.NewSHA1(uuid.NameSpaceOID, []byte("tier-list-template:"+key))
+	for i := range rows {
+		rows[i].ID = uuid.NewSHA1(id, []byte(rows[i].Label))
+		rows[i].TemplateID = id
+		rows[i].SortOrder = i
+	}
+	return TierListTemplate{ID: id, Name: name, Description: description, Rows: rows, BuiltIn: true}
+}
+
+func scorePtr(v float64) *float64 {
+	return &v
+}
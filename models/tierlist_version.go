@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Why a tier list version was recorded
+const (
+	VersionSourceCreate   = "create"
+	VersionSourceGenerate = "generate"
+	VersionSourceUpdate   = "update"
+	VersionSourcePatch    = "patch"
+	VersionSourceRestore  = "restore"
+	VersionSourceLive     = "live"
+	VersionSourceFork     = "fork"
+)
+
+// TierListVersion is a snapshot of a tier list taken after each save
+type TierListVersion struct {
+	ID         uuid.UUID       `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	TierListID uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_tier_list_version" json:"tier_list_id"`
+	Version    int             `gorm:"not null;uniqueIndex:idx_tier_list_version" json:"version"`
+	Source     string          `gorm:"type:varchar(10);not null" json:"source"`
+	Snapshot   json.RawMessage `gorm:"type:jsonb;not null" json:"snapshot"`
+	CreatedAt  time.Time       `json:"created_at"`
+
+	TierList TierList `gorm:"foreignKey:TierListID;constraint:OnDelete:CASCADE;" json:"-"`
+}
+
+// TierListSnapshot is the stored form of a tier list's name, rows and items
+type TierListSnapshot struct {
+	Name string        `json:"name"`
+	Rows []SnapshotRow `json:"rows"`
+}
+
+type SnapshotRow struct {
+	ID    uuid.UUID      `json:"id"`
+	Label string         `json:"label"`
+	Color string         `json:"color"`
+	Items []SnapshotItem `json:"items"`
+}
+
+type SnapshotItem struct {
+	ID     uuid.UUID `json:"id"`
+	GameID uuid.UUID `json:"game_id"`
+}
+
+func (v *TierListVersion) BeforeCreate(tx *gorm.DB) (err error) {
+	if v.ID == uuid.Nil {
+		v.ID = uuid.New()
+	}
+	return
+}
@@ -8,14 +8,18 @@ import (
 )
 
 type User struct {
-	ID           uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Username     string    `gorm:"uniqueIndex;not null" json:"username"`
-	Email        string    `gorm:"uniqueIndex;not null" json:"email"`
-	PasswordHash string    `gorm:"not null" json:"-"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
-	Role         string    `gorm:"type:varchar(20);default:'user';not null" json:"role"`
-	Games        []Game    `gorm:"foreignKey:UserID" json:"games,omitempty"`
+	ID            uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Username      string    `gorm:"uniqueIndex;not null" json:"username"`
+	Email         string    `gorm:"uniqueIndex;not null" json:"email"`
+	PasswordHash  string    `gorm:"not null" json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	Role          string    `gorm:"type:varchar(20);default:'user';not null" json:"role"`
+	CalendarToken *string   `gorm:"uniqueIndex" json:"-"` // Secret for the public release calendar feed
+	// Admin override of the role's storage quota in bytes; nil uses the role
+	// quota, -1 is unlimited
+	StorageQuotaBytes *int64 `json:"-"`
+	Games             []Game `gorm:"foreignKey:UserID" json:"games,omitempty"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,50 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache stores renders under Dir/renders as <tierListID>_<key>.<format>.
+// The key is a hash of everything that affects the output, so any edit to the
+// list produces a new key; storing it removes the stale files of that list.
+type Cache struct {
+	Dir string
+}
+
+func (rc Cache) renderPath(tierListID, key, format string) string {
+	return filepath.Join(rc.Dir, "renders", tierListID+"_"+key+"."+format)
+}
+
+// CachedRender returns a previously stored render, if any
+func (rc Cache) CachedRender(tierListID, key, format string) ([]byte, bool) {
+	data, err := os.ReadFile(rc.renderPath(tierListID, key, format))
+	return data, err == nil
+}
+
+// StoreRender saves a render and drops older renders of the same tier list
+// in the same format
+func (rc Cache) StoreRender(tierListID, key, format string, data []byte) error {
+	path := rc.renderPath(tierListID, key, format)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	stale, _ := filepath.Glob(filepath.Join(rc.Dir, "renders", tierListID+"_*."+format))
+	for _, old := range stale {
+		if old != path {
+			os.Remove(old)
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// InvalidateRenders removes every cached render of a tier list
+func (rc Cache) InvalidateRenders(tierListID string) {
+	files, _ := filepath.Glob(filepath.Join(rc.Dir, "renders", tierListID+"_*"))
+	for _, f := range files {
+		if strings.HasPrefix(filepath.Base(f), tierListID+"_") {
+			os.Remove(f)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package render
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	rc := Cache{Dir: filepath.Join(t.TempDir(), "cache")}
+	list, other := "5b7c0f4e-1111-4a3b-9c2d-000000000001", "5b7c0f4e-1111-4a3b-9c2d-000000000002"
+
+	if _, ok := rc.CachedRender(list, "k1", "png"); ok {
+		t.Fatal("CachedRender() found a render in an empty cache")
+	}
+	store := func(id, key, format string) {
+		t.Helper()
+		if err := rc.StoreRender(id, key, format, []byte(id+key+format)); err != nil {
+			t.Fatalf("StoreRender(%s, %s, %s) = %v", id, key, format, err)
+		}
+	}
+	store(list, "k1", "png")
+	store(list, "k1", "svg")
+	store(list, "k2", "png") // A newer render replaces k1 in the same format
+	store(other, "k1", "png")
+	store(other, "k1", "svg")
+
+	files := func() []string {
+		t.Helper()
+		entries, err := os.ReadDir(filepath.Join(rc.Dir, "renders"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	want := []string{list + "_k1.svg", list + "_k2.png", other + "_k1.png", other + "_k1.svg"}
+	if got := files(); !slices.Equal(got, want) {
+		t.Errorf("cached files = %v, want %v", got, want)
+	}
+	if data, ok := rc.CachedRender(list, "k2", "png"); !ok || string(data) != list+"k2png" {
+		t.Errorf("CachedRender() = %q, %v", data, ok)
+	}
+	if _, ok := rc.CachedRender(list, "k1", "png"); ok {
+		t.Error("CachedRender() returned a replaced render")
+	}
+
+	rc.InvalidateRenders(list)
+	want = []string{other + "_k1.png", other + "_k1.svg"}
+	if got := files(); !slices.Equal(got, want) {
+		t.Errorf("cached files after InvalidateRenders() = %v, want %v", got, want)
+	}
+
+	// Nothing cached yet is not an error
+	Cache{Dir: t.TempDir()}.InvalidateRenders(list)
+}
@@ -0,0 +1,131 @@
+package render
+
+import (
+	"backlog-backend/images"
+	"backlog-backend/storage"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const maxCoverBytes = 10 << 20
+
+// Remote covers are cached for coverCacheTTL, and the cache is trimmed to
+// maxCoverCacheBytes, oldest first, whenever a cover is added
+const (
+	coverCacheTTL      = 7 * 24 * time.Hour
+	maxCoverCacheBytes = 200 << 20
+)
+
+var coverCacheMu sync.Mutex
+
+// Covers loads the covers drawn on tier lists
+type Covers struct {
+	Store    storage.Storage // Uploaded covers
+	Fetcher  *images.Fetcher // Remote covers, with the SSRF protections of image fetches
+	CacheDir string          // Remote covers are cached under CacheDir/covers
+}
+
+// Load resolves a game's CoverURL to an image. Uploads are read from the
+// image storage; remote covers are downloaded once and cached on disk. A
+// cover that cannot be loaded returns nil so the renderer draws a
+// placeholder.
+func (cv Covers) Load(coverURL string) image.Image {
+	var data []byte
+	var err error
+	switch {
+	case strings.HasPrefix(coverURL, "/images/"):
+		name := filepath.Base(coverURL)
+		if !storage.ValidName(name) {
+			return nil
+		}
+		img, err := images.Load(context.Background(), cv.Store, name)
+		if err != nil {
+			return nil
+		}
+		return img
+	case strings.HasPrefix(coverURL, "http://"), strings.HasPrefix(coverURL, "https://"):
+		data, err = cv.fetchRemote(coverURL)
+	default:
+		return nil
+	}
+	if err != nil {
+		return nil
+	}
+	img, err := images.Decode(data, images.DefaultLimits)
+	if err != nil {
+		return nil
+	}
+	return img
+}
+
+func (cv Covers) fetchRemote(url string) ([]byte, error) {
+	sum := sha256.Sum256([]byte(url))
+	path := filepath.Join(cv.CacheDir, "covers", hex.EncodeToString(sum[:]))
+	if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) < coverCacheTTL {
+		if data, err := os.ReadFile(path); err == nil {
+			return data, nil
+		}
+	}
+
+	// Covers are user-supplied URLs; the same SSRF protections as image
+	// fetches apply
+	data, err := cv.Fetcher.Download(context.Background(), url, maxCoverBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err == nil {
+		coverCacheMu.Lock()
+		if os.WriteFile(path, data, 0644) == nil {
+			pruneCoverCache(filepath.Dir(path))
+		}
+		coverCacheMu.Unlock()
+	}
+	return data, nil
+}
+
+// pruneCoverCache removes expired covers, then the oldest ones until the
+// cache fits in maxCoverCacheBytes
+func pruneCoverCache(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	type cached struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var files []cached
+	var total int64
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if time.Since(info.ModTime()) >= coverCacheTTL {
+			os.Remove(path)
+			continue
+		}
+		files = append(files, cached{path, info.Size(), info.ModTime()})
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+	for _, f := range files {
+		if total <= maxCoverCacheBytes {
+			break
+		}
+		if os.Remove(f.path) == nil {
+			total -= f.size
+		}
+	}
+}
@@ -0,0 +1,344 @@
+// Package render draws tier lists as PNG or SVG images without any external
+// tooling, so it runs on headless servers.
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	DefaultWidth    = 1200
+	DefaultItemSize = 96
+	MinWidth        = 400
+	MaxWidth        = 4000
+	MinItemSize     = 32
+	MaxItemSize     = 256
+
+	gap         = 4
+	titleHeight = 56
+)
+
+var (
+	background  = color.RGBA{0x1A, 0x1A, 0x1E, 0xFF}
+	placeholder = color.RGBA{0x3A, 0x3A, 0x42, 0xFF}
+)
+
+type Options struct {
+	Width    int
+	ItemSize int
+	Title    string
+}
+
+// Normalize clamps the options to the supported range and fills in defaults
+func (o Options) Normalize() Options {
+	if o.Width == 0 {
+		o.Width = DefaultWidth
+	}
+	if o.ItemSize == 0 {
+		o.ItemSize = DefaultItemSize
+	}
+	o.Width = clamp(o.Width, MinWidth, MaxWidth)
+	o.ItemSize = clamp(o.ItemSize, MinItemSize, MaxItemSize)
+	return o
+}
+
+type Item struct {
+	Title string
+	Cover image.Image // nil draws a placeholder with the title
+}
+
+type Row struct {
+	Label string
+	Color string // #RRGGBB
+	Items []Item
+}
+
+type TierList struct {
+	Rows []Row
+}
+
+// layout holds the computed geometry shared by the PNG and SVG renderers
+type layout struct {
+	opts       Options
+	labelWidth int
+	perRow     int
+	rowHeights []int
+	height     int
+	top        int
+}
+
+func computeLayout(t TierList, opts Options) layout {
+	l := layout{opts: opts, labelWidth: opts.ItemSize + opts.ItemSize/2}
+	if opts.Title != "" {
+		l.top = titleHeight
+	}
+	l.perRow = (opts.Width - l.labelWidth - gap) / (opts.ItemSize + gap)
+	if l.perRow < 1 {
+		l.perRow = 1
+	}
+	l.height = l.top
+	for _, row := range t.Rows {
+		lines := (len(row.Items) + l.perRow - 1) / l.perRow
+		if lines == 0 {
+			lines = 1
+		}
+		h := lines*(opts.ItemSize+gap) + gap
+		l.rowHeights = append(l.rowHeights, h)
+		l.height += h
+	}
+	if l.height == 0 {
+		l.height = opts.ItemSize
+	}
+	return l
+}
+
+// itemRect returns the position of the i-th item of a row starting at y
+func (l layout) itemRect(y, i int) image.Rectangle {
+	col, line := i%l.perRow, i/l.perRow
+	x0 := l.labelWidth + gap + col*(l.opts.ItemSize+gap)
+	y0 := y + gap + line*(l.opts.ItemSize+gap)
+	return image.Rect(x0, y0, x0+l.opts.ItemSize, y0+l.opts.ItemSize)
+}
+
+// PNG renders the tier list as a PNG image
+func PNG(w io.Writer, t TierList, opts Options) error {
+	opts = opts.Normalize()
+	l := computeLayout(t, opts)
+	img := image.NewRGBA(image.Rect(0, 0, opts.Width, l.height))
+	draw.Draw(img, img.Bounds(), image.NewUniform(background), image.Point{}, draw.Src)
+
+	if opts.Title != "" {
+		drawText(img, opts.Title, image.Rect(0, 0, opts.Width, titleHeight), 26, color.White)
+	}
+
+	y := l.top
+	for r, row := range t.Rows {
+		h := l.rowHeights[r]
+		fill := parseHexColor(row.Color)
+		labelRect := image.Rect(0, y, l.labelWidth, y+h-1)
+		draw.Draw(img, labelRect, image.NewUniform(fill), image.Point{}, draw.Src)
+		drawText(img, row.Label, labelRect.Inset(4), labelFontSize(opts.ItemSize), contrastColor(fill))
+
+		for i, item := range row.Items {
+			rect := l.itemRect(y, i)
+			if item.Cover != nil {
+				drawCover(img, rect, item.Cover)
+			} else {
+				draw.Draw(img, rect, image.NewUniform(placeholder), image.Point{}, draw.Src)
+				drawText(img, item.Title, rect.Inset(4), 11, color.White)
+			}
+		}
+		y += h
+	}
+
+	return png.Encode(w, img)
+}
+
+// SVG renders the tier list as a standalone SVG document. Covers are
+// embedded as scaled JPEG data URIs so the file has no external references.
+func SVG(w io.Writer, t TierList, opts Options) error {
+	opts = opts.Normalize()
+	l := computeLayout(t, opts)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`,
+		opts.Width, l.height, opts.Width, l.height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, hexColor(background))
+	if opts.Title != "" {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#FFFFFF" font-size="26" font-weight="bold" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+			opts.Width/2, titleHeight/2, escapeXML(opts.Title))
+	}
+
+	y := l.top
+	for r, row := range t.Rows {
+		h := l.rowHeights[r]
+		fill := parseHexColor(row.Color)
+		fmt.Fprintf(&b, `<rect x="0" y="%d" width="%d" height="%d" fill="%s"/>`, y, l.labelWidth, h-1, hexColor(fill))
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" font-size="%d" font-weight="bold" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+			l.labelWidth/2, y+h/2, hexColor(contrastColor(fill)), int(labelFontSize(opts.ItemSize)), escapeXML(row.Label))
+
+		for i, item := range row.Items {
+			rect := l.itemRect(y, i)
+			if item.Cover != nil {
+				uri, err := coverDataURI(item.Cover, opts.ItemSize)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(&b, `<image x="%d" y="%d" width="%d" height="%d" href="%s"><title>%s</title></image>`,
+					rect.Min.X, rect.Min.Y, opts.ItemSize, opts.ItemSize, uri, escapeXML(item.Title))
+			} else {
+				fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`,
+					rect.Min.X, rect.Min.Y, opts.ItemSize, opts.ItemSize, hexColor(placeholder))
+				fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#FFFFFF" font-size="11" text-anchor="middle" dominant-baseline="middle">%s</text>`,
+					rect.Min.X+opts.ItemSize/2, rect.Min.Y+opts.ItemSize/2, escapeXML(truncate(item.Title, opts.ItemSize/7)))
+			}
+		}
+		y += h
+	}
+	b.WriteString(`</svg>`)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// drawCover scales the cover to fill the square, cropping the overflow
+func drawCover(dst draw.Image, rect image.Rectangle, cover image.Image) {
+	xdraw.CatmullRom.Scale(dst, rect, cover, cropToSquare(cover.Bounds()), draw.Src, nil)
+}
+
+func cropToSquare(b image.Rectangle) image.Rectangle {
+	w, h := b.Dx(), b.Dy()
+	if w > h {
+		off := (w - h) / 2
+		return image.Rect(b.Min.X+off, b.Min.Y, b.Min.X+off+h, b.Max.Y)
+	}
+	off := (h - w) / 2
+	return image.Rect(b.Min.X, b.Min.Y+off, b.Max.X, b.Min.Y+off+w)
+}
+
+func coverDataURI(cover image.Image, size int) (string, error) {
+	thumb := image.NewRGBA(image.Rect(0, 0, size, size))
+	drawCover(thumb, thumb.Bounds(), cover)
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85}); err != nil {
+		return "", err
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+var (
+	fontOnce sync.Once
+	fontData *opentype.Font
+	faces    sync.Map // float64 size -> font.Face
+)
+
+func face(size float64) font.Face {
+	fontOnce.Do(func() {
+		fontData, _ = opentype.Parse(gobold.TTF)
+	})
+	if f, ok := faces.Load(size); ok {
+		return f.(font.Face)
+	}
+	f, err := opentype.NewFace(fontData, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil
+	}
+	actual, _ := faces.LoadOrStore(size, f)
+	return actual.(font.Face)
+}
+
+// drawText centres text in rect, shrinking it until it fits the width
+func drawText(dst draw.Image, text string, rect image.Rectangle, size float64, c color.Color) {
+	if text == "" {
+		return
+	}
+	var f font.Face
+	var width fixed.Int26_6
+	for ; size >= 8; size-- {
+		if f = face(size); f == nil {
+			return
+		}
+		if width = font.MeasureString(f, text); width.Ceil() <= rect.Dx() {
+			break
+		}
+	}
+	for width.Ceil() > rect.Dx() && len(text) > 1 {
+		text = truncate(text, len([]rune(text))-1)
+		width = font.MeasureString(f, text)
+	}
+
+	metrics := f.Metrics()
+	x := rect.Min.X + (rect.Dx()-width.Ceil())/2
+	y := rect.Min.Y + (rect.Dy()+metrics.Ascent.Ceil()-metrics.Descent.Ceil())/2
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(c), Face: f, Dot: fixed.P(x, y)}
+	d.DrawString(text)
+}
+
+func labelFontSize(itemSize int) float64 {
+	return float64(clamp(itemSize/3, 12, 48))
+}
+
+// parseHexColor is lenient: anything that is not #RRGGBB renders white
+func parseHexColor(s string) color.RGBA {
+	if len(s) == 7 && s[0] == '#' {
+		if v, err := strconv.ParseUint(s[1:], 16, 32); err == nil {
+			return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 0xFF}
+		}
+	}
+	return color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+}
+
+// contrastColor picks black or white text for the given background
+func contrastColor(c color.RGBA) color.RGBA {
+	luminance := 0.299*float64(c.R) + 0.587*float64(c.G) + 0.114*float64(c.B)
+	if luminance > 150 {
+		return color.RGBA{0, 0, 0, 0xFF}
+	}
+	return color.RGBA{0xFF, 0xFF, 0xFF, 0xFF}
+}
+
+func hexColor(c color.RGBA) string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+func escapeXML(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '&':
+			b.WriteString("&amp;")
+		case '<':
+			b.WriteString("&lt;")
+		case '>':
+			b.WriteString("&gt;")
+		case '"':
+			b.WriteString("&quot;")
+		case '\'':
+			b.WriteString("&apos;")
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if n < 1 {
+		n = 1
+	}
+	if len(runes) <= n {
+		return s
+	}
+	if n == 1 {
+		return string(runes[:1])
+	}
+	return string(runes[:n-1]) + "…"
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
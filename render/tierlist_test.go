@@ -0,0 +1,106 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in, want Options
+	}{
+		{Options{}, Options{Width: DefaultWidth, ItemSize: DefaultItemSize}},
+		{Options{Width: 800, ItemSize: 64, Title: "Best"}, Options{Width: 800, ItemSize: 64, Title: "Best"}},
+		{Options{Width: 100, ItemSize: 8}, Options{Width: MinWidth, ItemSize: MinItemSize}},
+		{Options{Width: 9000, ItemSize: 900}, Options{Width: MaxWidth, ItemSize: MaxItemSize}},
+		{Options{Width: -5, ItemSize: -5}, Options{Width: MinWidth, ItemSize: MinItemSize}},
+	}
+	for _, tt := range tests {
+		if got := tt.in.Normalize(); got != tt.want {
+			t.Errorf("%+v.Normalize() = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+// items returns n items, every other one with a cover
+func items(n int) []Item {
+	out := make([]Item, n)
+	for i := range out {
+		out[i].Title = fmt.Sprintf("Game %d", i)
+		if i%2 == 0 {
+			cover := image.NewRGBA(image.Rect(0, 0, 40, 60)) // Portrait, cropped to a square
+			draw.Draw(cover, cover.Bounds(), image.NewUniform(color.RGBA{0xC0, 0x30, 0x30, 0xFF}), image.Point{}, draw.Src)
+			out[i].Cover = cover
+		}
+	}
+	return out
+}
+
+func TestRenderDimensions(t *testing.T) {
+	tests := []struct {
+		name          string
+		list          TierList
+		opts          Options
+		width, height int
+	}{
+		// Default options fit 10 items per line, each line 96+4 pixels high plus a gap
+		{"empty list", TierList{}, Options{}, 1200, 96},
+		{"empty row", TierList{Rows: []Row{{Label: "S", Color: "#FF7F7F"}}}, Options{}, 1200, 104},
+		{"full line", TierList{Rows: []Row{{Label: "S", Items: items(10)}}}, Options{}, 1200, 104},
+		{"wrapped line", TierList{Rows: []Row{{Label: "S", Items: items(11)}}}, Options{}, 1200, 204},
+		{"title and rows", TierList{Rows: []Row{{Label: "S", Items: items(3)}, {Label: "A"}}}, Options{Title: "Best games"}, 1200, 56 + 104 + 104},
+		{"custom size", TierList{Rows: []Row{{Label: "S", Items: items(25)}}}, Options{Width: 800, ItemSize: 64}, 800, 3*68 + 4},
+		{"clamped options keep one item per line", TierList{Rows: []Row{{Label: "S", Items: items(2)}}}, Options{Width: 100, ItemSize: 300}, 400, 2*260 + 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := PNG(&buf, tt.list, tt.opts); err != nil {
+				t.Fatalf("PNG() = %v", err)
+			}
+			cfg, err := png.DecodeConfig(&buf)
+			if err != nil {
+				t.Fatalf("decoding the PNG: %v", err)
+			}
+			if cfg.Width != tt.width || cfg.Height != tt.height {
+				t.Errorf("PNG is %dx%d, want %dx%d", cfg.Width, cfg.Height, tt.width, tt.height)
+			}
+
+			buf.Reset()
+			if err := SVG(&buf, tt.list, tt.opts); err != nil {
+				t.Fatalf("SVG() = %v", err)
+			}
+			svg := buf.String()
+			size := fmt.Sprintf(`width="%d" height="%d" viewBox="0 0 %d %d"`, tt.width, tt.height, tt.width, tt.height)
+			if !strings.HasPrefix(svg, "<svg ") || !strings.Contains(svg, size) || !strings.HasSuffix(svg, "</svg>") {
+				t.Errorf("SVG does not start with a %s document: %.200s", size, svg)
+			}
+		})
+	}
+}
+
+func TestSVGContent(t *testing.T) {
+	list := TierList{Rows: []Row{{Label: "S <best>", Color: "#FF7F7F", Items: items(2)}}}
+	var buf bytes.Buffer
+	if err := SVG(&buf, list, Options{Title: "Tom & Jerry"}); err != nil {
+		t.Fatal(err)
+	}
+	svg := buf.String()
+	for _, want := range []string{
+		"Tom &amp; Jerry",
+		"S &lt;best&gt;",
+		`fill="#FF7F7F"`,
+		`href="data:image/jpeg;base64,`, // The first item has a cover
+		"Game 1",                        // The second one a placeholder
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("SVG does not contain %s", want)
+		}
+	}
+}
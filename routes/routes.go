@@ -1,13 +1,17 @@
 package routes
 
 import (
+	"backlog-backend/config"
 	"backlog-backend/handlers"
 	"backlog-backend/middleware"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetupRoutes(app *fiber.App) {
+// SetupRoutes registers the API on app, served by h
+func SetupRoutes(app *fiber.App, cfg *config.Config, h *handlers.Handler) {
+	protected := middleware.Protected(cfg.Auth.JWTSecret)
+
 	// Health
 	app.Get("/", func(c *fiber.Ctx) error {
 		return c.SendString("Hello, World! Backend is running.")
@@ -19,48 +23,129 @@ func SetupRoutes(app *fiber.App) {
 		})
 	})
 
+	// Uploaded images, optionally resized with ?w=&h=&fit=
+	app.Get("/images/:name", h.ServeImage)
+
 	// API Group
 	api := app.Group("/api")
 
 	// User Routes
 	users := api.Group("/users")
-	users.Get("/", handlers.GetUsers)
-	users.Get("/:id", handlers.GetUser)
-	users.Post("/", handlers.CreateUser)
-	users.Post("/login", handlers.Login)
+	users.Get("/", h.GetUsers)
+	users.Get("/me/storage", protected, h.GetMyStorage)
+	users.Get("/:id", h.GetUser)
+	users.Post("/", h.CreateUser)
+	users.Post("/login", h.Login)
 	// Protected User Routes
-	users.Put("/:id", middleware.Protected(), handlers.UpdateUser)
-	users.Delete("/:id", middleware.Protected(), handlers.DeleteUser)
+	users.Put("/:id", protected, h.UpdateUser)
+	users.Delete("/:id", protected, h.DeleteUser)
+	// Admin Routes
+	users.Get("/:id/storage", protected, middleware.AdminOnly(), h.GetUserStorage)
+	users.Put("/:id/storage-quota", protected, middleware.AdminOnly(), h.UpdateStorageQuota)
+
+	// Platform vocabulary
+	api.Get("/platforms", h.GetPlatforms)
+
+	// Public release calendar feed, authenticated by the token in the URL
+	api.Get("/calendar/:token", h.GetPublicReleaseCalendar)
 
 	// Game Routes
 	games := api.Group("/games")
 	// Protected Game Routes
-	games.Use(middleware.Protected())
-	games.Get("/", handlers.GetGames)
-	games.Get("/:id", handlers.GetGame)
-	games.Post("/", handlers.CreateGame)
-	games.Put("/:id", handlers.UpdateGame)
-	games.Delete("/:id", handlers.DeleteGame)
+	games.Use(protected)
+	games.Get("/", h.GetGames)
+	games.Get("/next", h.GetNextGame)
+	games.Get("/queue", h.GetQueue)
+	games.Put("/queue", h.ReorderQueue)
+	games.Get("/upcoming", h.GetUpcomingReleases)
+	games.Get("/releases.ics", h.GetReleaseCalendar)
+	games.Post("/releases/calendar-token", h.RotateCalendarToken)
+	games.Get("/:id", h.GetGame)
+	games.Post("/", h.CreateGame)
+	games.Put("/:id", h.UpdateGame)
+	games.Delete("/:id", h.DeleteGame)
+	games.Get("/:id/ownerships", h.GetOwnerships)
+	games.Post("/:id/ownerships", h.CreateOwnership)
+	games.Put("/:id/ownerships/:ownershipId", h.UpdateOwnership)
+	games.Delete("/:id/ownerships/:ownershipId", h.DeleteOwnership)
+	games.Get("/:id/achievements", h.GetAchievements)
+	games.Post("/:id/achievements", h.CreateAchievement)
+	games.Post("/:id/achievements/import", h.ImportAchievements)
+	games.Post("/:id/achievements/sync", h.SyncAchievements)
+	games.Put("/:id/achievements/:achievementId", h.UpdateAchievement)
+	games.Delete("/:id/achievements/:achievementId", h.DeleteAchievement)
+	games.Get("/:id/relations", h.GetGameRelations)
+	games.Post("/:id/relations", h.CreateGameRelation)
+	games.Delete("/:id/relations/:relationId", h.DeleteGameRelation)
+
+	// Series Routes
+	series := api.Group("/series")
+	series.Use(protected)
+	series.Get("/", h.GetSeries)
+	series.Get("/stats", h.GetSeriesStats)
+	series.Get("/:id", h.GetSeriesByID)
+	series.Post("/", h.CreateSeries)
+	series.Put("/:id", h.UpdateSeries)
+	series.Delete("/:id", h.DeleteSeries)
+	series.Post("/:id/games", h.AddGamesToSeries)
+	series.Delete("/:id/games/:gameId", h.RemoveGameFromSeries)
 
 	// Tag Routes
 	tags := api.Group("/tags")
-	tags.Get("/", handlers.GetTags)
+	tags.Get("/", h.GetTags)
 	// Protected Tag Routes
-	tags.Post("/", middleware.Protected(), handlers.CreateTag)
-	tags.Put("/:id", middleware.Protected(), handlers.UpdateTag)
-	tags.Delete("/:id", middleware.Protected(), handlers.DeleteTag)
+	tags.Post("/", protected, h.CreateTag)
+	tags.Put("/:id", protected, h.UpdateTag)
+	tags.Delete("/:id", protected, h.DeleteTag)
 
 	// Image Routes
 	// Post /api/upload
-	api.Post("/upload", middleware.Protected(), handlers.UploadImage)
+	api.Post("/upload", protected, h.UploadImage)
+	// Post /api/images/fetch
+	api.Post("/images/fetch", protected, h.FetchImage)
 	// Delete /api/images/:filename
-	api.Delete("/images/:filename", middleware.Protected(), handlers.DeleteImage)
+	api.Delete("/images/:filename", protected, h.DeleteImage)
 	// Tier List Routes
 	tierLists := api.Group("/tier-lists")
-	tierLists.Use(middleware.Protected())
-	tierLists.Get("/", handlers.GetTierLists)
-	tierLists.Get("/:id", handlers.GetTierList)
-	tierLists.Post("/", handlers.CreateTierList)
-	tierLists.Put("/:id", handlers.UpdateTierList)
-	tierLists.Delete("/:id", handlers.DeleteTierList)
+	tierLists.Use(protected)
+	tierLists.Get("/", h.GetTierLists)
+	tierLists.Post("/generate", h.GenerateTierList)
+	tierLists.Post("/consensus", h.GetConsensusTierList)
+	tierLists.Get("/invites", h.GetTierListInvites)
+	tierLists.Post("/invites/:inviteId/accept", h.AcceptTierListInvite)
+	tierLists.Delete("/invites/:inviteId", h.DeclineTierListInvite)
+	tierLists.Get("/:id", h.GetTierList)
+	tierLists.Post("/", h.CreateTierList)
+	tierLists.Put("/:id", h.UpdateTierList)
+	tierLists.Patch("/:id", h.PatchTierList)
+	tierLists.Delete("/:id", h.DeleteTierList)
+	tierLists.Get("/:id/render", h.RenderTierList)
+	tierLists.Post("/:id/clone", h.ForkTierList)
+	tierLists.Get("/:id/versions", h.GetTierListVersions)
+	tierLists.Get("/:id/versions/:version", h.GetTierListVersion)
+	tierLists.Post("/:id/versions/:version/restore", h.RestoreTierListVersion)
+	tierLists.Get("/:id/share", h.GetShareSettings)
+	tierLists.Put("/:id/share", h.UpdateShareSettings)
+	tierLists.Post("/:id/share/rotate", h.RotateShareLink)
+	tierLists.Delete("/:id/share", h.RevokeShareLink)
+	tierLists.Get("/:id/collaborators", h.GetCollaborators)
+	tierLists.Post("/:id/collaborators", h.InviteCollaborator)
+	tierLists.Put("/:id/collaborators/:userId", h.UpdateCollaborator)
+	tierLists.Delete("/:id/collaborators/:userId", h.RemoveCollaborator)
+	tierLists.Get("/:id/live", h.LiveTierListUpgrade, h.LiveTierList())
+
+	// Public, read-only access to shared tier lists
+	share := api.Group("/share")
+	share.Get("/tier-lists", h.GetPublicTierLists)
+	share.Get("/tier-lists/:slug", h.GetSharedTierList)
+	share.Post("/tier-lists/:slug/clone", protected, h.ForkSharedTierList)
+
+	// Tier List Template Routes
+	templates := api.Group("/tier-list-templates")
+	templates.Use(protected)
+	templates.Get("/", h.GetTemplates)
+	templates.Get("/:id", h.GetTemplate)
+	templates.Post("/", h.CreateTemplate)
+	templates.Put("/:id", h.UpdateTemplate)
+	templates.Delete("/:id", h.DeleteTemplate)
 }
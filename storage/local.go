@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Local stores objects as files under Dir. Only suitable for a single
+// instance, or several sharing a network filesystem.
+type Local struct {
+	Dir string
+	// BaseURL is where the API serves the files, used by SignedURL
+	BaseURL string
+}
+
+func NewLocal(dir, baseURL string) *Local {
+	return &Local{Dir: dir, BaseURL: strings.TrimRight(baseURL, "/")}
+}
+
+func (l *Local) path(name string) (string, error) {
+	if !ValidName(name) {
+		return "", ErrInvalidName
+	}
+	return filepath.Join(l.Dir, filepath.FromSlash(name)), nil
+}
+
+func (l *Local) Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error {
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// Write then rename so readers never see a partial file
+	tmp, err := os.CreateTemp(filepath.Dir(p), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func (l *Local) Get(ctx context.Context, name string) (io.ReadCloser, *Object, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil, ErrNotFound
+		}
+		return nil, nil, err
+	}
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		f.Close()
+		return nil, nil, ErrNotFound
+	}
+	return f, localObject(name, info), nil
+}
+
+func (l *Local) Delete(ctx context.Context, name string) error {
+	p, err := l.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(p); err != nil {
+		if os.IsNotExist(err) {
+			return ErrNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+func (l *Local) Stat(ctx context.Context, name string) (*Object, error) {
+	p, err := l.path(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(p)
+	if err != nil || info.IsDir() {
+		if err == nil || os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return localObject(name, info), nil
+}
+
+func (l *Local) List(ctx context.Context, prefix string) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(l.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && p == l.Dir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		rel, err := filepath.Rel(l.Dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if d.IsDir() {
+			// Only descend into directories that can hold matching names
+			if p != l.Dir && !strings.HasPrefix(name+"/", prefix) && !strings.HasPrefix(prefix, name+"/") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || !strings.HasPrefix(name, prefix) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil // Removed while walking
+		}
+		objects = append(objects, *localObject(name, info))
+		return nil
+	})
+	return objects, err
+}
+
+// SignedURL returns the public path the API serves the file from. Local
+// files need no signature; they expire only when deleted.
+func (l *Local) SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error) {
+	if !ValidName(name) {
+		return "", ErrInvalidName
+	}
+	return l.BaseURL + "/" + name, nil
+}
+
+func localObject(name string, info fs.FileInfo) *Object {
+	return &Object{
+		Name:        name,
+		Size:        info.Size(),
+		ContentType: mime.TypeByExtension(path.Ext(name)),
+		ModTime:     info.ModTime(),
+	}
+}
@@ -0,0 +1,81 @@
+// Package storage abstracts where uploaded images live so several API
+// instances can share them. Objects are addressed by flat names such as
+// "<uuid>_<date>_<game>.jpg"; a "/" groups derived objects under a prefix.
+package storage
+
+import (
+	"backlog-backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned when an object does not exist
+var ErrNotFound = errors.New("object not found")
+
+// ErrInvalidName is returned for names that could escape the storage root
+var ErrInvalidName = errors.New("invalid object name")
+
+// Object describes a stored file
+type Object struct {
+	Name        string
+	Size        int64
+	ContentType string
+	ModTime     time.Time
+}
+
+// Storage is a flat object store for uploaded images
+type Storage interface {
+	Put(ctx context.Context, name string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, name string) (io.ReadCloser, *Object, error)
+	Delete(ctx context.Context, name string) error
+	Stat(ctx context.Context, name string) (*Object, error)
+	// List returns every object whose name starts with prefix
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL returns a URL that serves the object directly until expiry
+	SignedURL(ctx context.Context, name string, expiry time.Duration) (string, error)
+}
+
+// FromConfig builds the configured storage:
+//
+//   - local (default): files under LocalDir, default ./images
+//   - s3: an S3-compatible bucket. Pointing the endpoint at a local MinIO is
+//     enough to develop against it.
+func FromConfig(cfg config.Storage) (Storage, error) {
+	switch cfg.Driver {
+	case "", "local":
+		dir := cfg.LocalDir
+		if dir == "" {
+			dir = "./images"
+		}
+		return NewLocal(dir, "/images"), nil
+	case "s3":
+		return NewS3(S3Config{
+			Endpoint:  cfg.S3.Endpoint,
+			Bucket:    cfg.S3.Bucket,
+			AccessKey: cfg.S3.AccessKeyID,
+			SecretKey: cfg.S3.SecretAccessKey,
+			Region:    cfg.S3.Region,
+			UseSSL:    cfg.S3.UseSSL,
+			Prefix:    cfg.S3.Prefix,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q (expected local or s3)", cfg.Driver)
+	}
+}
+
+// ValidName reports whether a name is safe to use as an object key
+func ValidName(name string) bool {
+	if name == "" || strings.Contains(name, "\\") || strings.HasPrefix(name, "/") {
+		return false
+	}
+	for _, part := range strings.Split(name, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
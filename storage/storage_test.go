@@ -0,0 +1,230 @@
+package storage
+
+import (
+	"backlog-backend/config"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"cover.jpg", true},
+		{"resized/cover_128x0_inside.jpg", true},
+		{"a/b/c.png", true},
+		{"", false},
+		{"/etc/passwd", false},
+		{"../cover.jpg", false},
+		{"resized/../../cover.jpg", false},
+		{"resized/./cover.jpg", false},
+		{"resized//cover.jpg", false},
+		{"resized/", false},
+		{`..\cover.jpg`, false},
+		{"..", false},
+	}
+	for _, tt := range tests {
+		if got := ValidName(tt.name); got != tt.want {
+			t.Errorf("ValidName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	s, err := FromConfig(config.Storage{Driver: "local", LocalDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.(*Local); !ok {
+		t.Errorf("FromConfig(local) = %T", s)
+	}
+	if _, err := FromConfig(config.Storage{Driver: "s3"}); err == nil {
+		t.Error("FromConfig(s3) without an endpoint succeeded")
+	}
+	if _, err := FromConfig(config.Storage{Driver: "ftp"}); err == nil {
+		t.Error("FromConfig(ftp) succeeded")
+	}
+}
+
+func TestLocal(t *testing.T) {
+	dir := t.TempDir()
+	s := NewLocal(dir, "/images/")
+	testStorage(t, s)
+
+	url, err := s.SignedURL(context.Background(), "resized/cover_128x0_inside.jpg", time.Hour)
+	if err != nil || url != "/images/resized/cover_128x0_inside.jpg" {
+		t.Errorf("SignedURL() = %q, %v", url, err)
+	}
+
+	// Temporary files of interrupted uploads are not listed
+	if err := os.WriteFile(filepath.Join(dir, ".upload-123"), []byte("partial"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	objects, err := s.List(context.Background(), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range objects {
+		if strings.HasPrefix(o.Name, ".") {
+			t.Errorf("List() returned the temporary file %s", o.Name)
+		}
+	}
+}
+
+func TestLocalMissingDir(t *testing.T) {
+	s := NewLocal(filepath.Join(t.TempDir(), "missing"), "/images")
+	if objects, err := s.List(context.Background(), ""); err != nil || len(objects) != 0 {
+		t.Errorf("List() of a missing directory = %v, %v", objects, err)
+	}
+}
+
+// TestS3 runs against a real bucket, skipped unless STORAGE_TEST_S3_ENDPOINT
+// is set. With the MinIO service of docker-compose.yml:
+//
+//	STORAGE_TEST_S3_ENDPOINT=localhost:9000 go test ./storage
+//
+// The credentials default to MinIO's; STORAGE_TEST_S3_BUCKET,
+// STORAGE_TEST_S3_ACCESS_KEY_ID, STORAGE_TEST_S3_SECRET_ACCESS_KEY and
+// STORAGE_TEST_S3_USE_SSL override them. Objects are written under a prefix
+// unique to the run and removed afterwards.
+func TestS3(t *testing.T) {
+	endpoint := os.Getenv("STORAGE_TEST_S3_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("STORAGE_TEST_S3_ENDPOINT is not set")
+	}
+	env := func(key, fallback string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return fallback
+	}
+	s, err := NewS3(S3Config{
+		Endpoint:  endpoint,
+		Bucket:    env("STORAGE_TEST_S3_BUCKET", "backlog-test"),
+		AccessKey: env("STORAGE_TEST_S3_ACCESS_KEY_ID", "minioadmin"),
+		SecretKey: env("STORAGE_TEST_S3_SECRET_ACCESS_KEY", "minioadmin"),
+		UseSSL:    env("STORAGE_TEST_S3_USE_SSL", "false") == "true",
+		Prefix:    fmt.Sprintf("/test-%d/", time.Now().UnixNano()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		objects, _ := s.List(context.Background(), "")
+		for _, o := range objects {
+			s.Delete(context.Background(), o.Name)
+		}
+	})
+	testStorage(t, s)
+
+	url, err := s.SignedURL(context.Background(), "cover.jpg", time.Minute)
+	if err != nil || !strings.Contains(url, "/test-") || !strings.Contains(url, "X-Amz-Signature=") {
+		t.Errorf("SignedURL() = %q, %v", url, err)
+	}
+}
+
+// testStorage checks the behavior every Storage shares. s must be empty.
+func testStorage(t *testing.T, s Storage) {
+	t.Helper()
+	ctx := context.Background()
+
+	put := func(name, content, contentType string) {
+		t.Helper()
+		if err := s.Put(ctx, name, strings.NewReader(content), int64(len(content)), contentType); err != nil {
+			t.Fatalf("Put(%s) = %v", name, err)
+		}
+	}
+	put("cover.jpg", "first", "image/jpeg")
+	put("cover.jpg", "jpeg data", "image/jpeg") // Overwrites
+	put("cover.thumb.webp", "webp", "image/webp")
+	put("resized/cover_128x0_inside.jpg", "resized", "image/jpeg")
+	put("other.png", "png data", "image/png")
+
+	r, obj, err := s.Get(ctx, "cover.jpg")
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+	data, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || string(data) != "jpeg data" {
+		t.Errorf("Get() read %q, %v", data, err)
+	}
+	if obj.Name != "cover.jpg" || obj.Size != 9 || obj.ContentType != "image/jpeg" || obj.ModTime.IsZero() {
+		t.Errorf("Get() object = %+v", obj)
+	}
+
+	obj, err = s.Stat(ctx, "resized/cover_128x0_inside.jpg")
+	if err != nil || obj.Name != "resized/cover_128x0_inside.jpg" || obj.Size != 7 {
+		t.Errorf("Stat() = %+v, %v", obj, err)
+	}
+
+	list := func(prefix string) []string {
+		t.Helper()
+		objects, err := s.List(ctx, prefix)
+		if err != nil {
+			t.Fatalf("List(%q) = %v", prefix, err)
+		}
+		var names []string
+		for _, o := range objects {
+			names = append(names, o.Name)
+		}
+		slices.Sort(names)
+		return names
+	}
+	for prefix, want := range map[string][]string{
+		"":               {"cover.jpg", "cover.thumb.webp", "other.png", "resized/cover_128x0_inside.jpg"},
+		"cover.":         {"cover.jpg", "cover.thumb.webp"},
+		"resized/":       {"resized/cover_128x0_inside.jpg"},
+		"resized/cover_": {"resized/cover_128x0_inside.jpg"},
+		"res":            {"resized/cover_128x0_inside.jpg"},
+		"missing":        nil,
+	} {
+		if got := list(prefix); !slices.Equal(got, want) {
+			t.Errorf("List(%q) = %v, want %v", prefix, got, want)
+		}
+	}
+
+	if err := s.Delete(ctx, "cover.jpg"); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+	if _, err := s.Stat(ctx, "cover.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat() after Delete() = %v, want %v", err, ErrNotFound)
+	}
+	if _, _, err := s.Get(ctx, "cover.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get() after Delete() = %v, want %v", err, ErrNotFound)
+	}
+	if err := s.Delete(ctx, "cover.jpg"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete() twice = %v, want %v", err, ErrNotFound)
+	}
+	// A prefix of stored names is not an object itself
+	if _, err := s.Stat(ctx, "resized"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Stat(resized) = %v, want %v", err, ErrNotFound)
+	}
+
+	for _, name := range []string{"../escape.jpg", "/abs.jpg", "resized/../../escape.jpg", ""} {
+		if err := s.Put(ctx, name, strings.NewReader("x"), 1, "image/jpeg"); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Put(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+		if _, _, err := s.Get(ctx, name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Get(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+		if _, err := s.Stat(ctx, name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Stat(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+		if err := s.Delete(ctx, name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Delete(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+		if _, err := s.SignedURL(ctx, name, time.Minute); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("SignedURL(%q) = %v, want %v", name, err, ErrInvalidName)
+		}
+	}
+}